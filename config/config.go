@@ -0,0 +1,326 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/pelletier/go-toml/v2"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// EnvConfigFile the config file to load, when the -config flag is not given
+const EnvConfigFile = "KNAVEBOT_CONFIG"
+
+// DefaultSQLitePath the database file, when sqlite3 is used without a dsn
+const DefaultSQLitePath = "/var/lib/sqlite/karma.db"
+
+// Config everything knave-bot can be configured with
+// Each setting is taken from, in increasing precedence: the defaults, the config file, env vars and flags
+type Config struct {
+	Server   Server           `yaml:"server" toml:"server"`
+	Database Database         `yaml:"database" toml:"database"`
+	Log      Log              `yaml:"log" toml:"log"`
+	Slack    Slack            `yaml:"slack" toml:"slack"`
+	Karma    karma.ProcConfig `yaml:"karma" toml:"karma"`
+	Words    Words            `yaml:"words" toml:"words"`
+}
+
+// Server the http server
+type Server struct {
+	Addr string `yaml:"addr" toml:"addr"`
+}
+
+// Database the karma database. For sqlite3 the DSN is a file path
+type Database struct {
+	Driver string `yaml:"driver" toml:"driver"`
+	DSN    string `yaml:"dsn" toml:"dsn"`
+}
+
+// Log the logrus level and formatter
+type Log struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+// Slack the app's secrets. These have no flags, so they don't show up in the process list
+type Slack struct {
+	SigningSecret string `yaml:"signing_secret" toml:"signing_secret"`
+	BotToken      string `yaml:"bot_token" toml:"bot_token"`
+}
+
+// Words the word list files, which add words to the built in insults and compliments or corpora of their own.
+// They are reloaded when they change, or on SIGHUP
+type Words struct {
+	Files   []string `yaml:"files" toml:"files"`
+	Grammar string   `yaml:"grammar" toml:"grammar"`
+}
+
+// Default the settings used when nothing else is configured
+func Default() Config {
+	return Config{
+		Server:   Server{Addr: ":8080"},
+		Database: Database{Driver: karma.DriverSQLite},
+		Log:      Log{Level: "info", Format: FormatText},
+		Karma:    karma.DefaultConfig,
+	}
+}
+
+// Load builds the config from the command line arguments (without the program name) and the environment.
+// It returns the arguments that are left after the flags, for subcommands
+func Load(args []string, getenv func(string) string) (Config, []string, error) {
+	c := Default()
+
+	fs := flag.NewFlagSet("knave-bot", flag.ContinueOnError)
+	file := fs.String("config", getenv(EnvConfigFile), "config file, .yaml .yml or .toml")
+	var flags Config
+	fs.StringVar(&flags.Server.Addr, "addr", "", "address to listen on")
+	fs.StringVar(&flags.Database.Driver, "db-driver", "", "database driver, sqlite3 or postgres")
+	fs.StringVar(&flags.Database.DSN, "db-dsn", "", "database file (sqlite3) or connection string (postgres)")
+	fs.StringVar(&flags.Log.Level, "log-level", "", "log level, trace debug info warn error")
+	fs.StringVar(&flags.Log.Format, "log-format", "", "log format, text or json")
+	fs.IntVar(&flags.Karma.SingleLimit, "single-limit", 0, "most karma that can be given at once")
+	fs.IntVar(&flags.Karma.DailyLimit, "daily-limit", 0, "most karma a user can give in a day")
+	fs.IntVar(&flags.Karma.TopUserDefault, "top-default", 0, "size of the leaderboards")
+	fs.IntVar(&flags.Karma.TopUserMax, "top-max", 0, "largest leaderboard that can be asked for")
+	fs.IntVar(&flags.Karma.PairCooldown, "pair-cooldown", 0, "minutes before giving the same person karma again, 0 is off")
+	fs.IntVar(&flags.Karma.PairLimit, "pair-limit", 0, "most karma one user can give another in the pair window, 0 is off")
+	fs.IntVar(&flags.Karma.PairWindow, "pair-window", 0, "hours the pair limit applies to")
+	fs.IntVar(&flags.Karma.RecipientDailyLimit, "recipient-daily-limit", 0, "most karma a user can receive in a day, 0 is off")
+	fs.IntVar(&flags.Karma.UndoWindow, "undo-window", 0, "minutes a giver has to undo their last karma, 0 is off")
+	fs.StringVar(&flags.Karma.Timezone, "timezone", "", "timezone that days start and end in, like America/New_York or Local")
+	fs.StringVar(&flags.Karma.DailyWindow, "daily-window", "", "when the daily limit frees up, day (at midnight) or rolling (24h later)")
+	fs.StringVar(&flags.Karma.GroupPolicy, "group-policy", "", "karma for a user group, @here or @channel is split between the members or replicated to each")
+	fs.StringVar(&flags.Karma.SeasonLength, "season-length", "", "when a running season rolls over to the next, off, month or quarter")
+	words := fs.String("words", "", "comma separated word list files, .json .yaml or .yml")
+	fs.StringVar(&flags.Words.Grammar, "grammar", "", "grammar file that /knave insults are made from, .json .yaml or .yml")
+	admins := fs.String("admins", "", "comma separated slack user ids that may change team settings")
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if *file != "" {
+		if err := loadFile(&c, *file); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	if err := loadEnv(&c, getenv); err != nil {
+		return Config{}, nil, err
+	}
+
+	// only the flags that were given override
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			c.Server.Addr = flags.Server.Addr
+		case "db-driver":
+			c.Database.Driver = flags.Database.Driver
+		case "db-dsn":
+			c.Database.DSN = flags.Database.DSN
+		case "log-level":
+			c.Log.Level = flags.Log.Level
+		case "log-format":
+			c.Log.Format = flags.Log.Format
+		case "single-limit":
+			c.Karma.SingleLimit = flags.Karma.SingleLimit
+		case "daily-limit":
+			c.Karma.DailyLimit = flags.Karma.DailyLimit
+		case "top-default":
+			c.Karma.TopUserDefault = flags.Karma.TopUserDefault
+		case "top-max":
+			c.Karma.TopUserMax = flags.Karma.TopUserMax
+		case "pair-cooldown":
+			c.Karma.PairCooldown = flags.Karma.PairCooldown
+		case "pair-limit":
+			c.Karma.PairLimit = flags.Karma.PairLimit
+		case "pair-window":
+			c.Karma.PairWindow = flags.Karma.PairWindow
+		case "recipient-daily-limit":
+			c.Karma.RecipientDailyLimit = flags.Karma.RecipientDailyLimit
+		case "undo-window":
+			c.Karma.UndoWindow = flags.Karma.UndoWindow
+		case "timezone":
+			c.Karma.Timezone = flags.Karma.Timezone
+		case "daily-window":
+			c.Karma.DailyWindow = flags.Karma.DailyWindow
+		case "group-policy":
+			c.Karma.GroupPolicy = flags.Karma.GroupPolicy
+		case "season-length":
+			c.Karma.SeasonLength = flags.Karma.SeasonLength
+		case "words":
+			c.Words.Files = splitList(*words)
+		case "grammar":
+			c.Words.Grammar = flags.Words.Grammar
+		case "admins":
+			c.Karma.Admins = splitList(*admins)
+		}
+	})
+
+	if c.Database.Driver == karma.DriverSQLite && c.Database.DSN == "" {
+		c.Database.DSN = DefaultSQLitePath
+	}
+
+	return c, fs.Args(), nil
+}
+
+// loadFile overlays the settings in a yaml or toml file. Unknown keys are an error, they are likely typos
+func loadFile(c *Config, file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+		// an empty file is fine
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	default:
+		return fmt.Errorf("config file %v must be .yaml, .yml or .toml", file)
+	}
+	if err != nil {
+		return fmt.Errorf("parsing config file %v: %w", file, err)
+	}
+
+	return nil
+}
+
+// loadEnv overlays the settings in the environment
+func loadEnv(c *Config, getenv func(string) string) error {
+	// PORT is what gin listened on before there was any config
+	if port := getenv("PORT"); port != "" {
+		c.Server.Addr = ":" + port
+	}
+
+	strs := map[string]*string{
+		"KNAVEBOT_ADDR":          &c.Server.Addr,
+		"KNAVEBOT_DB_DRIVER":     &c.Database.Driver,
+		"KNAVEBOT_DB_DSN":        &c.Database.DSN,
+		"KNAVEBOT_LOG_LEVEL":     &c.Log.Level,
+		"KNAVEBOT_LOG_FORMAT":    &c.Log.Format,
+		"KNAVEBOT_TIMEZONE":      &c.Karma.Timezone,
+		"KNAVEBOT_DAILY_WINDOW":  &c.Karma.DailyWindow,
+		"KNAVEBOT_GROUP_POLICY":  &c.Karma.GroupPolicy,
+		"KNAVEBOT_SEASON_LENGTH": &c.Karma.SeasonLength,
+		"KNAVEBOT_GRAMMAR":       &c.Words.Grammar,
+		"SLACK_SIGNING_SECRET":   &c.Slack.SigningSecret,
+		"SLACK_BOT_TOKEN":        &c.Slack.BotToken,
+	}
+	for env, s := range strs {
+		if v := getenv(env); v != "" {
+			*s = v
+		}
+	}
+
+	if admins := getenv("KNAVEBOT_ADMINS"); admins != "" {
+		c.Karma.Admins = splitList(admins)
+	}
+	if words := getenv("KNAVEBOT_WORDS"); words != "" {
+		c.Words.Files = splitList(words)
+	}
+
+	ints := map[string]*int{
+		"KNAVEBOT_SINGLE_LIMIT":          &c.Karma.SingleLimit,
+		"KNAVEBOT_DAILY_LIMIT":           &c.Karma.DailyLimit,
+		"KNAVEBOT_TOP_USER_DEFAULT":      &c.Karma.TopUserDefault,
+		"KNAVEBOT_TOP_USER_MAX":          &c.Karma.TopUserMax,
+		"KNAVEBOT_PAIR_COOLDOWN_MINUTES": &c.Karma.PairCooldown,
+		"KNAVEBOT_PAIR_LIMIT":            &c.Karma.PairLimit,
+		"KNAVEBOT_PAIR_WINDOW_HOURS":     &c.Karma.PairWindow,
+		"KNAVEBOT_RECIPIENT_DAILY_LIMIT": &c.Karma.RecipientDailyLimit,
+		"KNAVEBOT_UNDO_WINDOW_MINUTES":   &c.Karma.UndoWindow,
+	}
+	var errs []error
+	for env, i := range ints {
+		v := getenv(env)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v must be a number, got %q", env, v))
+			continue
+		}
+		*i = n
+	}
+
+	return errors.Join(errs...)
+}
+
+// splitList a comma separated list, without blanks
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Validate reports every setting that is missing or invalid, not just the first
+func (c Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := log.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	if c.Log.Format != FormatText && c.Log.Format != FormatJSON {
+		errs = append(errs, fmt.Errorf("log.format must be %v or %v, got %q", FormatText, FormatJSON, c.Log.Format))
+	}
+
+	if c.Slack.SigningSecret == "" {
+		errs = append(errs, errors.New("slack.signing_secret (SLACK_SIGNING_SECRET) is required to verify requests from slack"))
+	}
+
+	if err := c.Karma.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate the database settings, all that the migrate subcommand needs
+func (d Database) Validate() error {
+	var errs []error
+	switch d.Driver {
+	case karma.DriverSQLite, karma.DriverPostgres:
+	default:
+		errs = append(errs, fmt.Errorf("database.driver must be %v or %v, got %q", karma.DriverSQLite, karma.DriverPostgres, d.Driver))
+	}
+	if d.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(file, []byte(content), 0644)
+	assert.Nil(t, err)
+	return file
+}
+
+func TestLoadDefaults(t *testing.T) {
+	c, args, err := Load(nil, env(nil))
+	assert.Nil(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, ":8080", c.Server.Addr)
+	assert.Equal(t, karma.DriverSQLite, c.Database.Driver)
+	assert.Equal(t, DefaultSQLitePath, c.Database.DSN)
+	assert.Equal(t, karma.DefaultConfig, c.Karma)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "knave.yaml", `
+server:
+  addr: ":7000"
+database:
+  driver: postgres
+  dsn: postgres://file
+log:
+  level: debug
+karma:
+  single_limit: 3
+  daily_limit: 10
+  admins: [UFILE]
+words:
+  files: [file.yaml]
+  grammar: file.grammar.yaml
+`)
+
+	testcases := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected func(c *Config)
+	}{
+		{"file", []string{"-config", file}, nil, func(c *Config) {}},
+		{"config file from env", nil, map[string]string{EnvConfigFile: file}, func(c *Config) {}},
+		{"env over file", []string{"-config", file}, map[string]string{
+			"KNAVEBOT_ADDR":         ":7001",
+			"KNAVEBOT_DB_DSN":       "postgres://env",
+			"KNAVEBOT_SINGLE_LIMIT": "4",
+			"KNAVEBOT_ADMINS":       "UENV1, UENV2",
+			"KNAVEBOT_PAIR_LIMIT":   "12",
+			"KNAVEBOT_TIMEZONE":     "Europe/London",
+			"KNAVEBOT_GROUP_POLICY": "replicate",
+			"KNAVEBOT_WORDS":        "env.yaml,env.json",
+			"KNAVEBOT_GRAMMAR":      "env.grammar.json",
+		}, func(c *Config) {
+			c.Server.Addr = ":7001"
+			c.Database.DSN = "postgres://env"
+			c.Karma.SingleLimit = 4
+			c.Karma.Admins = []string{"UENV1", "UENV2"}
+			c.Karma.PairLimit = 12
+			c.Karma.Timezone = "Europe/London"
+			c.Karma.GroupPolicy = karma.PolicyReplicate
+			c.Words.Files = []string{"env.yaml", "env.json"}
+			c.Words.Grammar = "env.grammar.json"
+		}},
+		{"flags over env", []string{"-config", file, "-addr", ":7002", "-single-limit", "2", "-admins", "UFLAG", "-pair-cooldown", "15", "-daily-window", "rolling", "-words", "flag.yml", "-grammar", "flag.grammar.yml"}, map[string]string{
+			"KNAVEBOT_ADDR":                  ":7001",
+			"KNAVEBOT_SINGLE_LIMIT":          "4",
+			"KNAVEBOT_ADMINS":                "UENV1",
+			"KNAVEBOT_PAIR_COOLDOWN_MINUTES": "5",
+		}, func(c *Config) {
+			c.Server.Addr = ":7002"
+			c.Karma.SingleLimit = 2
+			c.Karma.Admins = []string{"UFLAG"}
+			c.Karma.PairCooldown = 15
+			c.Karma.DailyWindow = karma.WindowRolling
+			c.Words.Files = []string{"flag.yml"}
+			c.Words.Grammar = "flag.grammar.yml"
+		}},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			expected := Default()
+			expected.Server.Addr = ":7000"
+			expected.Database = Database{Driver: karma.DriverPostgres, DSN: "postgres://file"}
+			expected.Log.Level = "debug"
+			expected.Karma.SingleLimit = 3
+			expected.Karma.DailyLimit = 10
+			expected.Karma.Admins = []string{"UFILE"}
+			expected.Words.Files = []string{"file.yaml"}
+			expected.Words.Grammar = "file.grammar.yaml"
+			test.expected(&expected)
+
+			c, _, err := Load(test.args, env(test.env))
+			assert.Nil(t, err)
+			assert.Equal(t, expected, c)
+		})
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	file := writeFile(t, "knave.toml", `
+[slack]
+signing_secret = "shh"
+
+[karma]
+top_user_max = 20
+`)
+
+	c, _, err := Load([]string{"-config", file}, env(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "shh", c.Slack.SigningSecret)
+	assert.Equal(t, 20, c.Karma.TopUserMax)
+	assert.Equal(t, karma.DefaultConfig.DailyLimit, c.Karma.DailyLimit)
+}
+
+func TestLoadArgs(t *testing.T) {
+	_, args, err := Load([]string{"-db-dsn", "karma.db", "migrate", "down", "2"}, env(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"migrate", "down", "2"}, args)
+}
+
+func TestLoadErrors(t *testing.T) {
+	testcases := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"unknown flag", []string{"-nope"}, nil},
+		{"missing file", []string{"-config", "nope.yaml"}, nil},
+		{"unknown extension", []string{"-config", writeFile(t, "knave.ini", "")}, nil},
+		{"unknown yaml key", []string{"-config", writeFile(t, "knave.yaml", "karma:\n  singel_limit: 3\n")}, nil},
+		{"unknown toml key", []string{"-config", writeFile(t, "knave.toml", "[server]\nport = 80\n")}, nil},
+		{"env not a number", nil, map[string]string{"KNAVEBOT_DAILY_LIMIT": "lots"}},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := Load(test.args, env(test.env))
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Default()
+	valid.Database.DSN = DefaultSQLitePath
+	valid.Slack.SigningSecret = "shh"
+	assert.Nil(t, valid.Validate())
+
+	invalid := valid
+	invalid.Server.Addr = ""
+	invalid.Database.Driver = "mysql"
+	invalid.Log.Level = "loud"
+	invalid.Log.Format = "xml"
+	invalid.Slack.SigningSecret = ""
+	invalid.Karma.DailyLimit = 1
+
+	err := invalid.Validate()
+	assert.NotNil(t, err)
+	// every problem is reported at once
+	for _, msg := range []string{"server.addr", "database.driver", "log.level", "log.format", "signing_secret", "daily_limit"} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
@@ -0,0 +1,136 @@
+package karma
+
+import (
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// admin sub-commands
+const (
+	adminSet     = "set"
+	adminReset   = "reset"
+	adminBan     = "ban"
+	adminUnban   = "unban"
+	adminAdd     = "add"
+	adminRemove  = "remove"
+	adminList    = "list"
+	adminHistory = "history"
+)
+
+// IsAdmin whether the user is a global admin (config) or one of the team's admins
+func (p SlackProcessor) IsAdmin(team, user string) (bool, error) {
+	for _, a := range p.defaults.Admins {
+		if a == user {
+			return true, nil
+		}
+	}
+
+	return p.dao.IsAdmin(team, user)
+}
+
+// admin `/karma admin <set|reset|ban|unban|add|remove|list|history> ...`, for admins only
+func (p SlackProcessor) admin(team, callee string, words []string) (slack.Response, error) {
+	allowed, err := p.IsAdmin(team, callee)
+	if err != nil {
+		return slack.Response{}, err
+	}
+	if !allowed {
+		return slack.ErrorResponse(msgNotAdmin), nil
+	}
+
+	sub, ok := parseArg(words, 1)
+	if !ok {
+		return slack.DirectResponse(msgAdminUsage, cmdAdmin), nil
+	}
+
+	switch sub {
+	case adminList:
+		admins, err := p.dao.Admins(team)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		banned, err := p.dao.Banned(team)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgAdmins(p.defaults.Admins, admins, banned), ""), nil
+
+	case adminHistory:
+		n := p.leaderboardSize(words[1:])
+		entries, err := p.dao.Audit(team, n)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgAudit(entries), ""), nil
+	}
+
+	// the rest act on a user
+	target, ok := parseArgUser(words, 2)
+	if !ok {
+		return slack.DirectResponse(msgAdminUsage, cmdAdmin), nil
+	}
+
+	switch sub {
+	case adminSet:
+		k, ok := parseArgInt(words, 3, 0)
+		if !ok {
+			return slack.DirectResponse(msgAdminUsage, cmdAdmin), nil
+		}
+		if err := p.dao.SetKarma(team, target, k, callee); err != nil {
+			return slack.Response{}, err
+		}
+		return slack.ChannelResponse(MsgAdminSetKarma(callee, target, k)), nil
+
+	case adminReset:
+		if err := p.dao.SetKarma(team, target, 0, callee); err != nil {
+			return slack.Response{}, err
+		}
+		return slack.ChannelResponse(MsgAdminSetKarma(callee, target, 0)), nil
+
+	case adminBan:
+		if err := p.dao.Ban(team, target, callee); err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgBanned(target), ""), nil
+
+	case adminUnban:
+		if err := p.dao.Unban(team, target, callee); err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgUnbanned(target), ""), nil
+
+	case adminAdd:
+		if err := p.dao.AddAdmin(team, target, callee); err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgAdminAdded(target), ""), nil
+
+	case adminRemove:
+		if err := p.dao.RemoveAdmin(team, target, callee); err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgAdminRemoved(target), ""), nil
+	}
+
+	return slack.DirectResponse(msgAdminUsage, cmdAdmin), nil
+}
+
+// banned the response when the giver or the receiver of karma is banned, ok is false when neither is
+func (p SlackProcessor) banned(team, callee, target string) (slack.Response, bool, error) {
+	banned, err := p.dao.IsBanned(team, callee)
+	if err != nil {
+		return slack.Response{}, false, err
+	}
+	if banned {
+		return slack.ErrorResponse(msgBannedGiver), true, nil
+	}
+
+	banned, err = p.dao.IsBanned(team, target)
+	if err != nil {
+		return slack.Response{}, false, err
+	}
+	if banned {
+		return slack.ErrorResponse(MsgBannedTarget(target)), true, nil
+	}
+
+	return slack.Response{}, false, nil
+}
@@ -0,0 +1,191 @@
+package karma
+
+import (
+	"testing"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func adminCommand(text string) slack.CommandData {
+	c := command(text)
+	c.UserID = "UADMIN"
+	return c
+}
+
+func TestProcessAdmin(t *testing.T) {
+	p := happyMockProcessor()
+
+	testcases := []ProcessTestCase{
+		{
+			name:         "usage",
+			command:      adminCommand("admin"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgAdminUsage,
+		},
+		{
+			name:         "set",
+			command:      adminCommand("admin set <@USER> 10"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UADMIN> has set <@USER>'s karma to 10.",
+		},
+		{
+			name:         "set negative",
+			command:      adminCommand("admin set <@USER> -10"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UADMIN> has set <@USER>'s karma to -10.",
+		},
+		{
+			name:         "set without karma",
+			command:      adminCommand("admin set <@USER>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgAdminUsage,
+		},
+		{
+			name:         "reset",
+			command:      adminCommand("admin reset <@USER>"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UADMIN> has set <@USER>'s karma to 0.",
+		},
+		{
+			name:         "ban",
+			command:      adminCommand("admin ban <@USER>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgBanned("USER"),
+		},
+		{
+			name:         "ban nobody",
+			command:      adminCommand("admin ban"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgAdminUsage,
+		},
+		{
+			name:         "unban",
+			command:      adminCommand("admin unban <@UBANNED>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgUnbanned("UBANNED"),
+		},
+		{
+			name:         "add",
+			command:      adminCommand("admin add <@USER>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgAdminAdded("USER"),
+		},
+		{
+			name:         "remove",
+			command:      adminCommand("admin remove <@USER>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgAdminRemoved("USER"),
+		},
+		{
+			name:         "list",
+			command:      adminCommand("admin list"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "Global admins: nobody\nTeam admins: <@UADMIN>\nBanned: <@UBANNED>",
+		},
+		{
+			name:         "history",
+			command:      adminCommand("admin history 1"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "The recent changes to this team:\n<@UADMIN> config set `daily_limit` from default to 0 (2019-11-09)\n",
+		},
+		{
+			name:         "unknown sub-command",
+			command:      adminCommand("admin promote <@USER>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgAdminUsage,
+		},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, p, test)
+	}
+}
+
+func TestProcessAdminSetKarma(t *testing.T) {
+	dao := HappyDao()
+	var saved []interface{}
+	dao.SetKarmaMock = func(team, user string, karma int, actor string) error {
+		saved = append(saved, team, user, karma, actor)
+		return nil
+	}
+	p := mockProcessor(dao)
+
+	_, err := p.Process(adminCommand("admin set <@USER> 42"))
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"", "USER", 42, "UADMIN"}, saved)
+}
+
+func TestProcessAdminGlobal(t *testing.T) {
+	// UCALLER is an admin through the config, not the team
+	p := adminProcessor(HappyDao(), nil)
+
+	processHelper(t, p, ProcessTestCase{
+		name:         "global admin",
+		command:      command("admin list"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         "Global admins: <@UCALLER>\nTeam admins: <@UADMIN>\nBanned: <@UBANNED>",
+	})
+}
+
+func TestProcessAdminNotAdmin(t *testing.T) {
+	for _, text := range []string{"admin", "admin set <@USER> 10", "admin ban <@USER>", "admin add <@UCALLER>", "admin list"} {
+		processHelper(t, happyMockProcessor(), ProcessTestCase{
+			name:         text,
+			command:      command(text),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgNotAdmin,
+		})
+	}
+}
+
+func TestProcessAdminSad(t *testing.T) {
+	dao := SadDao()
+	dao.IsAdminMock = func(team, user string) (bool, error) { return true, nil }
+	p := mockProcessor(dao)
+
+	for _, text := range []string{"admin set <@USER> 10", "admin reset <@USER>", "admin ban <@USER>", "admin unban <@USER>", "admin add <@USER>", "admin remove <@USER>", "admin list", "admin history"} {
+		t.Run(text, func(t *testing.T) {
+			_, err := p.Process(adminCommand(text))
+			assert.NotNil(t, err)
+		})
+	}
+
+	_, err := sadMockProcessor().Process(command("admin list"))
+	assert.NotNil(t, err)
+}
+
+func TestProcessBanned(t *testing.T) {
+	p := happyMockProcessor()
+
+	testcases := []ProcessTestCase{
+		{
+			name:         "banned target",
+			command:      command("++ <@UBANNED>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgBannedTarget("UBANNED"),
+		},
+		{
+			name:         "banned target loses nothing",
+			command:      command("-- <@UBANNED> 2"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgBannedTarget("UBANNED"),
+		},
+	}
+	for _, test := range testcases {
+		processHelper(t, p, test)
+	}
+
+	banned := command("++ <@USER>")
+	banned.UserID = "UBANNED"
+	processHelper(t, p, ProcessTestCase{
+		name:         "banned giver",
+		command:      banned,
+		responseType: slack.ResponseType.Ephemeral,
+		text:         msgBannedGiver,
+	})
+
+	responses, err := p.ProcessMessage(message("<@UBANNED> ++"))
+	assert.Nil(t, err)
+	assert.Equal(t, MsgBannedTarget("UBANNED"), responses[0].Text)
+}
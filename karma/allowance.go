@@ -0,0 +1,83 @@
+package karma
+
+import (
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// rollingWindow how long karma counts towards the daily limit, when the team's daily_window is rolling
+const rollingWindow = 24 * time.Hour
+
+// allowance how much of the daily limit a user has used, and the karma that used it when the window is rolling
+type allowance struct {
+	limit   int
+	used    int
+	rolling bool
+	given   []Transaction
+}
+
+// allowance the user's allowance at now, in the team's daily window: the calendar day in the team's timezone,
+// or the last 24h of karma given
+func (p SlackProcessor) allowance(team, user string, now time.Time) (allowance, error) {
+	a := allowance{limit: p.config.DailyLimit, rolling: p.config.DailyWindow == WindowRolling}
+	if !a.rolling {
+		used, err := p.dao.GetDaily(team, user, now)
+		a.used = used
+		return a, err
+	}
+
+	given, err := p.dao.GivenSince(team, user, now.Add(-rollingWindow))
+	if err != nil {
+		return a, err
+	}
+	a.given = given
+	for _, t := range given {
+		a.used += Abs(t.Delta)
+	}
+	return a, nil
+}
+
+// overDailyLimit over is true, with a response saying when to try again, when delta is more than the callee has left today
+func (p SlackProcessor) overDailyLimit(team, callee string, delta int, now time.Time) (slack.Response, bool, error) {
+	a, err := p.allowance(team, callee, now)
+	if err != nil {
+		return slack.Response{}, false, err
+	}
+	if a.available() < Abs(delta) {
+		return slack.ErrorResponse(MsgOverDailyLimit(a.limit, a.used, a.available(), a.frees(Abs(delta), now), now)), true, nil
+	}
+	return slack.Response{}, false, nil
+}
+
+func (a allowance) available() int {
+	return a.limit - a.used
+}
+
+// frees when there will be room for amount more karma. Days free up all at once at midnight,
+// a rolling window as each karma turns 24h old
+func (a allowance) frees(amount int, now time.Time) time.Time {
+	if !a.rolling {
+		return startOfDay(now).AddDate(0, 0, 1)
+	}
+	at, _ := windowFrees(a.given, a.limit, amount, rollingWindow, now)
+	return at
+}
+
+// String the usage, and exactly when it frees up
+func (a allowance) String(now time.Time) string {
+	usage := MsgUserDailyLimit(a.used, a.available())
+	if a.rolling {
+		usage = MsgUserRollingLimit(a.used, a.available())
+	}
+	if a.used == 0 {
+		return usage
+	}
+
+	all := a.frees(a.limit, now)
+	if !a.rolling || len(a.given) == 1 {
+		return usage + " " + MsgAllowanceResets(all, now)
+	}
+	oldest := a.given[0]
+	return usage + " " + MsgAllowanceFrees(Abs(oldest.Delta), oldest.CreatedAt.Add(rollingWindow), all, now)
+}
@@ -0,0 +1,127 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+// windowProcessor a processor with the team's timezone and daily window. The caller has used daily karma today,
+// and given the transactions in the last 24h. It records the day and window that were looked up
+func windowProcessor(timezone, window string, daily int, given []Transaction) (SlackProcessor, *time.Time) {
+	var asked time.Time
+	dao := HappyDao()
+	dao.GetDailyMock = func(team, user string, date time.Time) (int, error) {
+		asked = date
+		return daily, nil
+	}
+	dao.GivenSinceMock = func(team, user string, since time.Time) ([]Transaction, error) {
+		asked = since
+		return given, nil
+	}
+
+	p := mockProcessor(dao)
+	p.config.Timezone = timezone
+	p.config.DailyWindow = window
+	p.defaults = p.config
+	return p, &asked
+}
+
+func TestAllowanceTimezone(t *testing.T) {
+	testcases := []struct {
+		timezone string
+		day      string
+		resets   time.Time
+	}{
+		{"UTC", "2026-05-04", time.Date(2026, time.May, 5, 0, 0, 0, 0, time.UTC)},
+		// it is already tomorrow in Tokyo
+		{"Asia/Tokyo", "2026-05-05", time.Date(2026, time.May, 5, 15, 0, 0, 0, time.UTC)},
+		{"America/New_York", "2026-05-04", time.Date(2026, time.May, 5, 4, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.timezone, func(t *testing.T) {
+			p, asked := windowProcessor(test.timezone, WindowDay, 5, nil)
+
+			processHelper(t, p, ProcessTestCase{
+				name:         "me",
+				command:      command("me"),
+				responseType: slack.ResponseType.Ephemeral,
+				text:         "<@UCALLER> has 5 karma.\n" + MsgUserDailyLimit(5, 20) + " " + MsgAllowanceResets(test.resets, mockNow),
+				attach:       true,
+			})
+			assert.Equal(t, test.day, IsoDate(*asked))
+
+			processHelper(t, p, ProcessTestCase{
+				name:         "within the limit",
+				command:      command("++ <@USER> 5"),
+				responseType: slack.ResponseType.InChannel,
+				text:         "<@UCALLER> is giving 5 karma to <@USER>. <@USER> has 6 karma.",
+				attach:       true,
+			})
+		})
+	}
+
+	p, _ := windowProcessor("Asia/Tokyo", WindowDay, 23, nil)
+	processHelper(t, p, ProcessTestCase{
+		name:         "try again at midnight in the team's timezone",
+		command:      command("++ <@USER> 3"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         MsgOverDailyLimit(25, 23, 2, time.Date(2026, time.May, 5, 15, 0, 0, 0, time.UTC), mockNow),
+	})
+}
+
+func TestAllowanceRolling(t *testing.T) {
+	ago := func(d time.Duration, delta int) Transaction {
+		return Transaction{From: "UCALLER", To: "USER", Delta: delta, CreatedAt: mockNow.Add(-d)}
+	}
+	given := []Transaction{ago(20*time.Hour, 3), ago(10*time.Hour, -2), ago(time.Hour, 1)}
+
+	p, asked := windowProcessor("UTC", WindowRolling, 0, given)
+	processHelper(t, p, ProcessTestCase{
+		name:         "me",
+		command:      command("me"),
+		responseType: slack.ResponseType.Ephemeral,
+		text: "<@UCALLER> has 5 karma.\n" + MsgUserRollingLimit(6, 19) + " " +
+			MsgAllowanceFrees(3, mockNow.Add(4*time.Hour), mockNow.Add(23*time.Hour), mockNow),
+		attach: true,
+	})
+	assert.Equal(t, mockNow.Add(-24*time.Hour), *asked)
+
+	p, _ = windowProcessor("UTC", WindowRolling, 0, given[2:])
+	processHelper(t, p, ProcessTestCase{
+		name:         "me with one karma given",
+		command:      command("me"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         "<@UCALLER> has 5 karma.\n" + MsgUserRollingLimit(1, 24) + " " + MsgAllowanceResets(mockNow.Add(23*time.Hour), mockNow),
+		attach:       true,
+	})
+
+	p, _ = windowProcessor("UTC", WindowRolling, 0, nil)
+	processHelper(t, p, ProcessTestCase{
+		name:         "me with nothing given",
+		command:      command("me"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         "<@UCALLER> has 5 karma.\n" + MsgUserRollingLimit(0, 25),
+		attach:       true,
+	})
+
+	p, _ = windowProcessor("UTC", WindowRolling, 0, given)
+	p.config.DailyLimit = 8
+	p.defaults = p.config
+	processHelper(t, p, ProcessTestCase{
+		name:         "over the limit until the oldest karma leaves the window",
+		command:      command("++ <@USER> 4"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         MsgOverDailyLimit(8, 6, 2, mockNow.Add(4*time.Hour), mockNow),
+	})
+	processHelper(t, p, ProcessTestCase{
+		name:         "within the limit",
+		command:      command("++ <@USER> 2"),
+		responseType: slack.ResponseType.InChannel,
+		text:         "<@UCALLER> is giving 2 karma to <@USER>. <@USER> has 3 karma.",
+		attach:       true,
+	})
+}
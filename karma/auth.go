@@ -0,0 +1,109 @@
+package karma
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// the gin context key of the api token that authenticated the request
+const contextToken = "api_token"
+
+// tokenActor the prefix of the audit trail's actor when a change is made over the REST api
+const tokenActor = "token:"
+
+// APIError the body of every error response from the REST api
+type APIError struct {
+	Status  int    `json:"status"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// abortError stops the request with a json error body
+func abortError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, APIError{
+		Status:  status,
+		Error:   http.StatusText(status),
+		Message: message,
+	})
+}
+
+// TokenAuth authenticates REST api requests by their bearer token
+type TokenAuth struct {
+	tokens TokenStore
+	abort  func(c *gin.Context, status int, message string)
+}
+
+// NewTokenAuth factory method
+func NewTokenAuth(tokens TokenStore) TokenAuth {
+	return TokenAuth{tokens: tokens, abort: abortError}
+}
+
+// enveloped the same authentication, with the error bodies of the v2 api
+func (a TokenAuth) enveloped() TokenAuth {
+	a.abort = abortEnvelope
+	return a
+}
+
+// Require middleware that only lets through requests with a bearer token that has the scope and the :team of the route
+func (a TokenAuth) Require(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="karmabot"`)
+			a.abort(c, 401, "Please pass an api token. Authorization: Bearer <token>")
+			return
+		}
+
+		token, err := a.tokens.TokenByHash(HashToken(bearer))
+		if errors.Is(err, ErrUnknownToken) {
+			c.Header("WWW-Authenticate", `Bearer realm="karmabot", error="invalid_token"`)
+			a.abort(c, 401, "The api token is unknown or was revoked.")
+			return
+		}
+		if err != nil {
+			log.Errorf("Unable to lookup api token. %v", err)
+			a.abort(c, 500, err.Error())
+			return
+		}
+
+		if team := c.Param("team"); !token.AllowsTeam(team) {
+			a.abort(c, 403, "The api token is not for team "+team)
+			return
+		}
+		if !token.Allows(scope) {
+			a.abort(c, 403, "The api token needs the "+string(scope)+" scope")
+			return
+		}
+
+		c.Set(contextToken, token)
+		c.Next()
+	}
+}
+
+// RequestActor who made a REST api request, for the audit trail: the api token that authenticated it, like `token:7 (deploy)`.
+// ok is false when no token did. It is never taken from the request itself, so nobody can make changes in someone else's name
+func RequestActor(c *gin.Context) (string, bool) {
+	token, ok := c.Value(contextToken).(APIToken)
+	if !ok {
+		return "", false
+	}
+	if token.Name == "" {
+		return fmt.Sprintf("%v%v", tokenActor, token.ID), true
+	}
+	return fmt.Sprintf("%v%v (%v)", tokenActor, token.ID, token.Name), true
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,187 @@
+package karma
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAuth(t *testing.T) {
+	testcases := []struct {
+		name     string
+		tokens   TokenStore
+		method   string
+		path     string
+		header   string
+		code     int
+		expected string
+	}{
+		{
+			name:     "no token",
+			tokens:   HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/davidvilla",
+			code:     401,
+			expected: apiError(401, "Please pass an api token. Authorization: Bearer <token>"),
+		},
+		{
+			name:     "not a bearer token",
+			tokens:   HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/davidvilla",
+			header:   "Basic " + mockReadToken,
+			code:     401,
+			expected: apiError(401, "Please pass an api token. Authorization: Bearer <token>"),
+		},
+		{
+			name:     "unknown token",
+			tokens:   HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/davidvilla",
+			header:   "Bearer nope",
+			code:     401,
+			expected: apiError(401, "The api token is unknown or was revoked."),
+		},
+		{
+			name:     "read",
+			tokens:   HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/davidvilla",
+			header:   "Bearer " + mockReadToken,
+			code:     200,
+			expected: "5",
+		},
+		{
+			name:     "another team",
+			tokens:   HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/redbulls/bwp",
+			header:   "Bearer " + mockAdminToken,
+			code:     403,
+			expected: apiError(403, "The api token is not for team redbulls"),
+		},
+		{
+			name:     "read can't write",
+			tokens:   HappyDao(),
+			method:   "PUT",
+			path:     "/karmabot/v1/team/nycfc/davidvilla?delta=1",
+			header:   "Bearer " + mockReadToken,
+			code:     403,
+			expected: apiError(403, "The api token needs the write scope"),
+		},
+		{
+			name:     "write",
+			tokens:   HappyDao(),
+			method:   "DELETE",
+			path:     "/karmabot/v1/team/nycfc/davidvilla",
+			header:   "Bearer " + mockWriteToken,
+			code:     200,
+			expected: "0",
+		},
+		{
+			name:     "write can read",
+			tokens:   HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/davidvilla",
+			header:   "bearer " + mockWriteToken,
+			code:     200,
+			expected: "5",
+		},
+		{
+			name:     "write can't change settings",
+			tokens:   HappyDao(),
+			method:   "DELETE",
+			path:     "/karmabot/v1/team/nycfc/config/daily_limit",
+			header:   "Bearer " + mockWriteToken,
+			code:     403,
+			expected: apiError(403, "The api token needs the admin scope"),
+		},
+		{
+			name:     "every team",
+			tokens:   MockDAO{TokenByHashMock: func(hash string) (APIToken, error) { return APIToken{Team: AllTeams, Scopes: []Scope{ScopeRead}}, nil }},
+			method:   "GET",
+			path:     "/karmabot/v1/team/redbulls/bwp",
+			header:   "Bearer " + mockReadToken,
+			code:     200,
+			expected: "5",
+		},
+		{
+			name:     "error",
+			tokens:   SadDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/davidvilla",
+			header:   "Bearer " + mockReadToken,
+			code:     500,
+			expected: apiError(500, "TokenByHashMock"),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			dao := HappyDao()
+			r := gin.New()
+			BindRoutes(r.Group("/karmabot"), r.Group("/knavebot"), NewHandler(mockProcessor(dao), dao, nil), func(c *gin.Context) {}, NewTokenAuth(test.tokens))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(test.method, test.path, nil)
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, test.expected, w.Body.String())
+			if test.code == 401 {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("read, write")
+	assert.Nil(t, err)
+	assert.Equal(t, []Scope{ScopeRead, ScopeWrite}, scopes)
+
+	for _, s := range []string{"", ",", "read,delete"} {
+		_, err := ParseScopes(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestTokenAllows(t *testing.T) {
+	testcases := []struct {
+		scopes  []Scope
+		allowed []Scope
+		denied  []Scope
+	}{
+		{[]Scope{ScopeRead}, []Scope{ScopeRead}, []Scope{ScopeWrite, ScopeAdmin}},
+		{[]Scope{ScopeWrite}, []Scope{ScopeRead, ScopeWrite}, []Scope{ScopeAdmin}},
+		{[]Scope{ScopeAdmin}, []Scope{ScopeRead, ScopeWrite, ScopeAdmin}, nil},
+	}
+
+	for _, test := range testcases {
+		token := APIToken{Scopes: test.scopes}
+		for _, s := range test.allowed {
+			assert.True(t, token.Allows(s), "%v allows %v", test.scopes, s)
+		}
+		for _, s := range test.denied {
+			assert.False(t, token.Allows(s), "%v denies %v", test.scopes, s)
+		}
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	token, hash, err := NewToken()
+	assert.Nil(t, err)
+	assert.Regexp(t, "^kb_[0-9a-f]{64}$", token)
+	assert.Equal(t, HashToken(token), hash)
+	assert.NotEqual(t, token, hash)
+
+	other, _, err := NewToken()
+	assert.Nil(t, err)
+	assert.NotEqual(t, token, other)
+}
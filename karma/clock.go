@@ -0,0 +1,16 @@
+package karma
+
+import "time"
+
+// Clock tells the time. Tests inject a fixed one, so that days and windows are deterministic
+type Clock func() time.Time
+
+// SystemClock the real time
+var SystemClock Clock = time.Now
+
+// FixedClock a clock that has stopped at t
+func FixedClock(t time.Time) Clock {
+	return func() time.Time {
+		return t
+	}
+}
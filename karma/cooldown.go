@@ -0,0 +1,111 @@
+package karma
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// pairRules the rules that stop two users farming karma from each other: the cooldown and limit per giver and receiver,
+// and the receiver's daily cap. rejected is true, with a response saying when to try again, when one is broken
+func (p SlackProcessor) pairRules(team, callee, target string, delta int, now time.Time) (slack.Response, bool, error) {
+	amount := Abs(delta)
+	cooldown := time.Duration(p.config.PairCooldown) * time.Minute
+	window := time.Duration(p.config.PairWindow) * time.Hour
+
+	if cooldown > 0 || p.config.PairLimit > 0 {
+		if p.config.PairLimit > 0 && amount > p.config.PairLimit {
+			return slack.ErrorResponse(MsgDeltaLimit(p.config.PairLimit)), true, nil
+		}
+
+		lookback := cooldown
+		if p.config.PairLimit > 0 && window > lookback {
+			lookback = window
+		}
+		history, err := p.dao.PairHistory(team, callee, target, now.Add(-lookback))
+		if err != nil {
+			return slack.Response{}, false, err
+		}
+
+		if cooldown > 0 && len(history) > 0 {
+			next := history[len(history)-1].CreatedAt.Add(cooldown)
+			if now.Before(next) {
+				return slack.ErrorResponse(MsgPairCooldown(target, next, now)), true, nil
+			}
+		}
+
+		if p.config.PairLimit > 0 {
+			if next, ok := windowFrees(history, p.config.PairLimit, amount, window, now); !ok {
+				return slack.ErrorResponse(MsgPairLimit(target, p.config.PairLimit, p.config.PairWindow, next, now)), true, nil
+			}
+		}
+	}
+
+	// only karma given counts towards the recipient's cap, taking it away is always allowed
+	if delta > 0 && p.config.RecipientDailyLimit > 0 {
+		today := startOfDay(now)
+		received, err := p.dao.ReceivedSince(team, target, today)
+		if err != nil {
+			return slack.Response{}, false, err
+		}
+		if received+delta > p.config.RecipientDailyLimit {
+			return slack.ErrorResponse(MsgRecipientLimit(target, p.config.RecipientDailyLimit, today.AddDate(0, 0, 1), now)), true, nil
+		}
+	}
+
+	return slack.Response{}, false, nil
+}
+
+// windowFrees whether amount more karma fits under a limit on the karma in a sliding window, like the pair limit.
+// When it doesn't, the time it will, which is when enough of the oldest karma has left the window
+func windowFrees(history []Transaction, limit, amount int, window time.Duration, now time.Time) (time.Time, bool) {
+	since := now.Add(-window)
+	var inWindow []Transaction
+	total := 0
+	for _, t := range history {
+		if !t.CreatedAt.Before(since) {
+			inWindow = append(inWindow, t)
+			total += Abs(t.Delta)
+		}
+	}
+	if total+amount <= limit {
+		return time.Time{}, true
+	}
+
+	for _, t := range inWindow {
+		total -= Abs(t.Delta)
+		if total+amount <= limit {
+			return t.CreatedAt.Add(window), false
+		}
+	}
+	return now.Add(window), false
+}
+
+// startOfDay midnight at the start of t's day
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// msgWhen a time that slack shows in the reader's own timezone, and how long until then
+func msgWhen(at, now time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%v> (in %v)", at.Unix(), at.UTC().Format("2006-01-02 15:04 UTC"), msgDuration(at.Sub(now)))
+}
+
+// msgDuration a wait rounded up to the minute, like 2h 5m
+func msgDuration(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+
+	h, m := minutes/60, minutes%60
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%vm", m)
+	case m == 0:
+		return fmt.Sprintf("%vh", h)
+	}
+	return fmt.Sprintf("%vh %vm", h, m)
+}
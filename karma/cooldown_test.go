@@ -0,0 +1,130 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/shakespeare"
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+var cooldownNow = time.Date(2026, time.May, 4, 15, 0, 0, 0, time.UTC)
+
+// pairProcessor a processor with the pair rules on, and the pair's recent history
+func pairProcessor(config ProcConfig, history []Transaction, received int) SlackProcessor {
+	dao := HappyDao()
+	dao.PairHistoryMock = func(team, from, to string, since time.Time) ([]Transaction, error) {
+		var r []Transaction
+		for _, t := range history {
+			if !t.CreatedAt.Before(since) {
+				r = append(r, t)
+			}
+		}
+		return r, nil
+	}
+	dao.ReceivedSinceMock = func(team, user string, since time.Time) (int, error) {
+		return received, nil
+	}
+
+	return NewProcessor(config, dao,
+		shakespeare.New("insult", "", nil),
+		shakespeare.New("compliment", "", nil))
+}
+
+func ago(d time.Duration, delta int) Transaction {
+	return Transaction{From: "UCALLER", To: "USER", Delta: delta, CreatedAt: cooldownNow.Add(-d)}
+}
+
+func TestPairRules(t *testing.T) {
+	config := DefaultConfig
+	config.PairCooldown = 10
+	config.PairLimit = 6
+	config.PairWindow = 24
+	config.RecipientDailyLimit = 8
+
+	testcases := []struct {
+		name     string
+		history  []Transaction
+		received int
+		delta    int
+		expected string
+	}{
+		{"first time", nil, 0, 3, ""},
+		{"after the cooldown", []Transaction{ago(11*time.Minute, 1)}, 0, 3, ""},
+		{"during the cooldown", []Transaction{ago(2*time.Minute, 1)}, 0, 3,
+			MsgPairCooldown("USER", cooldownNow.Add(8*time.Minute), cooldownNow)},
+		{"within the pair limit", []Transaction{ago(5*time.Hour, 3), ago(time.Hour, 1)}, 0, 2, ""},
+		{"over the pair limit", []Transaction{ago(5*time.Hour, 3), ago(time.Hour, -2)}, 0, 2,
+			MsgPairLimit("USER", 6, 24, cooldownNow.Add(19*time.Hour), cooldownNow)},
+		{"over the pair limit until more leaves the window", []Transaction{ago(5*time.Hour, 1), ago(3*time.Hour, 4)}, 0, 3,
+			MsgPairLimit("USER", 6, 24, cooldownNow.Add(21*time.Hour), cooldownNow)},
+		{"old karma has left the window", []Transaction{ago(25*time.Hour, 5)}, 0, 5, ""},
+		{"more than the pair limit at once", nil, 0, -7, MsgDeltaLimit(6)},
+		{"within the recipient limit", nil, 5, 3, ""},
+		{"over the recipient limit", nil, 6, 3,
+			MsgRecipientLimit("USER", 8, time.Date(2026, time.May, 5, 0, 0, 0, 0, time.UTC), cooldownNow)},
+		{"taking karma from a full recipient", nil, 8, -3, ""},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			p := pairProcessor(config, test.history, test.received)
+
+			resp, rejected, err := p.pairRules("TEAM", "UCALLER", "USER", test.delta, cooldownNow)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected != "", rejected)
+			assert.Equal(t, test.expected, resp.Text)
+		})
+	}
+}
+
+func TestPairRulesOff(t *testing.T) {
+	// DefaultConfig has every pair rule off, so the dao is never asked
+	p := mockProcessor(SadDao())
+
+	_, rejected, err := p.pairRules("TEAM", "UCALLER", "USER", 5, cooldownNow)
+	assert.Nil(t, err)
+	assert.False(t, rejected)
+}
+
+func TestProcessPairCooldown(t *testing.T) {
+	config := DefaultConfig
+	config.PairCooldown = 10
+	p := pairProcessor(config, []Transaction{{CreatedAt: time.Now().Add(-2 * time.Minute)}}, 0)
+
+	resp, err := p.Process(command("++ <@USER>"))
+	assert.Nil(t, err)
+	assert.Equal(t, slack.ResponseType.Ephemeral, resp.ResponseType)
+	assert.Contains(t, resp.Text, "You just gave <@USER> karma")
+	assert.Contains(t, resp.Text, "(in 8m)")
+
+	responses, err := p.ProcessMessage(message("<@USER> ++"))
+	assert.Nil(t, err)
+	assert.Contains(t, responses[0].Text, "You just gave <@USER> karma")
+
+	sad := SadDao()
+	sad.GetDailyMock = HappyDao().GetDailyMock
+	sad.TeamConfigMock = HappyDao().TeamConfigMock
+	sad.IsBannedMock = HappyDao().IsBannedMock
+	_, err = NewProcessor(config, sad, nil, nil).Process(command("++ <@USER>"))
+	assert.NotNil(t, err)
+}
+
+func TestMsgWhen(t *testing.T) {
+	at := cooldownNow.Add(2*time.Hour + 5*time.Minute)
+	assert.Equal(t, "<!date^1777914300^{date_short_pretty} at {time}|2026-05-04 17:05 UTC> (in 2h 5m)", msgWhen(at, cooldownNow))
+
+	testcases := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{time.Second, "1m"},
+		{7*time.Minute + time.Second, "8m"},
+		{3 * time.Hour, "3h"},
+		{24*time.Hour + 59*time.Minute, "24h 59m"},
+	}
+	for _, test := range testcases {
+		assert.Equal(t, test.expected, msgDuration(test.d), test.d)
+	}
+}
@@ -3,6 +3,8 @@ package karma
 import (
 	"database/sql"
 	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/icemanblues/knave-bot/slack"
@@ -15,21 +17,120 @@ type UserKarma struct {
 	Karma int
 }
 
+// Transaction a single movement of karma, as recorded in the ledger
+// From is empty when karma was adjusted outside of slack (the REST api)
+type Transaction struct {
+	ID        int64
+	Team      string
+	From      string
+	To        string
+	Channel   string
+	Delta     int
+	Message   string
+	CreatedAt time.Time
+}
+
+// ChannelKarma karma activity in a single channel
+type ChannelKarma struct {
+	Channel      string
+	Transactions int
+	Karma        int
+}
+
+// Report summary of the karma moved between users of a team during a period
+// Gainers are ranked by net karma received, Givers by karma given (not taken) away
+type Report struct {
+	Team         string
+	From         time.Time
+	To           time.Time
+	Gainers      []UserKarma
+	Givers       []UserKarma
+	Channels     []ChannelKarma
+	Transactions int
+	Net          int
+	Moved        int
+}
+
+// AuditEntry a change made by an admin, what was changed and how
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Team      string    `json:"team"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // DAO Data Access Object for the Karma database
 type DAO interface {
 	GetKarma(team, user string) (int, error)
 	UpdateKarma(team, user string, delta int) (int, error)
 	DeleteKarma(team, user string) (int, error)
 	Top(team string, n int) ([]UserKarma, error)
+	Bottom(team string, n int) ([]UserKarma, error)
 	Usage(slack.CommandData, slack.Response) error
 	GetDaily(team, user string, date time.Time) (int, error)
 	UpdateDaily(team, user string, date time.Time, karma int) (int, error)
-	UpdateKarmaDaily(team, callee, target string, delta int, date time.Time) (int, error)
+	UpdateKarmaDaily(t Transaction, date time.Time) (int, error)
+	UpdateKarmaDailyMany(ts []Transaction, date time.Time) ([]int, error)
+	Received(team, user string, limit, offset int) ([]Transaction, error)
+	Given(team, user string, limit, offset int) ([]Transaction, error)
+	Reasons(team, user string, n int) ([]Transaction, error)
+	PairHistory(team, from, to string, since time.Time) ([]Transaction, error)
+	ReceivedSince(team, user string, since time.Time) (int, error)
+	GivenSince(team, user string, since time.Time) ([]Transaction, error)
+	LastGiven(team, user string, since time.Time) ([]Transaction, error)
+	Undo(ts []Transaction, date time.Time) ([]int, error)
+	GetThing(team, thing string) (int, error)
+	UpdateThingDaily(t Transaction, date time.Time) (int, error)
+	TopThings(team string, n int) ([]ThingKarma, error)
+	CurrentSeason(team string) (Season, error)
+	GetSeason(team, name string) (Season, error)
+	Seasons(team string) ([]Season, error)
+	RunningSeasons() ([]Season, error)
+	StartSeason(team, name string, at time.Time) (Season, error)
+	EndSeason(team string, at time.Time) (Season, error)
+	RolloverSeason(team, next string, at time.Time) (Season, error)
+	SeasonTop(team string, since time.Time, n int) ([]UserKarma, error)
+	ArchivedTop(team, season string, n int) ([]UserKarma, error)
+	TeamWords(team string) ([]TeamWord, error)
+	AddTeamWord(w TeamWord) (TeamWord, error)
+	ApproveTeamWord(team string, id int64, actor string) (TeamWord, error)
+	RemoveTeamWord(team string, id int64, actor string) (TeamWord, error)
+	RebuildKarma(team string) error
+	Report(team string, from, to time.Time, n int) (Report, error)
+	TeamConfig(team string) (map[string]string, error)
+	SetTeamConfig(team, setting, value, actor string) error
+	ResetTeamConfig(team, setting, actor string) error
+	Audit(team string, n int) ([]AuditEntry, error)
+	SetKarma(team, user string, karma int, actor string) error
+	IsAdmin(team, user string) (bool, error)
+	Admins(team string) ([]string, error)
+	AddAdmin(team, user, actor string) error
+	RemoveAdmin(team, user, actor string) error
+	IsBanned(team, user string) (bool, error)
+	Banned(team string) ([]string, error)
+	Ban(team, user, actor string) error
+	Unban(team, user, actor string) error
+	TokenStore
+	CreateToken(t APIToken, hash string) (APIToken, error)
+	Tokens(team string) ([]APIToken, error)
+	RevokeToken(id int64) error
 }
 
+// ledger messages for karma wiped out by DeleteKarma, and set outright by an admin
+const (
+	ledgerReset = "reset"
+	ledgerSet   = "set"
+)
+
+// isoDate layout used for daily usage and report dates
+const isoDate = "2006-01-02"
+
 // IsoDate converts a time object to 2006-01-02 format
 func IsoDate(t time.Time) string {
-	return t.Format("2006-01-02")
+	return t.Format(isoDate)
 }
 
 // stringAttachment we use a *string so it can be nullable. This is to match the db column
@@ -50,19 +151,22 @@ func stringAttachment(att []slack.Attachments) *string {
 	return &s
 }
 
-// SQLiteDAO a SQLite imlpementation of the Karma database
-type SQLiteDAO struct {
-	db *sql.DB
+// SQLDAO a database/sql implementation of the Karma database, shared by SQLite and PostgreSQL.
+// The queries are written with ? placeholders, bind rewrites them for drivers that number theirs
+type SQLDAO struct {
+	db   *sql.DB
+	bind func(string) string
+	now  Clock
 }
 
 // GetKarma returns the karma value for the user in a given team
-func (dao SQLiteDAO) GetKarma(team, user string) (int, error) {
-	row := dao.db.QueryRow(`
+func (dao SQLDAO) GetKarma(team, user string) (int, error) {
+	row := dao.db.QueryRow(dao.bind(`
 		SELECT k.karma
 		FROM   karma k
 		WHERE  k.team = ?
-		AND	   k.user = ?;
-	`, team, user)
+		AND	   k."user" = ?;
+	`), team, user)
 
 	var k int
 	err := row.Scan(&k)
@@ -75,7 +179,7 @@ func (dao SQLiteDAO) GetKarma(team, user string) (int, error) {
 }
 
 // UpdateKarma adds (or removes) karma from a user in a given team (workspace)
-func (dao SQLiteDAO) UpdateKarma(workspace, user string, delta int) (int, error) {
+func (dao SQLDAO) UpdateKarma(workspace, user string, delta int) (int, error) {
 	tx, err := dao.db.Begin()
 	if err != nil {
 		return 0, err
@@ -88,6 +192,12 @@ func (dao SQLiteDAO) UpdateKarma(workspace, user string, delta int) (int, error)
 		return 0, err
 	}
 
+	err = dao.txLedger(tx, Transaction{Team: workspace, To: user, Delta: delta})
+	if err != nil {
+		log.Error("Could not record karma in the ledger.", workspace, user, delta, err)
+		return 0, err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		log.Error("Unable to commit the UpdateKarma transaction", workspace, user, delta, err)
@@ -97,59 +207,105 @@ func (dao SQLiteDAO) UpdateKarma(workspace, user string, delta int) (int, error)
 	return dao.GetKarma(workspace, user)
 }
 
-func (dao SQLiteDAO) txUpdateKarma(tx *sql.Tx, workspace, user string, delta int) error {
-	_, err := tx.Exec(`
+func (dao SQLDAO) txUpdateKarma(tx *sql.Tx, workspace, user string, delta int) error {
+	_, err := tx.Exec(dao.bind(`
 		INSERT INTO karma
-		(team, user, karma, created_at, updated_at)
+		(team, "user", karma, created_at, updated_at)
 		VALUES
 		(?, ?, ?, ?, ?)
-		ON CONFLICT(team, user) DO UPDATE SET 
-		karma = karma + excluded.karma,
+		ON CONFLICT(team, "user") DO UPDATE SET 
+		karma = karma.karma + excluded.karma,
 		updated_at = excluded.updated_at;
-	`, workspace, user, delta, time.Now(), time.Now())
+	`), workspace, user, delta, dao.now(), dao.now())
+
+	return err
+}
+
+// txLedger records a karma transaction in the ledger, at t.CreatedAt when it is set and now otherwise
+func (dao SQLDAO) txLedger(tx *sql.Tx, t Transaction) error {
+	at := t.CreatedAt
+	if at.IsZero() {
+		at = dao.now()
+	}
+
+	_, err := tx.Exec(dao.bind(`
+		INSERT INTO karma_ledger
+		(team, from_user, to_user, channel, delta, message, created_at)
+		VALUES
+		(?, ?, ?, ?, ?, ?, ?);
+	`), t.Team, t.From, t.To, t.Channel, t.Delta, t.Message, at.UTC())
 
 	return err
 }
 
 // DeleteKarma resets all karma for a given user in a given team to zer0
-func (dao SQLiteDAO) DeleteKarma(team, user string) (int, error) {
-	_, err := dao.db.Exec(`
+func (dao SQLDAO) DeleteKarma(team, user string) (int, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// the ledger needs to know how much karma was wiped out
+	var k int
+	err = tx.QueryRow(dao.bind(`
+		SELECT k.karma
+		FROM   karma k
+		WHERE  k.team = ?
+		AND	   k."user" = ?;
+	`), team, user).Scan(&k)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	_, err = tx.Exec(dao.bind(`
 		DELETE FROM karma
 		WHERE  team = ?
-		AND	   user = ?;
-	`, team, user)
+		AND	   "user" = ?;
+	`), team, user)
 	if err != nil {
 		return 0, err
 	}
 
+	if k != 0 {
+		err = dao.txLedger(tx, Transaction{Team: team, To: user, Delta: -k, Message: ledgerReset})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return 0, nil
 }
 
 // Usage tracks the usage of karma by pairing the request with the response
-func (dao SQLiteDAO) Usage(data slack.CommandData, res slack.Response) error {
+func (dao SQLDAO) Usage(data slack.CommandData, res slack.Response) error {
 	s := stringAttachment(res.Attachments)
 
-	_, err := dao.db.Exec(`
+	_, err := dao.db.Exec(dao.bind(`
 		INSERT INTO usage
-		(command, text, enterprise, team, channel, user, created_at, response, response_type, attachments)
+		(command, text, enterprise, team, channel, "user", created_at, response, response_type, attachments)
 		VALUES
 		(?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
-	`, data.Command, data.Text, data.EnterpriseID, data.TeamID, data.ChannelID, data.UserID, time.Now(), res.Text, res.ResponseType, s)
+	`), data.Command, data.Text, data.EnterpriseID, data.TeamID, data.ChannelID, data.UserID, dao.now(), res.Text, res.ResponseType, s)
 
 	return err
 }
 
 // Top returns the top n users (ordered by karma) from a given team
-func (dao SQLiteDAO) Top(team string, n int) ([]UserKarma, error) {
-	rows, err := dao.db.Query(`
-		SELECT		k.user, 
+func (dao SQLDAO) Top(team string, n int) ([]UserKarma, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		k."user", 
 					k.karma
 		FROM  		karma k
 		WHERE 		k.team = ?
 		AND			k.karma >= 0
 		ORDER BY	k.karma DESC, k.updated_at DESC
 		LIMIT ?;
-	`, team, n)
+	`), team, n)
 	if err != nil {
 		return nil, err
 	}
@@ -172,15 +328,32 @@ func (dao SQLiteDAO) Top(team string, n int) ([]UserKarma, error) {
 	return topUsers, nil
 }
 
+// Bottom returns the bottom n users (ordered by karma, lowest first) from a given team
+func (dao SQLDAO) Bottom(team string, n int) ([]UserKarma, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		k."user", 
+					k.karma
+		FROM  		karma k
+		WHERE 		k.team = ?
+		ORDER BY	k.karma ASC, k.updated_at DESC
+		LIMIT ?;
+	`), team, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanUserKarma(rows, n)
+}
+
 // GetDaily return the amount of karma the team/user has gives/taken for a day
-func (dao SQLiteDAO) GetDaily(team, user string, date time.Time) (int, error) {
-	row := dao.db.QueryRow(`
+func (dao SQLDAO) GetDaily(team, user string, date time.Time) (int, error) {
+	row := dao.db.QueryRow(dao.bind(`
 		SELECT du.usage
 		FROM   daily_usage du
 		WHERE  du.team = ?
-		AND	   du.user = ?
+		AND	   du."user" = ?
 		AND	   du.daily = ?;
-	`, team, user, IsoDate(date))
+	`), team, user, IsoDate(date))
 
 	var u int
 	err := row.Scan(&u)
@@ -193,7 +366,7 @@ func (dao SQLiteDAO) GetDaily(team, user string, date time.Time) (int, error) {
 }
 
 // UpdateDaily adds karma to team/user's daily usage count
-func (dao SQLiteDAO) UpdateDaily(team, user string, date time.Time, karma int) (int, error) {
+func (dao SQLDAO) UpdateDaily(team, user string, date time.Time, karma int) (int, error) {
 	tx, err := dao.db.Begin()
 	if err != nil {
 		return 0, err
@@ -214,54 +387,379 @@ func (dao SQLiteDAO) UpdateDaily(team, user string, date time.Time, karma int) (
 	return dao.GetDaily(team, user, date)
 }
 
-func (dao SQLiteDAO) txUpdateDaily(tx *sql.Tx, team, user string, date time.Time, karma int) error {
-	_, err := tx.Exec(`
+func (dao SQLDAO) txUpdateDaily(tx *sql.Tx, team, user string, date time.Time, karma int) error {
+	_, err := tx.Exec(dao.bind(`
 		INSERT INTO daily_usage
-		(team, user, daily, usage, created_at, updated_at)
+		(team, "user", daily, usage, created_at, updated_at)
 		VALUES
 		(?, ?, ?, ?, ?, ?)
-		ON CONFLICT(team, user, daily) DO UPDATE SET 
-		usage = usage + excluded.usage,
+		ON CONFLICT(team, "user", daily) DO UPDATE SET 
+		usage = daily_usage.usage + excluded.usage,
 		updated_at = excluded.updated_at;
-	`, team, user, IsoDate(date), karma, time.Now(), time.Now())
+	`), team, user, IsoDate(date), karma, dao.now(), dao.now())
 
 	return err
 }
 
-// UpdateKarmaDaily updates the karma total and daily usage at the same time, returns new karma
-// the target receives karma
-// the callee has their daily usage incremented
-func (dao SQLiteDAO) UpdateKarmaDaily(team, callee, target string, delta int, date time.Time) (int, error) {
-	tx, err := dao.db.Begin()
+// UpdateKarmaDaily updates the karma total, daily usage and ledger at the same time, returns new karma
+// the target (t.To) receives karma
+// the callee (t.From) has their daily usage incremented
+func (dao SQLDAO) UpdateKarmaDaily(t Transaction, date time.Time) (int, error) {
+	ks, err := dao.UpdateKarmaDailyMany([]Transaction{t}, date)
 	if err != nil {
 		return 0, err
 	}
+
+	return ks[0], nil
+}
+
+// UpdateKarmaDailyMany is UpdateKarmaDaily for several transactions in one database transaction, all or nothing.
+// Returns the new karma of each target, in order
+func (dao SQLDAO) UpdateKarmaDailyMany(ts []Transaction, date time.Time) ([]int, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return nil, err
+	}
 	defer tx.Rollback()
 
-	err = dao.txUpdateKarma(tx, team, target, delta)
+	// one time for all of them, so they can be told apart from the next karma given, like by LastGiven
+	at := dao.now()
+	for _, t := range ts {
+		t.CreatedAt = at
+		if err := dao.txUpdateKarma(tx, t.Team, t.To, t.Delta); err != nil {
+			return nil, err
+		}
+		if err := dao.txUpdateDaily(tx, t.Team, t.From, date, Abs(t.Delta)); err != nil {
+			return nil, err
+		}
+		if err := dao.txLedger(tx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	ks := make([]int, 0, len(ts))
+	for _, t := range ts {
+		var k int
+		err := tx.QueryRow(dao.bind(`
+			SELECT k.karma
+			FROM   karma k
+			WHERE  k.team = ?
+			AND	   k."user" = ?;
+		`), t.Team, t.To).Scan(&k)
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Received pages through the karma a user has received, most recent first
+func (dao SQLDAO) Received(team, user string, limit, offset int) ([]Transaction, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		l.id, l.team, l.from_user, l.to_user, l.channel, l.delta, l.message, l.created_at
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.to_user = ?
+		ORDER BY	l.created_at DESC, l.id DESC
+		LIMIT ? OFFSET ?;
+	`), team, user, limit, offset)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	err = dao.txUpdateDaily(tx, team, callee, date, Abs(delta))
+	return scanTransactions(rows)
+}
+
+// Given pages through the karma a user has given (or taken) from others, most recent first
+func (dao SQLDAO) Given(team, user string, limit, offset int) ([]Transaction, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		l.id, l.team, l.from_user, l.to_user, l.channel, l.delta, l.message, l.created_at
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user = ?
+		ORDER BY	l.created_at DESC, l.id DESC
+		LIMIT ? OFFSET ?;
+	`), team, user, limit, offset)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	err = tx.Commit()
+	return scanTransactions(rows)
+}
+
+// PairHistory the karma one user has given (or taken) from another since a time, oldest first
+func (dao SQLDAO) PairHistory(team, from, to string, since time.Time) ([]Transaction, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		l.id, l.team, l.from_user, l.to_user, l.channel, l.delta, l.message, l.created_at
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user = ?
+		AND			l.to_user = ?
+		AND			l.created_at >= ?`+notUndone+`
+		ORDER BY	l.created_at, l.id;
+	`), team, from, to, since.UTC())
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	k, err := dao.GetKarma(team, target)
+	return scanTransactions(rows)
+}
+
+// ReceivedSince the karma a user has been given by other users since a time. Karma taken away doesn't count
+func (dao SQLDAO) ReceivedSince(team, user string, since time.Time) (int, error) {
+	var k int
+	err := dao.db.QueryRow(dao.bind(`
+		SELECT	COALESCE(SUM(l.delta), 0)
+		FROM	karma_ledger l
+		WHERE	l.team = ?
+		AND		l.to_user = ?
+		AND		l.from_user <> ''
+		AND		l.delta > 0
+		AND		l.created_at >= ?`+notUndone+`;
+	`), team, user, since.UTC()).Scan(&k)
+
+	return k, err
+}
+
+// GivenSince the karma a user has given (or taken) from anyone, or any thing, since a time, oldest first
+func (dao SQLDAO) GivenSince(team, user string, since time.Time) ([]Transaction, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		g.id, g.team, g.from_user, g.to_user, g.channel, g.delta, g.message, g.created_at
+		FROM		(
+			SELECT	l.id, l.team, l.from_user, l.to_user, l.channel, l.delta, l.message, l.created_at
+			FROM	karma_ledger l
+			WHERE	l.team = ?
+			AND		l.from_user = ?
+			AND		l.created_at >= ?`+notUndone+`
+			UNION ALL
+			SELECT	t.id, t.team, t.from_user, t.thing, t.channel, t.delta, t.message, t.created_at
+			FROM	thing_ledger t
+			WHERE	t.team = ?
+			AND		t.from_user = ?
+			AND		t.created_at >= ?
+		) g
+		ORDER BY	g.created_at, g.id;
+	`), team, user, since.UTC(), team, user, since.UTC())
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return k, nil
+	return scanTransactions(rows)
+}
+
+// Reasons the n most recent transactions, with a reason, that a user received from another user
+func (dao SQLDAO) Reasons(team, user string, n int) ([]Transaction, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		l.id, l.team, l.from_user, l.to_user, l.channel, l.delta, l.message, l.created_at
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.to_user = ?
+		AND			l.from_user != ''
+		AND			l.message != ''`+notUndone+`
+		ORDER BY	l.created_at DESC, l.id DESC
+		LIMIT ?;
+	`), team, user, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTransactions(rows)
+}
+
+func scanTransactions(rows *sql.Rows) ([]Transaction, error) {
+	defer rows.Close()
+
+	transactions := make([]Transaction, 0)
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.Team, &t.From, &t.To, &t.Channel, &t.Delta, &t.Message, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// RebuildKarma recomputes the karma totals of a team from its ledger
+func (dao SQLDAO) RebuildKarma(team string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(dao.bind(`
+		DELETE FROM karma
+		WHERE  team = ?;
+	`), team)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(dao.bind(`
+		INSERT INTO karma
+		(team, "user", karma, created_at, updated_at)
+		SELECT		l.team, l.to_user, SUM(l.delta), ?, ?
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		GROUP BY	l.team, l.to_user;
+	`), dao.now(), dao.now(), team)
+	if err != nil {
+		log.Error("Unable to rebuild karma from the ledger", team, err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Report summarizes the ledger for a team between from (inclusive) and to (exclusive), top n of each ranking.
+// Only karma moved by slack users is counted, not REST adjustments or resets
+func (dao SQLDAO) Report(team string, from, to time.Time, n int) (Report, error) {
+	r := Report{Team: team, From: from, To: to}
+	from, to = from.UTC(), to.UTC()
+
+	row := dao.db.QueryRow(dao.bind(`
+		SELECT	COUNT(*), COALESCE(SUM(l.delta), 0), COALESCE(SUM(ABS(l.delta)), 0)
+		FROM	karma_ledger l
+		WHERE	l.team = ?
+		AND		l.from_user != ''
+		AND		l.created_at >= ?
+		AND		l.created_at < ?`+notUndone+`;
+	`), team, from, to)
+	if err := row.Scan(&r.Transactions, &r.Net, &r.Moved); err != nil {
+		return Report{}, err
+	}
+
+	gainers, err := dao.db.Query(dao.bind(`
+		SELECT		l.to_user, SUM(l.delta) AS karma
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user != ''
+		AND			l.created_at >= ?
+		AND			l.created_at < ?`+notUndone+`
+		GROUP BY	l.to_user
+		HAVING		SUM(l.delta) > 0
+		ORDER BY	karma DESC, l.to_user
+		LIMIT ?;
+	`), team, from, to, n)
+	if err != nil {
+		return Report{}, err
+	}
+	if r.Gainers, err = scanUserKarma(gainers, n); err != nil {
+		return Report{}, err
+	}
+
+	givers, err := dao.db.Query(dao.bind(`
+		SELECT		l.from_user, SUM(l.delta) AS karma
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user != ''
+		AND			l.delta > 0
+		AND			l.created_at >= ?
+		AND			l.created_at < ?`+notUndone+`
+		GROUP BY	l.from_user
+		ORDER BY	karma DESC, l.from_user
+		LIMIT ?;
+	`), team, from, to, n)
+	if err != nil {
+		return Report{}, err
+	}
+	if r.Givers, err = scanUserKarma(givers, n); err != nil {
+		return Report{}, err
+	}
+
+	channels, err := dao.db.Query(dao.bind(`
+		SELECT		l.channel, COUNT(*) AS transactions, SUM(ABS(l.delta))
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user != ''
+		AND			l.channel != ''
+		AND			l.created_at >= ?
+		AND			l.created_at < ?`+notUndone+`
+		GROUP BY	l.channel
+		ORDER BY	transactions DESC, l.channel
+		LIMIT ?;
+	`), team, from, to, n)
+	if err != nil {
+		return Report{}, err
+	}
+	defer channels.Close()
+
+	r.Channels = make([]ChannelKarma, 0, n)
+	for channels.Next() {
+		var c ChannelKarma
+		if err := channels.Scan(&c.Channel, &c.Transactions, &c.Karma); err != nil {
+			return Report{}, err
+		}
+		r.Channels = append(r.Channels, c)
+	}
+	if err := channels.Err(); err != nil {
+		return Report{}, err
+	}
+
+	return r, nil
+}
+
+func scanUserKarma(rows *sql.Rows, n int) ([]UserKarma, error) {
+	defer rows.Close()
+
+	users := make([]UserKarma, 0, n)
+	for rows.Next() {
+		var u UserKarma
+		if err := rows.Scan(&u.User, &u.Karma); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
 }
 
-// NewDao factory method
-func NewDao(db *sql.DB) SQLiteDAO {
-	return SQLiteDAO{db}
+// NewDao factory method for a SQLite database
+func NewDao(db *sql.DB) SQLDAO {
+	return SQLDAO{db, bindQuestion, SystemClock}
+}
+
+// NewPostgresDao factory method for a PostgreSQL database
+func NewPostgresDao(db *sql.DB) SQLDAO {
+	return SQLDAO{db, bindDollar, SystemClock}
+}
+
+// WithClock the same dao, recording changes at the times the clock tells
+func (dao SQLDAO) WithClock(now Clock) SQLDAO {
+	dao.now = now
+	return dao
+}
+
+// bindQuestion leaves the ? placeholders alone (SQLite)
+func bindQuestion(query string) string {
+	return query
+}
+
+// bindDollar numbers the ? placeholders $1, $2... (PostgreSQL)
+func bindDollar(query string) string {
+	sb := strings.Builder{}
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
 }
@@ -0,0 +1,279 @@
+package karma
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Season a named stretch of time, like 2026Q2, with a leaderboard of its own. EndedAt is nil while it is running
+type Season struct {
+	ID        int64      `json:"id"`
+	Team      string     `json:"team"`
+	Name      string     `json:"name"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
+
+// ErrNoSeason the team has no season running, or none by that name
+var ErrNoSeason = errors.New("no season")
+
+// ErrSeasonRunning a season can't start while another is running
+var ErrSeasonRunning = errors.New("a season is already running")
+
+// ErrSeasonExists the team already has a season by that name
+var ErrSeasonExists = errors.New("a season by that name already exists")
+
+// CurrentSeason the team's running season, ErrNoSeason when there isn't one
+func (dao SQLDAO) CurrentSeason(team string) (Season, error) {
+	return dao.txCurrentSeason(dao.db, team)
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (dao SQLDAO) txCurrentSeason(tx queryRower, team string) (Season, error) {
+	row := tx.QueryRow(dao.bind(`
+		SELECT	id, team, name, started_at, ended_at
+		FROM	seasons
+		WHERE	team = ?
+		AND		ended_at IS NULL;
+	`), team)
+
+	s, err := scanSeason(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Season{}, ErrNoSeason
+	}
+	return s, err
+}
+
+// GetSeason the team's season by name, running or ended. ErrNoSeason when there isn't one
+func (dao SQLDAO) GetSeason(team, name string) (Season, error) {
+	row := dao.db.QueryRow(dao.bind(`
+		SELECT	id, team, name, started_at, ended_at
+		FROM	seasons
+		WHERE	team = ?
+		AND		name = ?;
+	`), team, name)
+
+	s, err := scanSeason(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Season{}, ErrNoSeason
+	}
+	return s, err
+}
+
+// Seasons every season of a team, the newest first
+func (dao SQLDAO) Seasons(team string) ([]Season, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		id, team, name, started_at, ended_at
+		FROM		seasons
+		WHERE		team = ?
+		ORDER BY	started_at DESC, id DESC;
+	`), team)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSeasons(rows)
+}
+
+// RunningSeasons the running season of every team that has one
+func (dao SQLDAO) RunningSeasons() ([]Season, error) {
+	rows, err := dao.db.Query(`
+		SELECT		id, team, name, started_at, ended_at
+		FROM		seasons
+		WHERE		ended_at IS NULL
+		ORDER BY	team;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanSeasons(rows)
+}
+
+// StartSeason starts a season for the team at a time. ErrSeasonRunning when one already is, ErrSeasonExists when the name is taken
+func (dao SQLDAO) StartSeason(team, name string, at time.Time) (Season, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return Season{}, err
+	}
+	defer tx.Rollback()
+
+	s, err := dao.txStartSeason(tx, team, name, at)
+	if err != nil {
+		return Season{}, err
+	}
+
+	return s, tx.Commit()
+}
+
+func (dao SQLDAO) txStartSeason(tx *sql.Tx, team, name string, at time.Time) (Season, error) {
+	if _, err := dao.txCurrentSeason(tx, team); !errors.Is(err, ErrNoSeason) {
+		if err == nil {
+			return Season{}, ErrSeasonRunning
+		}
+		return Season{}, err
+	}
+
+	var exists int
+	err := tx.QueryRow(dao.bind(`
+		SELECT	COUNT(*)
+		FROM	seasons
+		WHERE	team = ?
+		AND		name = ?;
+	`), team, name).Scan(&exists)
+	if err != nil {
+		return Season{}, err
+	}
+	if exists > 0 {
+		return Season{}, ErrSeasonExists
+	}
+
+	s := Season{Team: team, Name: name, StartedAt: at.UTC()}
+	err = tx.QueryRow(dao.bind(`
+		INSERT INTO seasons (team, name, started_at)
+		VALUES (?, ?, ?)
+		RETURNING id;
+	`), team, name, s.StartedAt).Scan(&s.ID)
+
+	return s, err
+}
+
+// EndSeason ends the team's running season at a time, archiving its final standings. ErrNoSeason when there isn't one
+func (dao SQLDAO) EndSeason(team string, at time.Time) (Season, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return Season{}, err
+	}
+	defer tx.Rollback()
+
+	s, err := dao.txEndSeason(tx, team, at)
+	if err != nil {
+		return Season{}, err
+	}
+
+	return s, tx.Commit()
+}
+
+func (dao SQLDAO) txEndSeason(tx *sql.Tx, team string, at time.Time) (Season, error) {
+	s, err := dao.txCurrentSeason(tx, team)
+	if err != nil {
+		return Season{}, err
+	}
+
+	// the karma people gave each other in the season. admin changes and opening balances have no giver
+	_, err = tx.Exec(dao.bind(`
+		INSERT INTO season_standings (team, season, "user", karma)
+		SELECT		l.team, ?, l.to_user, SUM(l.delta)
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user != ''
+		AND			l.created_at >= ?
+		AND			l.created_at < ?`+notUndone+`
+		GROUP BY	l.team, l.to_user;
+	`), s.Name, team, s.StartedAt.UTC(), at.UTC())
+	if err != nil {
+		return Season{}, err
+	}
+
+	_, err = tx.Exec(dao.bind(`
+		UPDATE	seasons
+		SET		ended_at = ?
+		WHERE	id = ?;
+	`), at.UTC(), s.ID)
+	if err != nil {
+		return Season{}, err
+	}
+
+	ended := at.UTC()
+	s.EndedAt = &ended
+	return s, nil
+}
+
+// RolloverSeason ends the team's running season and starts the next one at the same time, all at once
+func (dao SQLDAO) RolloverSeason(team, next string, at time.Time) (Season, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return Season{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err := dao.txEndSeason(tx, team, at); err != nil {
+		return Season{}, err
+	}
+	s, err := dao.txStartSeason(tx, team, next, at)
+	if err != nil {
+		return Season{}, err
+	}
+
+	return s, tx.Commit()
+}
+
+// SeasonTop the n users with the most karma given to them since a time, the leaderboard of a running season
+func (dao SQLDAO) SeasonTop(team string, since time.Time, n int) ([]UserKarma, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		l.to_user, SUM(l.delta) AS karma
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user != ''
+		AND			l.created_at >= ?`+notUndone+`
+		GROUP BY	l.to_user
+		HAVING		SUM(l.delta) >= 0
+		ORDER BY	karma DESC, MAX(l.created_at) DESC
+		LIMIT ?;
+	`), team, since.UTC(), n)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanUserKarma(rows, n)
+}
+
+// ArchivedTop the n users with the most karma in a season that has ended
+func (dao SQLDAO) ArchivedTop(team, season string, n int) ([]UserKarma, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		s."user", s.karma
+		FROM		season_standings s
+		WHERE		s.team = ?
+		AND			s.season = ?
+		AND			s.karma >= 0
+		ORDER BY	s.karma DESC, s."user"
+		LIMIT ?;
+	`), team, season, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanUserKarma(rows, n)
+}
+
+func scanSeasons(rows *sql.Rows) ([]Season, error) {
+	defer rows.Close()
+
+	seasons := make([]Season, 0)
+	for rows.Next() {
+		s, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, s)
+	}
+
+	return seasons, rows.Err()
+}
+
+func scanSeason(row interface{ Scan(...interface{}) error }) (Season, error) {
+	var s Season
+	var ended sql.NullTime
+	if err := row.Scan(&s.ID, &s.Team, &s.Name, &s.StartedAt, &ended); err != nil {
+		return Season{}, err
+	}
+
+	if ended.Valid {
+		s.EndedAt = &ended.Time
+	}
+	return s, nil
+}
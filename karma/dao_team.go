@@ -0,0 +1,326 @@
+package karma
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// audit actions
+const (
+	auditConfigSet   = "config set"
+	auditConfigReset = "config reset"
+	auditKarmaSet    = "karma set"
+	auditAdminAdd    = "admin add"
+	auditAdminRemove = "admin remove"
+	auditBan         = "ban"
+	auditUnban       = "unban"
+)
+
+// team membership tables
+const (
+	tableAdmins = "team_admins"
+	tableBans   = "team_bans"
+)
+
+// TeamConfig the settings a team has overridden, by name
+func (dao SQLDAO) TeamConfig(team string) (map[string]string, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT	setting, value
+		FROM	team_config
+		WHERE	team = ?;
+	`), team)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var setting, value string
+		if err := rows.Scan(&setting, &value); err != nil {
+			return nil, err
+		}
+		settings[setting] = value
+	}
+
+	return settings, rows.Err()
+}
+
+// SetTeamConfig overrides a setting for a team, and records who did it in the audit trail
+func (dao SQLDAO) SetTeamConfig(team, setting, value, actor string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	old, err := dao.txTeamSetting(tx, team, setting)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(dao.bind(`
+		INSERT INTO team_config (team, setting, value, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(team, setting)
+		DO UPDATE SET value = excluded.value, updated_by = excluded.updated_by, updated_at = excluded.updated_at;
+	`), team, setting, value, actor, dao.now().UTC())
+	if err != nil {
+		return err
+	}
+
+	detail := fmt.Sprintf("from %v to %v", old, value)
+	if err := dao.txAudit(tx, team, actor, auditConfigSet, setting, detail); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetTeamConfig puts a setting back to the global default for a team, and records who did it in the audit trail
+func (dao SQLDAO) ResetTeamConfig(team, setting, actor string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	old, err := dao.txTeamSetting(tx, team, setting)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(dao.bind(`
+		DELETE FROM team_config
+		WHERE	team = ?
+		AND		setting = ?;
+	`), team, setting)
+	if err != nil {
+		return err
+	}
+
+	detail := fmt.Sprintf("from %v to default", old)
+	if err := dao.txAudit(tx, team, actor, auditConfigReset, setting, detail); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// txTeamSetting the current value of a team's setting, "default" when it isn't overridden
+func (dao SQLDAO) txTeamSetting(tx *sql.Tx, team, setting string) (string, error) {
+	var value string
+	err := tx.QueryRow(dao.bind(`
+		SELECT	value
+		FROM	team_config
+		WHERE	team = ?
+		AND		setting = ?;
+	`), team, setting).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "default", nil
+	}
+
+	return value, err
+}
+
+func (dao SQLDAO) txAudit(tx *sql.Tx, team, actor, action, target, detail string) error {
+	_, err := tx.Exec(dao.bind(`
+		INSERT INTO audit (team, actor, action, target, detail, created_at)
+		VALUES (?, ?, ?, ?, ?, ?);
+	`), team, actor, action, target, detail, dao.now().UTC())
+
+	return err
+}
+
+// Audit the latest n changes made to a team, newest first
+func (dao SQLDAO) Audit(team string, n int) ([]AuditEntry, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		id, team, actor, action, target, detail, created_at
+		FROM		audit
+		WHERE		team = ?
+		ORDER BY	created_at DESC, id DESC
+		LIMIT		?;
+	`), team, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0, n)
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Team, &e.Actor, &e.Action, &e.Target, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// SetKarma sets a user's karma outright, and records which admin did it in the audit trail
+func (dao SQLDAO) SetKarma(team, user string, karma int, actor string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var k int
+	err = tx.QueryRow(dao.bind(`
+		SELECT k.karma
+		FROM   karma k
+		WHERE  k.team = ?
+		AND	   k."user" = ?;
+	`), team, user).Scan(&k)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// the ledger gets the difference, so the totals can still be rebuilt
+	if delta := karma - k; delta != 0 {
+		if err := dao.txUpdateKarma(tx, team, user, delta); err != nil {
+			return err
+		}
+
+		message := ledgerSet
+		if karma == 0 {
+			message = ledgerReset
+		}
+		if err := dao.txLedger(tx, Transaction{Team: team, To: user, Delta: delta, Message: message}); err != nil {
+			return err
+		}
+	}
+
+	detail := fmt.Sprintf("from %v to %v", k, karma)
+	if err := dao.txAudit(tx, team, actor, auditKarmaSet, user, detail); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsAdmin whether the user was made an admin of the team. The global admins are in the config
+func (dao SQLDAO) IsAdmin(team, user string) (bool, error) {
+	return dao.isMember(tableAdmins, team, user)
+}
+
+// Admins the users that were made admins of the team
+func (dao SQLDAO) Admins(team string) ([]string, error) {
+	return dao.members(tableAdmins, team)
+}
+
+// AddAdmin makes the user an admin of the team
+func (dao SQLDAO) AddAdmin(team, user, actor string) error {
+	return dao.addMember(tableAdmins, team, user, actor, auditAdminAdd)
+}
+
+// RemoveAdmin makes the user an ordinary member of the team again
+func (dao SQLDAO) RemoveAdmin(team, user, actor string) error {
+	return dao.removeMember(tableAdmins, team, user, actor, auditAdminRemove)
+}
+
+// IsBanned whether the user is banned from giving and receiving karma in the team
+func (dao SQLDAO) IsBanned(team, user string) (bool, error) {
+	return dao.isMember(tableBans, team, user)
+}
+
+// Banned the users that are banned in the team
+func (dao SQLDAO) Banned(team string) ([]string, error) {
+	return dao.members(tableBans, team)
+}
+
+// Ban stops the user from giving or receiving karma in the team
+func (dao SQLDAO) Ban(team, user, actor string) error {
+	return dao.addMember(tableBans, team, user, actor, auditBan)
+}
+
+// Unban lets the user give and receive karma in the team again
+func (dao SQLDAO) Unban(team, user, actor string) error {
+	return dao.removeMember(tableBans, team, user, actor, auditUnban)
+}
+
+func (dao SQLDAO) isMember(table, team, user string) (bool, error) {
+	var n int
+	err := dao.db.QueryRow(dao.bind(`
+		SELECT	COUNT(*)
+		FROM	`+table+`
+		WHERE	team = ?
+		AND		"user" = ?;
+	`), team, user).Scan(&n)
+
+	return n > 0, err
+}
+
+func (dao SQLDAO) members(table, team string) ([]string, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		"user"
+		FROM		`+table+`
+		WHERE		team = ?
+		ORDER BY	created_at, "user";
+	`), team)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]string, 0)
+	for rows.Next() {
+		var user string
+		if err := rows.Scan(&user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// addMember adds the user to a membership table, if they aren't already in it
+func (dao SQLDAO) addMember(table, team, user, actor, action string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(dao.bind(`
+		INSERT INTO `+table+` (team, "user", added_by, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(team, "user") DO NOTHING;
+	`), team, user, actor, dao.now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if err := dao.txAudit(tx, team, actor, action, user, ""); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// removeMember removes the user from a membership table
+func (dao SQLDAO) removeMember(table, team, user, actor, action string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(dao.bind(`
+		DELETE FROM `+table+`
+		WHERE	team = ?
+		AND		"user" = ?;
+	`), team, user)
+	if err != nil {
+		return err
+	}
+
+	if err := dao.txAudit(tx, team, actor, action, user, ""); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
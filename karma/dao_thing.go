@@ -0,0 +1,100 @@
+package karma
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ThingKarma things and their karma totals
+type ThingKarma struct {
+	Thing string
+	Karma int
+}
+
+// GetThing the karma of a thing, 0 when it has never had any
+func (dao SQLDAO) GetThing(team, thing string) (int, error) {
+	var k int
+	err := dao.db.QueryRow(dao.bind(`
+		SELECT	t.karma
+		FROM	things t
+		WHERE	t.team = ?
+		AND		t.thing = ?;
+	`), team, thing).Scan(&k)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return k, err
+}
+
+// UpdateThingDaily updates the thing's karma, the giver's daily usage and the thing ledger at the same time, returns new karma
+// the thing (t.To) receives karma
+// the callee (t.From) has their daily usage incremented, things share the allowance with users
+func (dao SQLDAO) UpdateThingDaily(t Transaction, date time.Time) (int, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(dao.bind(`
+		INSERT INTO things
+		(team, thing, karma, created_at, updated_at)
+		VALUES
+		(?, ?, ?, ?, ?)
+		ON CONFLICT(team, thing) DO UPDATE SET
+		karma = things.karma + excluded.karma,
+		updated_at = excluded.updated_at;
+	`), t.Team, t.To, t.Delta, dao.now().UTC(), dao.now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	err = dao.txUpdateDaily(tx, t.Team, t.From, date, Abs(t.Delta))
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(dao.bind(`
+		INSERT INTO thing_ledger
+		(team, from_user, thing, channel, delta, message, created_at)
+		VALUES
+		(?, ?, ?, ?, ?, ?, ?);
+	`), t.Team, t.From, t.To, t.Channel, t.Delta, t.Message, dao.now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return dao.GetThing(t.Team, t.To)
+}
+
+// TopThings the n things with the most karma
+func (dao SQLDAO) TopThings(team string, n int) ([]ThingKarma, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		t.thing, t.karma
+		FROM		things t
+		WHERE		t.team = ?
+		AND			t.karma >= 0
+		ORDER BY	t.karma DESC, t.updated_at DESC
+		LIMIT ?;
+	`), team, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	things := make([]ThingKarma, 0, n)
+	for rows.Next() {
+		var t ThingKarma
+		if err := rows.Scan(&t.Thing, &t.Karma); err != nil {
+			return nil, err
+		}
+		things = append(things, t)
+	}
+
+	return things, rows.Err()
+}
@@ -0,0 +1,99 @@
+package karma
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// CreateToken saves a new api token under the hash of its bearer token, returns it with its id
+func (dao SQLDAO) CreateToken(t APIToken, hash string) (APIToken, error) {
+	t.CreatedAt = dao.now().UTC()
+	err := dao.db.QueryRow(dao.bind(`
+		INSERT INTO api_tokens (name, team, scopes, token_hash, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id;
+	`), t.Name, t.Team, joinScopes(t.Scopes), hash, t.CreatedAt).Scan(&t.ID)
+
+	return t, err
+}
+
+// TokenByHash the api token with this hash, ErrUnknownToken when there isn't one or it was revoked
+func (dao SQLDAO) TokenByHash(hash string) (APIToken, error) {
+	row := dao.db.QueryRow(dao.bind(`
+		SELECT	id, name, team, scopes, created_at, revoked_at
+		FROM	api_tokens
+		WHERE	token_hash = ?
+		AND		revoked_at IS NULL;
+	`), hash)
+
+	t, err := scanToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIToken{}, ErrUnknownToken
+	}
+	return t, err
+}
+
+// Tokens the api tokens of a team, including the revoked ones. An empty team lists every token
+func (dao SQLDAO) Tokens(team string) ([]APIToken, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		id, name, team, scopes, created_at, revoked_at
+		FROM		api_tokens
+		WHERE		? = '' OR team = ?
+		ORDER BY	id;
+	`), team, team)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]APIToken, 0)
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokeToken stops an api token from working, ErrUnknownToken when it doesn't exist or is already revoked
+func (dao SQLDAO) RevokeToken(id int64) error {
+	res, err := dao.db.Exec(dao.bind(`
+		UPDATE	api_tokens
+		SET		revoked_at = ?
+		WHERE	id = ?
+		AND		revoked_at IS NULL;
+	`), dao.now().UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUnknownToken
+	}
+	return nil
+}
+
+func scanToken(row interface{ Scan(...interface{}) error }) (APIToken, error) {
+	var t APIToken
+	var scopes string
+	var revoked sql.NullTime
+	if err := row.Scan(&t.ID, &t.Name, &t.Team, &scopes, &t.CreatedAt, &revoked); err != nil {
+		return APIToken{}, err
+	}
+
+	for _, s := range strings.Split(scopes, ",") {
+		t.Scopes = append(t.Scopes, Scope(s))
+	}
+	if revoked.Valid {
+		t.RevokedAt = &revoked.Time
+	}
+	return t, nil
+}
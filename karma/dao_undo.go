@@ -0,0 +1,136 @@
+package karma
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrAlreadyUndone the karma was undone (or never existed) by the time Undo got to it
+var ErrAlreadyUndone = errors.New("karma already undone")
+
+// ErrUndoThing the most recent karma was given to a thing, which can't be undone
+var ErrUndoThing = errors.New("karma for things can't be undone")
+
+// ledgerUndo the message of the ledger entries that reverse karma that was undone
+const ledgerUndo = "undo"
+
+// notUndone leaves out of a query on the ledger, as l, the karma that was undone and the entries that reversed it
+const notUndone = `
+		AND			l.reverses IS NULL
+		AND			NOT EXISTS (SELECT 1 FROM karma_ledger r WHERE r.reverses = l.id)`
+
+// LastGiven the user's most recent karma given (or taken) to users since a time, empty when there is none.
+// Karma given to several users at once is one action, so it is every transaction of it.
+// Karma that was undone is skipped, and ErrUndoThing when the most recent karma was given to a thing
+func (dao SQLDAO) LastGiven(team, user string, since time.Time) ([]Transaction, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		l.id, l.team, l.from_user, l.to_user, l.channel, l.delta, l.message, l.created_at
+		FROM		karma_ledger l
+		WHERE		l.team = ?
+		AND			l.from_user = ?`+notUndone+`
+		AND			l.created_at = (
+			SELECT	MAX(l.created_at)
+			FROM	karma_ledger l
+			WHERE	l.team = ?
+			AND		l.from_user = ?
+			AND		l.created_at >= ?`+notUndone+`
+		)
+		ORDER BY	l.id;
+	`), team, user, team, user, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	ts, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// karma to a thing after it would be skipped over
+	var thingAt time.Time
+	err = dao.db.QueryRow(dao.bind(`
+		SELECT		t.created_at
+		FROM		thing_ledger t
+		WHERE		t.team = ?
+		AND			t.from_user = ?
+		AND			t.created_at >= ?
+		ORDER BY	t.created_at DESC
+		LIMIT 1;
+	`), team, user, since.UTC()).Scan(&thingAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(ts) == 0 || thingAt.After(ts[0].CreatedAt) {
+		return nil, ErrUndoThing
+	}
+
+	return ts, nil
+}
+
+// Undo reverses transactions: the targets' karma and the giver's daily usage on date, all at once.
+// The ledger keeps them, each with an entry for the opposite karma that reverses it.
+// Returns the new karma of each target, in order
+func (dao SQLDAO) Undo(ts []Transaction, date time.Time) ([]int, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, t := range ts {
+		// someone else's undo got there first
+		var undone int
+		err := tx.QueryRow(dao.bind(`
+			SELECT	COUNT(*)
+			FROM	karma_ledger l
+			WHERE	l.reverses = ?;
+		`), t.ID).Scan(&undone)
+		if err != nil {
+			return nil, err
+		}
+		if undone > 0 {
+			return nil, ErrAlreadyUndone
+		}
+
+		_, err = tx.Exec(dao.bind(`
+			INSERT INTO karma_ledger
+			(team, from_user, to_user, channel, delta, message, created_at, reverses)
+			VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?);
+		`), t.Team, t.From, t.To, t.Channel, -t.Delta, ledgerUndo, dao.now().UTC(), t.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := dao.txUpdateKarma(tx, t.Team, t.To, -t.Delta); err != nil {
+			return nil, err
+		}
+		if err := dao.txUpdateDaily(tx, t.Team, t.From, date, -Abs(t.Delta)); err != nil {
+			return nil, err
+		}
+	}
+
+	ks := make([]int, 0, len(ts))
+	for _, t := range ts {
+		var k int
+		err := tx.QueryRow(dao.bind(`
+			SELECT k.karma
+			FROM   karma k
+			WHERE  k.team = ?
+			AND	   k."user" = ?;
+		`), t.Team, t.To).Scan(&k)
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
@@ -0,0 +1,184 @@
+package karma
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// team word statuses
+const (
+	WordPending  = "pending"
+	WordApproved = "approved"
+)
+
+// audit actions for team words
+const (
+	auditWordApprove = "word approve"
+	auditWordRemove  = "word remove"
+)
+
+// ErrWordExists the team already has the word in that column, pending or approved
+var ErrWordExists = errors.New("the word is already in that column")
+
+// ErrNoWord the team has no such word
+var ErrNoWord = errors.New("no such word")
+
+// TeamWord a word a team has added to a column of one of the kits, like insult column B
+type TeamWord struct {
+	ID          int64     `json:"id"`
+	Team        string    `json:"team"`
+	Kit         string    `json:"kit"`
+	Column      string    `json:"column"`
+	Word        string    `json:"word"`
+	Status      string    `json:"status"`
+	SubmittedBy string    `json:"submitted_by"`
+	ApprovedBy  string    `json:"approved_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TeamWords all of a team's words, by kit, column and word
+func (dao SQLDAO) TeamWords(team string) ([]TeamWord, error) {
+	rows, err := dao.db.Query(dao.bind(`
+		SELECT		id, team, kit, col, word, status, submitted_by, approved_by, created_at
+		FROM		team_words
+		WHERE		team = ?
+		ORDER BY	kit, col, word;
+	`), team)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var words []TeamWord
+	for rows.Next() {
+		w, err := scanTeamWord(rows)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+
+	return words, rows.Err()
+}
+
+// AddTeamWord adds a word with its status. ErrWordExists when the column already has it, in any case
+func (dao SQLDAO) AddTeamWord(w TeamWord) (TeamWord, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return TeamWord{}, err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow(dao.bind(`
+		SELECT	COUNT(*)
+		FROM	team_words
+		WHERE	team = ?
+		AND		kit = ?
+		AND		col = ?
+		AND		LOWER(word) = LOWER(?);
+	`), w.Team, w.Kit, w.Column, w.Word).Scan(&exists)
+	if err != nil {
+		return TeamWord{}, err
+	}
+	if exists > 0 {
+		return TeamWord{}, ErrWordExists
+	}
+
+	w.CreatedAt = dao.now().UTC()
+	err = tx.QueryRow(dao.bind(`
+		INSERT INTO team_words (team, kit, col, word, status, submitted_by, approved_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id;
+	`), w.Team, w.Kit, w.Column, w.Word, w.Status, w.SubmittedBy, w.ApprovedBy, w.CreatedAt).Scan(&w.ID)
+	if err != nil {
+		return TeamWord{}, err
+	}
+
+	return w, tx.Commit()
+}
+
+// ApproveTeamWord approves a pending word, and records who did it in the audit trail. ErrNoWord when there isn't one
+func (dao SQLDAO) ApproveTeamWord(team string, id int64, actor string) (TeamWord, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return TeamWord{}, err
+	}
+	defer tx.Rollback()
+
+	w, err := dao.txTeamWord(tx, team, id)
+	if err != nil {
+		return TeamWord{}, err
+	}
+	if w.Status == WordApproved {
+		return w, tx.Commit()
+	}
+
+	_, err = tx.Exec(dao.bind(`
+		UPDATE	team_words
+		SET		status = ?, approved_by = ?
+		WHERE	id = ?;
+	`), WordApproved, actor, id)
+	if err != nil {
+		return TeamWord{}, err
+	}
+	if err := dao.txAudit(tx, team, actor, auditWordApprove, w.Kit+" "+w.Column, w.Word); err != nil {
+		return TeamWord{}, err
+	}
+
+	w.Status, w.ApprovedBy = WordApproved, actor
+	return w, tx.Commit()
+}
+
+// RemoveTeamWord removes a word, and records who did it in the audit trail. ErrNoWord when there isn't one
+func (dao SQLDAO) RemoveTeamWord(team string, id int64, actor string) (TeamWord, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return TeamWord{}, err
+	}
+	defer tx.Rollback()
+
+	w, err := dao.txTeamWord(tx, team, id)
+	if err != nil {
+		return TeamWord{}, err
+	}
+
+	_, err = tx.Exec(dao.bind(`
+		DELETE FROM	team_words
+		WHERE		id = ?;
+	`), id)
+	if err != nil {
+		return TeamWord{}, err
+	}
+	detail := fmt.Sprintf("%v (%v)", w.Word, w.Status)
+	if err := dao.txAudit(tx, team, actor, auditWordRemove, w.Kit+" "+w.Column, detail); err != nil {
+		return TeamWord{}, err
+	}
+
+	return w, tx.Commit()
+}
+
+func (dao SQLDAO) txTeamWord(tx *sql.Tx, team string, id int64) (TeamWord, error) {
+	row := tx.QueryRow(dao.bind(`
+		SELECT	id, team, kit, col, word, status, submitted_by, approved_by, created_at
+		FROM	team_words
+		WHERE	team = ?
+		AND		id = ?;
+	`), team, id)
+
+	w, err := scanTeamWord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TeamWord{}, ErrNoWord
+	}
+	return w, err
+}
+
+func scanTeamWord(row interface{ Scan(...interface{}) error }) (TeamWord, error) {
+	var w TeamWord
+	var approvedBy sql.NullString
+	err := row.Scan(&w.ID, &w.Team, &w.Kit, &w.Column, &w.Word, &w.Status, &w.SubmittedBy, &approvedBy, &w.CreatedAt)
+	w.ApprovedBy = approvedBy.String
+	return w, err
+}
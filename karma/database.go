@@ -0,0 +1,42 @@
+package karma
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Database drivers that the karma DAO can run on
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+)
+
+// Connect opens the database for the driver without touching its schema
+// For sqlite3 the data source is a file path, for postgres a connection string
+func Connect(driver, dataSourceName string) (*sql.DB, error) {
+	switch driver {
+	case DriverSQLite:
+		return createDB(dataSourceName)
+	case DriverPostgres:
+		return connectPostgres(dataSourceName)
+	}
+
+	return nil, fmt.Errorf("unknown database driver %q, expected %v or %v", driver, DriverSQLite, DriverPostgres)
+}
+
+// Open connects to the database, migrates it to the latest schema and returns it along with its DAO
+func Open(driver, dataSourceName string) (*sql.DB, DAO, error) {
+	db, err := Connect(driver, dataSourceName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := Migrate(db, driver); err != nil {
+		return nil, nil, err
+	}
+
+	if driver == DriverPostgres {
+		return db, NewPostgresDao(db), nil
+	}
+	return db, NewDao(db), nil
+}
@@ -0,0 +1,171 @@
+package karma
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// maxGroupMembers the biggest group that karma is handed out to
+const maxGroupMembers = 100
+
+// maxHereMembers the most people in a channel whose presence is looked up for @here. Each is a call to slack's
+// users.getPresence, made while the slash command waits, and slack only allows so many a minute
+const maxHereMembers = 20
+
+// hereTimeout how long looking up who is online for @here may take, well inside the 3 seconds slack gives a slash command
+var hereTimeout = 2 * time.Second
+
+// errHereTimeout slack didn't say who is online in time
+var errHereTimeout = errors.New("timed out looking up who is online")
+
+// slackbot is in every channel, but isn't anyone to give karma to
+const slackbot = "USLACKBOT"
+
+// giveGroup gives (or takes) karma to everyone in a user group, @here or @channel, except the callee and banned users.
+// The team's group policy either splits delta between them or gives delta to each. It is all or nothing, like giveMany
+func (p SlackProcessor) giveGroup(team, channel, callee string, group slack.Group, delta int, reason string) (slack.Response, error) {
+	if delta == 0 {
+		return slack.ErrorResponse(msgNoOp), nil
+	}
+	if p.dir == nil {
+		return slack.ErrorResponse(msgNoDirectory), nil
+	}
+
+	members, resp, err := p.groupMembers(team, channel, callee, group)
+	if err != nil || members == nil {
+		return resp, err
+	}
+
+	deltas, ok := shares(delta, len(members), p.config.GroupPolicy)
+	if !ok {
+		return slack.ErrorResponse(MsgGroupTooThin(group.Label, len(members), Abs(delta))), nil
+	}
+
+	ks, resp, err := p.moveMany(team, channel, callee, members, deltas, reason)
+	if err != nil || ks == nil {
+		return resp, err
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	if delta > 0 {
+		msg.WriteString(MsgGiveGroup(callee, group.Label, len(members), delta, p.config.GroupPolicy, reason))
+	} else {
+		msg.WriteString(MsgTakeGroup(callee, group.Label, len(members), -delta, p.config.GroupPolicy, reason))
+	}
+	msg.WriteString(MsgUsersStatus(members, ks))
+	att.WriteString(p.Salutation(delta))
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
+
+// groupMembers who in the group gets the karma: not the callee, slackbot or banned users, and only those online for @here.
+// members is nil, with a response saying why, when there is nobody or the group is too big
+func (p SlackProcessor) groupMembers(team, channel, callee string, group slack.Group) ([]string, slack.Response, error) {
+	var members []string
+	var err error
+	if group.Kind == slack.GroupSubteam {
+		members, err = p.dir.UserGroupMembers(group.ID)
+	} else {
+		members, err = p.dir.ChannelMembers(channel)
+	}
+	if err != nil {
+		return nil, slack.Response{}, err
+	}
+	if len(members) > maxGroupMembers {
+		return nil, slack.ErrorResponse(MsgGroupTooBig(group.Label, maxGroupMembers)), nil
+	}
+
+	banned, err := p.dao.Banned(team)
+	if err != nil {
+		return nil, slack.Response{}, err
+	}
+	members = slices.DeleteFunc(distinct(members), func(m string) bool {
+		return m == callee || m == slackbot || slices.Contains(banned, m)
+	})
+
+	if group.Kind == slack.GroupHere {
+		if len(members) > maxHereMembers {
+			return nil, slack.ErrorResponse(MsgHereTooBig(maxHereMembers)), nil
+		}
+		members, err = p.online(members)
+		if errors.Is(err, errHereTimeout) {
+			return nil, slack.ErrorResponse(msgHereTimeout), nil
+		}
+		if err != nil {
+			return nil, slack.Response{}, err
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, slack.ErrorResponse(MsgEmptyGroup(group.Label)), nil
+	}
+	return members, slack.Response{}, nil
+}
+
+// online the members who are online, in the same order. Their presences are looked up all at once, and errHereTimeout
+// when they take longer than hereTimeout
+func (p SlackProcessor) online(members []string) ([]string, error) {
+	type presence struct {
+		i      int
+		active bool
+		err    error
+	}
+	// buffered, so the lookups still running after a timeout can finish
+	presences := make(chan presence, len(members))
+	for i, m := range members {
+		go func() {
+			active, err := p.dir.IsActive(m)
+			presences <- presence{i, active, err}
+		}()
+	}
+
+	active := make([]bool, len(members))
+	timeout := time.After(hereTimeout)
+	for range members {
+		select {
+		case pr := <-presences:
+			if pr.err != nil {
+				return nil, pr.err
+			}
+			active[pr.i] = pr.active
+		case <-timeout:
+			return nil, errHereTimeout
+		}
+	}
+
+	online := make([]string, 0, len(members))
+	for i, m := range members {
+		if active[i] {
+			online = append(online, m)
+		}
+	}
+	return online, nil
+}
+
+// shares the karma for each of n members. Replicated, each gets delta. Split, delta is shared as evenly as it goes
+// with the first members getting what's left over, and ok is false when there isn't at least 1 each
+func shares(delta, n int, policy string) ([]int, bool) {
+	deltas := make([]int, n)
+	if policy == PolicyReplicate {
+		for i := range deltas {
+			deltas[i] = delta
+		}
+		return deltas, true
+	}
+
+	amount := Abs(delta)
+	if amount < n {
+		return nil, false
+	}
+	sign := delta / amount
+	for i := range deltas {
+		deltas[i] = sign * (amount / n)
+		if i < amount%n {
+			deltas[i] += sign
+		}
+	}
+	return deltas, true
+}
@@ -0,0 +1,209 @@
+package karma
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShares(t *testing.T) {
+	testcases := []struct {
+		name     string
+		delta    int
+		n        int
+		policy   string
+		expected []int
+		ok       bool
+	}{
+		{"replicate", 2, 3, PolicyReplicate, []int{2, 2, 2}, true},
+		{"replicate take", -1, 2, PolicyReplicate, []int{-1, -1}, true},
+		{"split evenly", 6, 3, PolicySplit, []int{2, 2, 2}, true},
+		{"split with a remainder", 7, 3, PolicySplit, []int{3, 2, 2}, true},
+		{"split take", -5, 2, PolicySplit, []int{-3, -2}, true},
+		{"too thin", 2, 3, PolicySplit, nil, false},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok := shares(test.delta, test.n, test.policy)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestParseGroupMentions(t *testing.T) {
+	assert.Equal(t, []Mention{
+		{Group: slack.Group{Kind: slack.GroupSubteam, ID: "S123", Label: "@devs"}, Delta: 2, Reason: "the release"},
+		{Group: slack.Group{Kind: slack.GroupHere, Label: "@here"}, Delta: -1},
+	}, parseMentions("<!subteam^S123|@devs> +++ for the release <!here>--"))
+
+	assert.Empty(t, parseMentions("<!everyone> ++"))
+}
+
+// groupProcessor a mock processor that can see a user group, S123, and a channel, CGENERAL, where only UA and UB are online
+func groupProcessor(dao MockDAO, policy string) (SlackProcessor, *[][]Transaction) {
+	var applied [][]Transaction
+	dao.UpdateKarmaDailyManyMock = func(ts []Transaction, date time.Time) ([]int, error) {
+		applied = append(applied, ts)
+		ks := make([]int, 0, len(ts))
+		for i := range ts {
+			ks = append(ks, i+1)
+		}
+		return ks, nil
+	}
+
+	dir := slack.NewFakeClient()
+	dir.Groups["S123"] = []string{"UA", "UCALLER", "UB", "UBANNED", "UC"}
+	dir.Channels["CGENERAL"] = []string{"UA", "UB", "UC", "UCALLER", "USLACKBOT"}
+	dir.Active["UA"] = true
+	dir.Active["UB"] = true
+	dir.Active["UCALLER"] = true
+
+	p := mockProcessor(dao).WithDirectory(dir)
+	p.config.GroupPolicy = policy
+	p.defaults.GroupPolicy = policy
+	return p, &applied
+}
+
+func channelCommand(text string) slack.CommandData {
+	c := command(text)
+	c.ChannelID = "CGENERAL"
+	return c
+}
+
+func TestProcessGroup(t *testing.T) {
+	split, applied := groupProcessor(HappyDao(), PolicySplit)
+	replicate, _ := groupProcessor(HappyDao(), PolicyReplicate)
+
+	testcases := []struct {
+		ProcessTestCase
+		p SlackProcessor
+	}{
+		{ProcessTestCase{
+			name:         "split",
+			command:      channelCommand("++ <!subteam^S123|@devs> 4 for the release"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is sharing 4 karma between the 3 people in @devs for the release. <@UA> has 1, <@UB> has 2 and <@UC> has 3 karma.",
+			attach:       true,
+		}, split},
+		{ProcessTestCase{
+			name:         "split too thin",
+			command:      channelCommand("++ <!subteam^S123|@devs>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgGroupTooThin("@devs", 3, 1),
+		}, split},
+		{ProcessTestCase{
+			name:         "replicate here",
+			command:      channelCommand("++ <!here> 2"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 2 karma each to the 2 people in @here. <@UA> has 1 and <@UB> has 2 karma.",
+			attach:       true,
+		}, replicate},
+		{ProcessTestCase{
+			name:         "replicate take from channel",
+			command:      channelCommand("-- <!channel> for the noise"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is taking away 1 karma each from the 3 people in @channel for the noise. <@UA> has 1, <@UB> has 2 and <@UC> has 3 karma.",
+			attach:       true,
+		}, replicate},
+		{ProcessTestCase{
+			name:         "single limit is for each of them",
+			command:      channelCommand("++ <!here> 6"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgDeltaLimit(5),
+		}, replicate},
+		{ProcessTestCase{
+			name:         "no directory",
+			command:      channelCommand("++ <!here>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgNoDirectory,
+		}, happyMockProcessor()},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, test.p, test.ProcessTestCase)
+	}
+
+	_, err := split.Process(channelCommand("++ <!subteam^S999> 3"))
+	assert.NotNil(t, err)
+
+	if assert.Len(t, *applied, 1) {
+		assert.Equal(t, []Transaction{
+			{From: "UCALLER", To: "UA", Channel: "CGENERAL", Delta: 2, Message: "the release"},
+			{From: "UCALLER", To: "UB", Channel: "CGENERAL", Delta: 1, Message: "the release"},
+			{From: "UCALLER", To: "UC", Channel: "CGENERAL", Delta: 1, Message: "the release"},
+		}, (*applied)[0])
+	}
+}
+
+func TestProcessGroupDailyLimit(t *testing.T) {
+	// 5 left today, which the 3 in the channel can't have 2 each of
+	p, applied := groupProcessor(NewMockDao(20), PolicyReplicate)
+	processHelper(t, p, ProcessTestCase{
+		name:         "combined amount",
+		command:      channelCommand("++ <!channel> 2"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         MsgOverDailyLimit(25, 20, 5, time.Date(2026, time.May, 5, 0, 0, 0, 0, time.UTC), mockNow),
+	})
+	assert.Empty(t, *applied)
+}
+
+func TestProcessMessageGroup(t *testing.T) {
+	p, _ := groupProcessor(HappyDao(), PolicySplit)
+	m := message("<!subteam^S123|@devs> ++++ for the launch")
+	m.Channel = "CGENERAL"
+
+	res, err := p.ProcessMessage(m)
+	assert.Nil(t, err)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, "<@UCALLER> is sharing 3 karma between the 3 people in @devs for the launch. <@UA> has 1, <@UB> has 2 and <@UC> has 3 karma.", res[0].Text)
+	}
+}
+
+// slowDirectory a directory that takes its time to say who is online
+type slowDirectory struct {
+	*slack.FakeClient
+	delay time.Duration
+}
+
+func (d slowDirectory) IsActive(user string) (bool, error) {
+	time.Sleep(d.delay)
+	return d.FakeClient.IsActive(user)
+}
+
+func TestProcessHere(t *testing.T) {
+	big := slack.NewFakeClient()
+	for i := 0; i <= maxHereMembers; i++ {
+		big.Channels["CGENERAL"] = append(big.Channels["CGENERAL"], fmt.Sprintf("U%v", i))
+	}
+	processHelper(t, mockProcessor(HappyDao()).WithDirectory(big), ProcessTestCase{
+		name:         "too big to look up",
+		command:      channelCommand("++ <!here>"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         MsgHereTooBig(maxHereMembers),
+	})
+
+	defer func(timeout time.Duration) { hereTimeout = timeout }(hereTimeout)
+	hereTimeout = 50 * time.Millisecond
+
+	// all at once, so a channel of slow lookups takes as long as one of them
+	slow := slack.NewFakeClient()
+	slow.Channels["CGENERAL"] = []string{"UA", "UB", "UC", "UD", "UE"}
+	slow.Active["UB"], slow.Active["UD"] = true, true
+	p := mockProcessor(HappyDao()).WithDirectory(slowDirectory{slow, 20 * time.Millisecond})
+	online, err := p.online(slow.Channels["CGENERAL"])
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UB", "UD"}, online)
+
+	p = mockProcessor(HappyDao()).WithDirectory(slowDirectory{slow, 200 * time.Millisecond})
+	processHelper(t, p, ProcessTestCase{
+		name:         "too slow",
+		command:      channelCommand("++ <!here>"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         msgHereTimeout,
+	})
+}
@@ -1,7 +1,11 @@
 package karma
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/icemanblues/knave-bot/slack"
 
@@ -17,14 +21,33 @@ type Handler interface {
 	DelKarma(c *gin.Context)
 	SlashKarma(c *gin.Context)
 	TopKarma(c *gin.Context)
+	SlackEvent(c *gin.Context)
+	ReportKarma(c *gin.Context)
+	RankingsTop(c *gin.Context)
+	RankingsBottom(c *gin.Context)
+	GetTeamConfig(c *gin.Context)
+	SetTeamConfig(c *gin.Context)
+	ResetTeamConfig(c *gin.Context)
+	AuditLog(c *gin.Context)
+	KarmaV2(c *gin.Context)
+	GiveKarmaV2(c *gin.Context)
+	ResetKarmaV2(c *gin.Context)
+	HistoryV2(c *gin.Context)
+	RankingsV2(c *gin.Context)
+	TeamStatsV2(c *gin.Context)
 }
 
 // SQLiteHandler Karma Handler implementation using sqlite
 type SQLiteHandler struct {
-	proc Processor
-	dao  DAO
+	proc   Processor
+	dao    DAO
+	client slack.Client
+	events *sync.WaitGroup
 }
 
+// eventTimeout how long handling a message event may take, long after slack had its 200, before it is logged as stuck
+var eventTimeout = 30 * time.Second
+
 // GetKarma handler method to read the current karma for an individual
 func (h SQLiteHandler) GetKarma(c *gin.Context) {
 	team := c.Param("team")
@@ -33,7 +56,7 @@ func (h SQLiteHandler) GetKarma(c *gin.Context) {
 	k, err := h.dao.GetKarma(team, user)
 	if err != nil {
 		log.Errorf("Unable to lookup karma. %v %v %v", team, user, err)
-		c.String(500, err.Error())
+		abortError(c, 500, err.Error())
 		return
 	}
 
@@ -49,14 +72,14 @@ func (h SQLiteHandler) AddKarma(c *gin.Context) {
 	delta, err := strconv.Atoi(d)
 	if err != nil {
 		log.Errorf("Not a valid integer. %v %v %v %v", team, user, delta, err)
-		c.String(400, "Please pass a valid integer. %v", d)
+		abortError(c, 400, fmt.Sprintf("Please pass a valid integer. %v", d))
 		return
 	}
 
 	k, err := h.dao.UpdateKarma(team, user, delta)
 	if err != nil {
 		log.Errorf("Unable to add or remove karma. %v %v %v %v", team, user, delta, err)
-		c.String(500, err.Error())
+		abortError(c, 500, err.Error())
 		return
 	}
 	c.String(200, "%v", k)
@@ -70,7 +93,7 @@ func (h SQLiteHandler) DelKarma(c *gin.Context) {
 	k, err := h.dao.DeleteKarma(team, user)
 	if err != nil {
 		log.Errorf("Unable to reset karma. %v %v %v", team, user, err)
-		c.String(500, err.Error())
+		abortError(c, 500, err.Error())
 		return
 	}
 
@@ -84,18 +107,228 @@ func (h SQLiteHandler) TopKarma(c *gin.Context) {
 	top := c.Query("top")
 	n, err := strconv.Atoi(top)
 	if err != nil {
-		c.String(400, "Please pass a valid integer. %v", n)
+		abortError(c, 400, fmt.Sprintf("Please pass a valid integer. %v", n))
 		return
 	}
 	if n <= 0 {
-		c.String(400, "Please pass a positive non-zero integer. %v", n)
+		abortError(c, 400, fmt.Sprintf("Please pass a positive non-zero integer. %v", n))
 		return
 	}
 
 	topUsers, err := h.dao.Top(team, n)
+	if err != nil {
+		log.Errorf("Unable to lookup top users. %v %v %v", team, n, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
 	c.JSON(200, topUsers)
 }
 
+// RankingsTop ranks the top n users of a team, ?n defaults to the team's top_user_default and is at most its top_user_max
+func (h SQLiteHandler) RankingsTop(c *gin.Context) {
+	h.rankings(c, h.dao.Top)
+}
+
+// RankingsBottom ranks the bottom n users of a team, ?n defaults to the team's top_user_default and is at most its top_user_max
+func (h SQLiteHandler) RankingsBottom(c *gin.Context) {
+	h.rankings(c, h.dao.Bottom)
+}
+
+func (h SQLiteHandler) rankings(c *gin.Context, leaderboard func(team string, n int) ([]UserKarma, error)) {
+	team := c.Param("team")
+
+	n, ok := h.leaderboardSize(c, team)
+	if !ok {
+		return
+	}
+
+	users, err := leaderboard(team, n)
+	if err != nil {
+		log.Errorf("Unable to rank users. %v %v %v", team, n, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, Rankings(users))
+}
+
+// leaderboardSize the ?n of a leaderboard, the team's top_user_default when it isn't passed and at most its top_user_max.
+// ok is false when the request has been aborted
+func (h SQLiteHandler) leaderboardSize(c *gin.Context, team string) (int, bool) {
+	cfg, _, err := h.proc.TeamConfig(team)
+	if err != nil {
+		log.Errorf("Unable to lookup team config. %v %v", team, err)
+		abortError(c, 500, err.Error())
+		return 0, false
+	}
+
+	return queryN(c, cfg.TopUserDefault, cfg.TopUserMax)
+}
+
+// queryN the ?n query param, def when it isn't passed and at most max. ok is false, and the request aborted,
+// when it isn't a positive integer
+func queryN(c *gin.Context, def, max int) (int, bool) {
+	q := c.Query("n")
+	if q == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(q)
+	if err != nil || n <= 0 {
+		abortError(c, 400, fmt.Sprintf("Please pass a positive non-zero integer. %v", q))
+		return 0, false
+	}
+	return min(n, max), true
+}
+
+// ReportKarma summarizes the karma moved in a team during a period
+// ?period=week|month|quarter|year (&last=true for the previous one) or ?from=2006-01-02&to=2006-01-02
+// ?n the size of each ranking, defaults to the team's top_user_default and is at most its top_user_max
+func (h SQLiteHandler) ReportKarma(c *gin.Context) {
+	team := c.Param("team")
+
+	n, ok := h.leaderboardSize(c, team)
+	if !ok {
+		return
+	}
+
+	period, ok := queryPeriod(c)
+	if !ok {
+		abortError(c, 400, "Please pass a valid period. ?period=week|month|quarter|year&last=true or ?from=2006-01-02&to=2006-01-02")
+		return
+	}
+
+	r, err := h.dao.Report(team, period.From, period.To, n)
+	if err != nil {
+		log.Errorf("Unable to build karma report. %v %v %v", team, period, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, r)
+}
+
+// queryPeriod the report period of the ?period, ?last, ?from and ?to query params
+func queryPeriod(c *gin.Context) (Period, bool) {
+	if l := c.Query("last"); l != "" && l != "true" && l != "false" {
+		return Period{}, false
+	}
+	if c.Query("to") != "" && c.Query("from") == "" {
+		return Period{}, false
+	}
+
+	var args []string
+	switch {
+	case c.Query("from") != "":
+		args = []string{c.Query("from")}
+		if to := c.Query("to"); to != "" {
+			args = append(args, to)
+		}
+	case c.Query("last") == "true":
+		args = []string{last, c.DefaultQuery("period", month)}
+	case c.Query("period") != "":
+		args = []string{c.Query("period")}
+	}
+
+	return parsePeriod(args, time.Now())
+}
+
+// TeamConfigResponse a team's effective settings, and which of them are its own
+type TeamConfigResponse struct {
+	Team      string            `json:"team"`
+	Config    ProcConfig        `json:"config"`
+	Overrides map[string]string `json:"overrides"`
+}
+
+// TeamConfigRequest changes a team's setting. The api token that changes it is recorded in the audit trail
+type TeamConfigRequest struct {
+	Value string `json:"value"`
+}
+
+// GetTeamConfig returns a team's karma settings
+func (h SQLiteHandler) GetTeamConfig(c *gin.Context) {
+	team := c.Param("team")
+
+	cfg, overrides, err := h.proc.TeamConfig(team)
+	if err != nil {
+		log.Errorf("Unable to lookup team config. %v %v", team, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, TeamConfigResponse{team, cfg, overrides})
+}
+
+// SetTeamConfig overrides one of a team's settings. The body is a TeamConfigRequest
+func (h SQLiteHandler) SetTeamConfig(c *gin.Context) {
+	team := c.Param("team")
+	setting := c.Param("setting")
+
+	actor, ok := RequestActor(c)
+	if !ok {
+		abortError(c, 401, "Please pass an api token. Authorization: Bearer <token>")
+		return
+	}
+
+	var req TeamConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Value == "" {
+		abortError(c, 400, `Please pass the value. {"value": "50"}`)
+		return
+	}
+
+	_, err := h.proc.SetTeamConfig(team, setting, req.Value, actor)
+	h.teamConfigChanged(c, team, setting, err)
+}
+
+// ResetTeamConfig puts one of a team's settings back to the default. The api token is recorded in the audit trail
+func (h SQLiteHandler) ResetTeamConfig(c *gin.Context) {
+	team := c.Param("team")
+	setting := c.Param("setting")
+
+	actor, ok := RequestActor(c)
+	if !ok {
+		abortError(c, 401, "Please pass an api token. Authorization: Bearer <token>")
+		return
+	}
+
+	_, err := h.proc.ResetTeamConfig(team, setting, actor)
+	h.teamConfigChanged(c, team, setting, err)
+}
+
+func (h SQLiteHandler) teamConfigChanged(c *gin.Context, team, setting string, err error) {
+	if errors.Is(err, ErrInvalidConfig) {
+		abortError(c, 400, err.Error())
+		return
+	}
+	if err != nil {
+		log.Errorf("Unable to change team config. %v %v %v", team, setting, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
+	h.GetTeamConfig(c)
+}
+
+// AuditLog the latest changes made to a team, newest first. ?n defaults to 20 and is at most 100, like a page of the v2 api
+func (h SQLiteHandler) AuditLog(c *gin.Context) {
+	team := c.Param("team")
+
+	n, ok := queryN(c, defaultPageLimit, maxPageLimit)
+	if !ok {
+		return
+	}
+
+	entries, err := h.dao.Audit(team, n)
+	if err != nil {
+		log.Errorf("Unable to lookup the audit trail. %v %v", team, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, entries)
+}
+
 var responseUnknownError = slack.ErrorResponse("Oh no! Looks like we're experiencing some technical difficulties")
 
 // SlashKarma handler method for the `/karma` slash-command
@@ -126,10 +359,91 @@ func (h SQLiteHandler) SlashKarma(c *gin.Context) {
 	}()
 }
 
+// SlackEvent handler method for the slack Events API. `@user ++` typed into a channel
+func (h SQLiteHandler) SlackEvent(c *gin.Context) {
+	var envelope slack.EventEnvelope
+	if err := c.ShouldBindJSON(&envelope); err != nil {
+		log.Errorf("Unable to parse slack event. %v", err)
+		c.String(400, "Unable to parse slack event")
+		return
+	}
+
+	switch envelope.Type {
+	case slack.EventURLVerification:
+		c.JSON(200, gin.H{"challenge": envelope.Challenge})
+		return
+
+	case slack.EventCallback:
+		// slack retries when it didn't see our 200. the first delivery was acknowledged and its karma given, or is being
+		if c.GetHeader(slack.HeaderRetryNum) != "" {
+			log.Infof("Ignoring retried slack event %v", envelope.EventID)
+			c.Status(200)
+			return
+		}
+
+		msg := envelope.Event
+		if !msg.IsUserMessage() {
+			c.Status(200)
+			return
+		}
+		msg.Team = envelope.TeamID
+
+		// slack wants its 200 within 3 seconds, @here lookups and replies can take longer than that
+		h.events.Add(1)
+		go h.handleMessage(envelope.EventID, msg)
+	}
+
+	c.Status(200)
+}
+
+// handleMessage gives the karma in a message and replies to it, after the event was acknowledged
+func (h SQLiteHandler) handleMessage(eventID string, msg slack.MessageEvent) {
+	defer h.events.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Panic handling slack message event. %v %v", eventID, r)
+		}
+	}()
+
+	stuck := time.AfterFunc(eventTimeout, func() {
+		log.Errorf("Slack message event is taking longer than %v. %v", eventTimeout, eventID)
+	})
+	defer stuck.Stop()
+
+	responses, err := h.proc.ProcessMessage(msg)
+	if err != nil {
+		log.Errorf("Could not process a slack message event. %v %v", eventID, err)
+		responses = append(responses, responseUnknownError)
+	}
+	h.reply(msg, responses)
+}
+
+// wait until the message events being handled are done
+func (h SQLiteHandler) wait() {
+	h.events.Wait()
+}
+
+// reply posts responses for a message, in channel responses to everyone, ephemeral ones to the author only
+func (h SQLiteHandler) reply(msg slack.MessageEvent, responses []slack.Response) {
+	for _, res := range responses {
+		var err error
+		if res.ResponseType == slack.ResponseType.InChannel {
+			err = h.client.PostMessage(msg.Channel, res)
+		} else {
+			err = h.client.PostEphemeral(msg.Channel, msg.User, res)
+		}
+		if err != nil {
+			log.Errorf("Unable to reply to slack message event. %v %v", msg.Channel, err)
+		}
+	}
+}
+
 // NewHandler factory method
-func NewHandler(proc Processor, dao DAO) SQLiteHandler {
+func NewHandler(proc Processor, dao DAO, client slack.Client) SQLiteHandler {
 	return SQLiteHandler{
-		proc: proc,
-		dao:  dao,
+		proc:   proc,
+		dao:    dao,
+		client: client,
+		events: &sync.WaitGroup{},
 	}
 }
@@ -2,30 +2,54 @@ package karma
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/icemanblues/knave-bot/slack"
 	"github.com/stretchr/testify/assert"
 )
 
+const testSecret = "karma-signing-secret"
+
 func setup(dao DAO) *gin.Engine {
-	proc := mockProcessor(dao)
-	h := NewHandler(proc, dao)
+	r, _ := setupWithClient(dao)
+	return r
+}
 
+func setupWithClient(dao DAO) (*gin.Engine, *slack.FakeClient) {
+	client := slack.NewFakeClient()
+	return routes(NewHandler(mockProcessor(dao), dao, client)), client
+}
+
+func routes(h SQLiteHandler) *gin.Engine {
 	r := gin.Default()
 
 	knaveRouter := r.Group("/knavebot")
 	karmaRouter := r.Group("/karmabot")
-	BindRoutes(karmaRouter, knaveRouter, h)
+	BindRoutes(karmaRouter, knaveRouter, h, slack.NewVerifier(testSecret, 0).Middleware(), NewTokenAuth(HappyDao()))
 
 	return r
 }
 
+// apiRequest a REST api request, authenticated with the admin token
+func apiRequest(method, path string, body io.Reader) *http.Request {
+	req, _ := http.NewRequest(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+mockAdminToken)
+	return req
+}
+
+// apiError the json body of an error response
+func apiError(status int, message string) string {
+	b, _ := json.Marshal(APIError{status, http.StatusText(status), message})
+	return string(b)
+}
+
 func TestGetKarma(t *testing.T) {
 	testcases := []struct {
 		name     string
@@ -43,7 +67,7 @@ func TestGetKarma(t *testing.T) {
 			name:     "GetKarma error",
 			dao:      SadDao(),
 			code:     500,
-			expected: "GetKarmaMock",
+			expected: apiError(500, "GetKarmaMock"),
 		},
 	}
 
@@ -54,7 +78,7 @@ func TestGetKarma(t *testing.T) {
 
 			// undertest
 			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("GET", "/karmabot/v1/team/nycfc/davidvilla", nil)
+			req := apiRequest("GET", "/karmabot/v1/team/nycfc/davidvilla", nil)
 			r.ServeHTTP(w, req)
 
 			// assert
@@ -84,7 +108,7 @@ func TestAddKarma(t *testing.T) {
 			dao:      HappyDao(),
 			delta:    "Not-A-Number",
 			code:     400,
-			expected: "Please pass a valid integer. Not-A-Number",
+			expected: apiError(400, "Please pass a valid integer. Not-A-Number"),
 		},
 		{
 			name:     "AddKarma negative delta",
@@ -98,7 +122,7 @@ func TestAddKarma(t *testing.T) {
 			dao:      SadDao(),
 			delta:    "5",
 			code:     500,
-			expected: "UpdateKarmaMock",
+			expected: apiError(500, "UpdateKarmaMock"),
 		},
 	}
 
@@ -109,7 +133,7 @@ func TestAddKarma(t *testing.T) {
 
 			// undertest
 			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("PUT", "/karmabot/v1/team/nycfc/davidvilla?delta="+test.delta, nil)
+			req := apiRequest("PUT", "/karmabot/v1/team/nycfc/davidvilla?delta="+test.delta, nil)
 			r.ServeHTTP(w, req)
 
 			// assert
@@ -136,7 +160,7 @@ func TestDelKarma(t *testing.T) {
 			name:     "DeleteKarma error",
 			dao:      SadDao(),
 			code:     500,
-			expected: "DeleteKarmaMock",
+			expected: apiError(500, "DeleteKarmaMock"),
 		},
 	}
 
@@ -147,7 +171,7 @@ func TestDelKarma(t *testing.T) {
 
 			// undertest
 			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("DELETE", "/karmabot/v1/team/nycfc/davidvilla", nil)
+			req := apiRequest("DELETE", "/karmabot/v1/team/nycfc/davidvilla", nil)
 			r.ServeHTTP(w, req)
 
 			// assert
@@ -181,8 +205,8 @@ func karmaTestRunner(t *testing.T, testcases []KarmaTestCase) {
 
 			// undertest
 			w := httptest.NewRecorder()
-			body := strings.NewReader(test.form.Encode())
-			req, _ := http.NewRequest("POST", "/knavebot/v1/cmd/karma", body)
+			body := []byte(test.form.Encode())
+			req, _ := slack.NewSignedRequest("POST", "/knavebot/v1/cmd/karma", testSecret, body)
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 			r.ServeHTTP(w, req)
 
@@ -334,3 +358,484 @@ func TestSubtract(t *testing.T) {
 
 	karmaTestRunner(t, testcases)
 }
+
+func TestSlashKarmaUnsigned(t *testing.T) {
+	r := setup(HappyDao())
+
+	w := httptest.NewRecorder()
+	body := strings.NewReader(makeForm("++ <@USER>").Encode())
+	req, _ := http.NewRequest("POST", "/knavebot/v1/cmd/karma", body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 401, w.Code)
+}
+
+func postEvent(r *gin.Engine, envelope slack.EventEnvelope, header http.Header) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(envelope)
+	req, _ := slack.NewSignedRequest("POST", "/knavebot/v1/events", testSecret, body)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func messageEnvelope(event slack.MessageEvent) slack.EventEnvelope {
+	return slack.EventEnvelope{
+		Type:    slack.EventCallback,
+		TeamID:  "nycfc",
+		EventID: "Ev123",
+		Event:   event,
+	}
+}
+
+func TestSlackEventURLVerification(t *testing.T) {
+	r := setup(HappyDao())
+
+	w := postEvent(r, slack.EventEnvelope{
+		Type:      slack.EventURLVerification,
+		Challenge: "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P",
+	}, nil)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`, w.Body.String())
+}
+
+func TestSlackEventMessage(t *testing.T) {
+	testcases := []struct {
+		name      string
+		dao       DAO
+		event     slack.MessageEvent
+		header    http.Header
+		messages  []slack.Posted
+		ephemeral []slack.Posted
+	}{
+		{
+			name: "plus plus",
+			dao:  HappyDao(),
+			event: slack.MessageEvent{
+				Type: "message", Channel: "CGENERAL", User: "UCALLER", Text: "<@USER> ++ nice work",
+			},
+			messages: []slack.Posted{{
+				Channel: "CGENERAL",
+				Response: slack.ChannelAttachmentsResponse(
+					"<@UCALLER> is giving 1 karma to <@USER>. <@USER> has 2 karma.",
+					"compliment"),
+			}},
+		},
+		{
+			name: "self karma",
+			dao:  HappyDao(),
+			event: slack.MessageEvent{
+				Type: "message", Channel: "CGENERAL", User: "UCALLER", Text: "<@UCALLER> ++",
+			},
+			ephemeral: []slack.Posted{{
+				Channel:  "CGENERAL",
+				User:     "UCALLER",
+				Response: slack.ErrorResponse(msgAddSelfTarget),
+			}},
+		},
+		{
+			name: "error",
+			dao:  SadDao(),
+			event: slack.MessageEvent{
+				Type: "message", Channel: "CGENERAL", User: "UCALLER", Text: "<@USER> ++",
+			},
+			ephemeral: []slack.Posted{{
+				Channel:  "CGENERAL",
+				User:     "UCALLER",
+				Response: responseUnknownError,
+			}},
+		},
+		{
+			name: "no mentions",
+			dao:  HappyDao(),
+			event: slack.MessageEvent{
+				Type: "message", Channel: "CGENERAL", User: "UCALLER", Text: "good morning",
+			},
+		},
+		{
+			name: "bot message",
+			dao:  HappyDao(),
+			event: slack.MessageEvent{
+				Type: "message", Subtype: "bot_message", BotID: "B123", Channel: "CGENERAL", Text: "<@USER> ++",
+			},
+		},
+		{
+			name: "edited message",
+			dao:  HappyDao(),
+			event: slack.MessageEvent{
+				Type: "message", Subtype: "message_changed", Channel: "CGENERAL", User: "UCALLER", Text: "<@USER> ++",
+			},
+		},
+		{
+			name: "retry",
+			dao:  HappyDao(),
+			event: slack.MessageEvent{
+				Type: "message", Channel: "CGENERAL", User: "UCALLER", Text: "<@USER> ++",
+			},
+			header: http.Header{slack.HeaderRetryNum: []string{"1"}},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			client := slack.NewFakeClient()
+			h := NewHandler(mockProcessor(test.dao), test.dao, client)
+
+			w := postEvent(routes(h), messageEnvelope(test.event), test.header)
+			h.wait()
+
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, test.messages, client.Messages)
+			assert.Equal(t, test.ephemeral, client.Ephemeral)
+		})
+	}
+}
+
+// slowProcessor a processor that doesn't give karma in a message until it is let go
+type slowProcessor struct {
+	Processor
+	release chan struct{}
+}
+
+func (p slowProcessor) ProcessMessage(m slack.MessageEvent) ([]slack.Response, error) {
+	<-p.release
+	return p.Processor.ProcessMessage(m)
+}
+
+func TestSlackEventAcknowledgedFirst(t *testing.T) {
+	dao := HappyDao()
+	client := slack.NewFakeClient()
+	proc := slowProcessor{mockProcessor(dao), make(chan struct{})}
+	h := NewHandler(proc, dao, client)
+
+	// the 200 comes back while the message is still being handled
+	w := postEvent(routes(h), messageEnvelope(slack.MessageEvent{
+		Type: "message", Channel: "CGENERAL", User: "UCALLER", Text: "<@USER> ++",
+	}), nil)
+	assert.Equal(t, 200, w.Code)
+
+	close(proc.release)
+	h.wait()
+	assert.Equal(t, []slack.Posted{{
+		Channel: "CGENERAL",
+		Response: slack.ChannelAttachmentsResponse(
+			"<@UCALLER> is giving 1 karma to <@USER>. <@USER> has 2 karma.",
+			"compliment"),
+	}}, client.Messages)
+}
+
+func TestSlackEventUnsigned(t *testing.T) {
+	r, client := setupWithClient(HappyDao())
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(messageEnvelope(slack.MessageEvent{
+		Type: "message", Channel: "CGENERAL", User: "UCALLER", Text: "<@USER> ++",
+	}))
+	req, _ := http.NewRequest("POST", "/knavebot/v1/events", strings.NewReader(string(body)))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 401, w.Code)
+	assert.Empty(t, client.Messages)
+}
+
+func TestReportKarma(t *testing.T) {
+	reportError := HappyDao()
+	reportError.ReportMock = SadDao().ReportMock
+
+	testcases := []struct {
+		name  string
+		dao   DAO
+		query string
+		code  int
+	}{
+		{"default", HappyDao(), "", 200},
+		{"period", HappyDao(), "?period=quarter", 200},
+		{"last period", HappyDao(), "?period=year&last=true", 200},
+		{"dates", HappyDao(), "?from=2026-01-01&to=2026-03-31&n=3", 200},
+		{"bad period", HappyDao(), "?period=forever", 400},
+		{"bad n", HappyDao(), "?n=-1", 400},
+		{"error", reportError, "", 500},
+		{"config error", SadDao(), "", 500},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			r := setup(test.dao)
+
+			w := httptest.NewRecorder()
+			req := apiRequest("GET", "/karmabot/v1/team/nycfc/report"+test.query, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.code, w.Code)
+			if test.code == 200 {
+				var actual Report
+				err := json.Unmarshal(w.Body.Bytes(), &actual)
+				assert.Nil(t, err)
+				assert.Equal(t, "nycfc", actual.Team)
+				assert.Equal(t, 16, actual.Moved)
+			}
+		})
+	}
+}
+
+func TestReportKarmaSize(t *testing.T) {
+	var sizes []int
+	dao := HappyDao()
+	dao.ReportMock = func(team string, from, to time.Time, n int) (Report, error) {
+		sizes = append(sizes, n)
+		return Report{}, nil
+	}
+	r := setup(dao)
+
+	for _, query := range []string{"", "?n=4", "?n=1000000000"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, apiRequest("GET", "/karmabot/v1/team/nycfc/report"+query, nil))
+		assert.Equal(t, 200, w.Code)
+	}
+
+	assert.Equal(t, []int{3, 4, 10}, sizes)
+}
+
+func TestRankingsHandler(t *testing.T) {
+	sad := SadDao()
+	topError, bottomError := HappyDao(), HappyDao()
+	topError.TopMock, bottomError.BottomMock = sad.TopMock, sad.BottomMock
+
+	testcases := []struct {
+		name     string
+		dao      DAO
+		path     string
+		code     int
+		expected string
+	}{
+		{
+			name:     "top",
+			dao:      HappyDao(),
+			path:     "/karmabot/v1/team/nycfc/rankings/top?n=2",
+			code:     200,
+			expected: `[{"rank":1,"user":"USER0","karma":100,"tied":false},{"rank":2,"user":"USER1","karma":101,"tied":false}]`,
+		},
+		{
+			name:     "bottom",
+			dao:      HappyDao(),
+			path:     "/karmabot/v1/team/nycfc/rankings/bottom?n=1",
+			code:     200,
+			expected: `[{"rank":1,"user":"USER0","karma":-100,"tied":false}]`,
+		},
+		{
+			name:     "bottom malformed n",
+			dao:      HappyDao(),
+			path:     "/karmabot/v1/team/nycfc/rankings/bottom?n=lots",
+			code:     400,
+			expected: apiError(400, "Please pass a positive non-zero integer. lots"),
+		},
+		{
+			name:     "top error",
+			dao:      topError,
+			path:     "/karmabot/v1/team/nycfc/rankings/top",
+			code:     500,
+			expected: apiError(500, "TopMock"),
+		},
+		{
+			name:     "bottom error",
+			dao:      bottomError,
+			path:     "/karmabot/v1/team/nycfc/rankings/bottom",
+			code:     500,
+			expected: apiError(500, "BottomMock"),
+		},
+		{
+			name:     "config error",
+			dao:      SadDao(),
+			path:     "/karmabot/v1/team/nycfc/rankings/top",
+			code:     500,
+			expected: apiError(500, "TeamConfigMock"),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			r := setup(test.dao)
+
+			w := httptest.NewRecorder()
+			req := apiRequest("GET", test.path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, test.expected, w.Body.String())
+		})
+	}
+}
+
+func TestRankingsHandlerSize(t *testing.T) {
+	var sizes []int
+	dao := HappyDao()
+	dao.TopMock = func(team string, n int) ([]UserKarma, error) {
+		sizes = append(sizes, n)
+		return nil, nil
+	}
+	r := setup(dao)
+
+	// the team's top_user_default, then no more than its top_user_max
+	for _, path := range []string{"/karmabot/v1/team/nycfc/rankings/top", "/karmabot/v1/team/nycfc/rankings/top?n=1000000000"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, apiRequest("GET", path, nil))
+		assert.Equal(t, 200, w.Code)
+	}
+
+	assert.Equal(t, []int{3, 10}, sizes)
+}
+
+func TestTeamConfigHandler(t *testing.T) {
+	testcases := []struct {
+		name     string
+		dao      DAO
+		method   string
+		path     string
+		body     string
+		code     int
+		expected string
+	}{
+		{
+			name:     "get",
+			dao:      HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/config",
+			code:     200,
+			expected: `{"team":"nycfc","config":{"single_limit":5,"daily_limit":25,"top_user_default":3,"top_user_max":10,"pair_cooldown_minutes":0,"pair_limit":0,"pair_window_hours":24,"recipient_daily_limit":0,"undo_window_minutes":5,"timezone":"UTC","daily_window":"day","group_policy":"split","season_length":"off"},"overrides":{}}`,
+		},
+		{
+			name:     "get error",
+			dao:      SadDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/config",
+			code:     500,
+			expected: apiError(500, "TeamConfigMock"),
+		},
+		{
+			name:     "set",
+			dao:      HappyDao(),
+			method:   "PUT",
+			path:     "/karmabot/v1/team/nycfc/config/daily_limit",
+			body:     `{"value": "50"}`,
+			code:     200,
+			expected: `{"team":"nycfc","config":{"single_limit":5,"daily_limit":25,"top_user_default":3,"top_user_max":10,"pair_cooldown_minutes":0,"pair_limit":0,"pair_window_hours":24,"recipient_daily_limit":0,"undo_window_minutes":5,"timezone":"UTC","daily_window":"day","group_policy":"split","season_length":"off"},"overrides":{}}`,
+		},
+		{
+			name:     "set invalid",
+			dao:      HappyDao(),
+			method:   "PUT",
+			path:     "/karmabot/v1/team/nycfc/config/daily_limit",
+			body:     `{"value": "1"}`,
+			code:     400,
+			expected: apiError(400, "invalid config: daily_limit (1) must be at least single_limit (5)"),
+		},
+		{
+			name:     "set without value",
+			dao:      HappyDao(),
+			method:   "PUT",
+			path:     "/karmabot/v1/team/nycfc/config/daily_limit",
+			body:     `{"actor": "UADMIN"}`,
+			code:     400,
+			expected: apiError(400, `Please pass the value. {"value": "50"}`),
+		},
+		{
+			name:     "set error",
+			dao:      SadDao(),
+			method:   "PUT",
+			path:     "/karmabot/v1/team/nycfc/config/daily_limit",
+			body:     `{"value": "50"}`,
+			code:     500,
+			expected: apiError(500, "TeamConfigMock"),
+		},
+		{
+			name:     "reset",
+			dao:      HappyDao(),
+			method:   "DELETE",
+			path:     "/karmabot/v1/team/nycfc/config/daily_limit",
+			code:     200,
+			expected: `{"team":"nycfc","config":{"single_limit":5,"daily_limit":25,"top_user_default":3,"top_user_max":10,"pair_cooldown_minutes":0,"pair_limit":0,"pair_window_hours":24,"recipient_daily_limit":0,"undo_window_minutes":5,"timezone":"UTC","daily_window":"day","group_policy":"split","season_length":"off"},"overrides":{}}`,
+		},
+		{
+			name:     "audit",
+			dao:      HappyDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/audit?n=1",
+			code:     200,
+			expected: `[{"id":1,"team":"nycfc","actor":"UADMIN","action":"config set","target":"daily_limit","detail":"from default to 0","created_at":"2019-11-09T00:00:00Z"}]`,
+		},
+		{
+			name:     "audit error",
+			dao:      SadDao(),
+			method:   "GET",
+			path:     "/karmabot/v1/team/nycfc/audit",
+			code:     500,
+			expected: apiError(500, "AuditMock"),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			r := setup(test.dao)
+
+			w := httptest.NewRecorder()
+			req := apiRequest(test.method, test.path, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, test.expected, w.Body.String())
+		})
+	}
+}
+
+func TestAuditLogSize(t *testing.T) {
+	var sizes []int
+	dao := HappyDao()
+	dao.AuditMock = func(team string, n int) ([]AuditEntry, error) {
+		sizes = append(sizes, n)
+		return nil, nil
+	}
+	r := setup(dao)
+
+	for _, query := range []string{"", "?n=50", "?n=1000000000"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, apiRequest("GET", "/karmabot/v1/team/nycfc/audit"+query, nil))
+		assert.Equal(t, 200, w.Code)
+	}
+
+	assert.Equal(t, []int{20, 50, 100}, sizes)
+}
+
+func TestTeamConfigHandlerActor(t *testing.T) {
+	var actors []string
+	dao := HappyDao()
+	dao.SetTeamConfigMock = func(team, setting, value, actor string) error {
+		actors = append(actors, actor)
+		return nil
+	}
+	dao.ResetTeamConfigMock = func(team, setting, actor string) error {
+		actors = append(actors, actor)
+		return nil
+	}
+	r := setup(dao)
+
+	// whoever the request says it is, the audit trail has the token that made it
+	for _, req := range []*http.Request{
+		apiRequest("PUT", "/karmabot/v1/team/nycfc/config/daily_limit", strings.NewReader(`{"value": "50", "actor": "USOMEONEELSE"}`)),
+		apiRequest("DELETE", "/karmabot/v1/team/nycfc/config/daily_limit?actor=USOMEONEELSE", nil),
+	} {
+		w := httptest.NewRecorder()
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+	}
+
+	assert.Equal(t, []string{"token:3 (admin)", "token:3 (admin)"}, actors)
+	assert.Equal(t, "`token:3 (admin)`", MsgActor("token:3 (admin)"))
+	assert.Equal(t, "<@UADMIN>", MsgActor("UADMIN"))
+}
@@ -0,0 +1,321 @@
+package karma
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// v2 page sizes
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	maxPageOffset    = 100000
+)
+
+// history directions
+const (
+	received = "received"
+	given    = "given"
+)
+
+// KarmaResponse a user's karma
+type KarmaResponse struct {
+	Team  string `json:"team"`
+	User  string `json:"user"`
+	Karma int    `json:"karma"`
+}
+
+// KarmaRequest gives karma to a user, a negative delta takes it away
+type KarmaRequest struct {
+	Delta int `json:"delta"`
+}
+
+// PageInfo where a page is in a list. NextOffset is missing on the last page
+type PageInfo struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+// TransactionResponse a single movement of karma. From is empty when it was adjusted through the api
+type TransactionResponse struct {
+	ID        int64     `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Channel   string    `json:"channel"`
+	Delta     int       `json:"delta"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HistoryResponse a page of the karma a user has received or given, most recent first
+type HistoryResponse struct {
+	Team      string                `json:"team"`
+	User      string                `json:"user"`
+	Direction string                `json:"direction"`
+	Items     []TransactionResponse `json:"items"`
+	Page      PageInfo              `json:"page"`
+}
+
+// RankingsResponse a page of a team's leaderboard
+type RankingsResponse struct {
+	Team  string    `json:"team"`
+	Order string    `json:"order"`
+	Items []Ranking `json:"items"`
+	Page  PageInfo  `json:"page"`
+}
+
+// UserTotal a user's share of the karma moved during a period
+type UserTotal struct {
+	User  string `json:"user"`
+	Karma int    `json:"karma"`
+}
+
+// ChannelTotal the karma moved in a channel during a period
+type ChannelTotal struct {
+	Channel      string `json:"channel"`
+	Transactions int    `json:"transactions"`
+	Karma        int    `json:"karma"`
+}
+
+// TeamStatsResponse the karma moved in a team during a period. From is inclusive and To is exclusive
+type TeamStatsResponse struct {
+	Team         string         `json:"team"`
+	Period       string         `json:"period"`
+	From         time.Time      `json:"from"`
+	To           time.Time      `json:"to"`
+	Transactions int            `json:"transactions"`
+	Net          int            `json:"net"`
+	Moved        int            `json:"moved"`
+	Gainers      []UserTotal    `json:"gainers"`
+	Givers       []UserTotal    `json:"givers"`
+	Channels     []ChannelTotal `json:"channels"`
+}
+
+// ErrorEnvelope the body of every error response from the v2 api
+type ErrorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+// abortEnvelope stops the request with a v2 error body
+func abortEnvelope(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, ErrorEnvelope{APIError{
+		Status:  status,
+		Error:   http.StatusText(status),
+		Message: message,
+	}})
+}
+
+// queryInt an integer query param from min to max, or its default when it is missing
+func queryInt(c *gin.Context, name string, def, min, max int) (int, bool) {
+	q := c.Query(name)
+	if q == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(q)
+	if err != nil || n < min || n > max {
+		abortEnvelope(c, 400, fmt.Sprintf("Please pass %v as an integer from %v to %v. %v", name, min, max, q))
+		return 0, false
+	}
+	return n, true
+}
+
+// queryPage the ?limit and ?offset of a page
+func queryPage(c *gin.Context) (PageInfo, bool) {
+	limit, ok := queryInt(c, "limit", defaultPageLimit, 1, maxPageLimit)
+	if !ok {
+		return PageInfo{}, false
+	}
+	offset, ok := queryInt(c, "offset", 0, 0, maxPageOffset)
+	if !ok {
+		return PageInfo{}, false
+	}
+	return PageInfo{Limit: limit, Offset: offset}, true
+}
+
+// next sets NextOffset when there was more than a page of results
+func (p PageInfo) next(found int) PageInfo {
+	if found > p.Limit {
+		next := p.Offset + p.Limit
+		p.NextOffset = &next
+	}
+	return p
+}
+
+// KarmaV2 a user's karma
+func (h SQLiteHandler) KarmaV2(c *gin.Context) {
+	team, user := c.Param("team"), c.Param("user")
+
+	k, err := h.dao.GetKarma(team, user)
+	if err != nil {
+		log.Errorf("Unable to lookup karma. %v %v %v", team, user, err)
+		abortEnvelope(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, KarmaResponse{team, user, k})
+}
+
+// GiveKarmaV2 gives (or takes) karma from a user. The body is a KarmaRequest
+func (h SQLiteHandler) GiveKarmaV2(c *gin.Context) {
+	team, user := c.Param("team"), c.Param("user")
+
+	var req KarmaRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Delta == 0 {
+		abortEnvelope(c, 400, `Please pass a non-zero delta. {"delta": 1}`)
+		return
+	}
+
+	k, err := h.dao.UpdateKarma(team, user, req.Delta)
+	if err != nil {
+		log.Errorf("Unable to add or remove karma. %v %v %v %v", team, user, req.Delta, err)
+		abortEnvelope(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, KarmaResponse{team, user, k})
+}
+
+// ResetKarmaV2 resets a user's karma to zero
+func (h SQLiteHandler) ResetKarmaV2(c *gin.Context) {
+	team, user := c.Param("team"), c.Param("user")
+
+	k, err := h.dao.DeleteKarma(team, user)
+	if err != nil {
+		log.Errorf("Unable to reset karma. %v %v %v", team, user, err)
+		abortEnvelope(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, KarmaResponse{team, user, k})
+}
+
+// HistoryV2 pages through the karma a user has received, or ?direction=given
+func (h SQLiteHandler) HistoryV2(c *gin.Context) {
+	team, user := c.Param("team"), c.Param("user")
+
+	page, ok := queryPage(c)
+	if !ok {
+		return
+	}
+
+	direction := c.DefaultQuery("direction", received)
+	history := h.dao.Received
+	switch direction {
+	case received:
+	case given:
+		history = h.dao.Given
+	default:
+		abortEnvelope(c, 400, fmt.Sprintf("Please pass a direction of received or given. %v", direction))
+		return
+	}
+
+	// one more than a page, to know if there is another
+	transactions, err := history(team, user, page.Limit+1, page.Offset)
+	if err != nil {
+		log.Errorf("Unable to lookup karma history. %v %v %v", team, user, err)
+		abortEnvelope(c, 500, err.Error())
+		return
+	}
+
+	items := make([]TransactionResponse, 0, page.Limit)
+	for i, t := range transactions {
+		if i == page.Limit {
+			break
+		}
+		items = append(items, TransactionResponse{t.ID, t.From, t.To, t.Channel, t.Delta, t.Message, t.CreatedAt})
+	}
+
+	c.JSON(200, HistoryResponse{team, user, direction, items, page.next(len(transactions))})
+}
+
+// RankingsV2 pages through a team's leaderboard, from the top or ?order=bottom
+func (h SQLiteHandler) RankingsV2(c *gin.Context) {
+	team := c.Param("team")
+
+	page, ok := queryPage(c)
+	if !ok {
+		return
+	}
+
+	order := c.DefaultQuery("order", top)
+	leaderboard := h.dao.Top
+	switch order {
+	case top:
+	case bottom:
+		leaderboard = h.dao.Bottom
+	default:
+		abortEnvelope(c, 400, fmt.Sprintf("Please pass an order of top or bottom. %v", order))
+		return
+	}
+
+	// ranks (and ties) depend on the users before the page, and one after it
+	users, err := leaderboard(team, page.Offset+page.Limit+1)
+	if err != nil {
+		log.Errorf("Unable to rank users. %v %v %v", team, page, err)
+		abortEnvelope(c, 500, err.Error())
+		return
+	}
+
+	rankings := Rankings(users)
+	items := make([]Ranking, 0, page.Limit)
+	for i := page.Offset; i < len(rankings) && i < page.Offset+page.Limit; i++ {
+		items = append(items, rankings[i])
+	}
+
+	c.JSON(200, RankingsResponse{team, order, items, page.next(len(rankings) - page.Offset)})
+}
+
+// TeamStatsV2 summarizes the karma moved in a team during a period, this month by default
+func (h SQLiteHandler) TeamStatsV2(c *gin.Context) {
+	team := c.Param("team")
+
+	n, ok := queryInt(c, "n", 5, 1, maxPageLimit)
+	if !ok {
+		return
+	}
+	period, ok := queryPeriod(c)
+	if !ok {
+		abortEnvelope(c, 400, "Please pass a valid period. ?period=week|month|quarter|year&last=true or ?from=2006-01-02&to=2006-01-02")
+		return
+	}
+
+	r, err := h.dao.Report(team, period.From, period.To, n)
+	if err != nil {
+		log.Errorf("Unable to build karma report. %v %v %v", team, period, err)
+		abortEnvelope(c, 500, err.Error())
+		return
+	}
+
+	stats := TeamStatsResponse{
+		Team:         team,
+		Period:       period.String(),
+		From:         period.From,
+		To:           period.To,
+		Transactions: r.Transactions,
+		Net:          r.Net,
+		Moved:        r.Moved,
+		Gainers:      userTotals(r.Gainers),
+		Givers:       userTotals(r.Givers),
+		Channels:     make([]ChannelTotal, 0, len(r.Channels)),
+	}
+	for _, ch := range r.Channels {
+		stats.Channels = append(stats.Channels, ChannelTotal{ch.Channel, ch.Transactions, ch.Karma})
+	}
+
+	c.JSON(200, stats)
+}
+
+func userTotals(users []UserKarma) []UserTotal {
+	totals := make([]UserTotal, 0, len(users))
+	for _, u := range users {
+		totals = append(totals, UserTotal{u.User, u.Karma})
+	}
+	return totals
+}
@@ -0,0 +1,133 @@
+package karma
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKarmaV2(t *testing.T) {
+	testcases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		code     int
+		expected string
+	}{
+		{"get", "GET", "/karmabot/v2/teams/nycfc/users/davidvilla", "", 200, `{"team":"nycfc","user":"davidvilla","karma":5}`},
+		{"give", "POST", "/karmabot/v2/teams/nycfc/users/davidvilla/karma", `{"delta": 2}`, 200, `{"team":"nycfc","user":"davidvilla","karma":3}`},
+		{"take", "POST", "/karmabot/v2/teams/nycfc/users/davidvilla/karma", `{"delta": -2}`, 200, `{"team":"nycfc","user":"davidvilla","karma":-1}`},
+		{"give nothing", "POST", "/karmabot/v2/teams/nycfc/users/davidvilla/karma", `{"delta": 0}`, 400, `{"error":{"status":400,"error":"Bad Request","message":"Please pass a non-zero delta. {\"delta\": 1}"}}`},
+		{"give malformed", "POST", "/karmabot/v2/teams/nycfc/users/davidvilla/karma", `{"delta": "lots"}`, 400, `{"error":{"status":400,"error":"Bad Request","message":"Please pass a non-zero delta. {\"delta\": 1}"}}`},
+		{"reset", "DELETE", "/karmabot/v2/teams/nycfc/users/davidvilla/karma", "", 200, `{"team":"nycfc","user":"davidvilla","karma":0}`},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			r := setup(HappyDao())
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, apiRequest(test.method, test.path, strings.NewReader(test.body)))
+
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, test.expected, w.Body.String())
+		})
+	}
+}
+
+func TestHistoryV2(t *testing.T) {
+	dao := HappyDao()
+	var asked []int
+	dao.GivenMock = func(team, user string, limit, offset int) ([]Transaction, error) {
+		asked = []int{limit, offset}
+		// only 3 left from the offset
+		return mockTransactions(team, user, "USER", 3), nil
+	}
+	r := setup(dao)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, apiRequest("GET", "/karmabot/v2/teams/nycfc/users/davidvilla/history?limit=2", nil))
+	assert.Equal(t, 200, w.Code)
+
+	var page HistoryResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, received, page.Direction)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "USER", page.Items[0].From)
+	assert.Equal(t, 2, *page.Page.NextOffset)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, apiRequest("GET", "/karmabot/v2/teams/nycfc/users/davidvilla/history?direction=given&limit=5&offset=10", nil))
+	assert.Equal(t, 200, w.Code)
+
+	page = HistoryResponse{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []int{6, 10}, asked)
+	assert.Equal(t, given, page.Direction)
+	assert.Len(t, page.Items, 3)
+	assert.Equal(t, "davidvilla", page.Items[0].From)
+	assert.Equal(t, PageInfo{Limit: 5, Offset: 10}, page.Page)
+}
+
+func TestRankingsV2(t *testing.T) {
+	dao := HappyDao()
+	dao.TopMock = func(team string, n int) ([]UserKarma, error) {
+		users := []UserKarma{{"A", 9}, {"B", 7}, {"C", 7}, {"D", 5}}
+		if n < len(users) {
+			users = users[:n]
+		}
+		return users, nil
+	}
+	r := setup(dao)
+
+	testcases := []struct {
+		name     string
+		query    string
+		expected RankingsResponse
+	}{
+		{"first page", "?limit=2", RankingsResponse{"nycfc", top, []Ranking{{1, "A", 9, false}, {2, "B", 7, true}}, PageInfo{2, 0, intPtr(2)}}},
+		{"ties across pages", "?limit=2&offset=2", RankingsResponse{"nycfc", top, []Ranking{{2, "C", 7, true}, {4, "D", 5, false}}, PageInfo{2, 2, nil}}},
+		{"past the end", "?offset=10", RankingsResponse{"nycfc", top, []Ranking{}, PageInfo{20, 10, nil}}},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, apiRequest("GET", "/karmabot/v2/teams/nycfc/rankings"+test.query, nil))
+			assert.Equal(t, 200, w.Code)
+
+			var actual RankingsResponse
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &actual))
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestTeamStatsV2(t *testing.T) {
+	r := setup(HappyDao())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, apiRequest("GET", "/karmabot/v2/teams/nycfc/stats?from=2026-01-01&to=2026-03-31", nil))
+	assert.Equal(t, 200, w.Code)
+
+	var stats TeamStatsResponse
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, "2026-01-01 to 2026-03-31", stats.Period)
+	assert.Equal(t, 16, stats.Moved)
+	assert.Equal(t, []UserTotal{{"USER0", 10}, {"USER1", 4}}, stats.Gainers)
+	assert.Equal(t, []ChannelTotal{{"CGENERAL", 7, 12}}, stats.Channels)
+
+	for _, query := range []string{"?period=forever", "?to=2026-03-31", "?last=maybe", "?n=0"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, apiRequest("GET", "/karmabot/v2/teams/nycfc/stats"+query, nil))
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
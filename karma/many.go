@@ -0,0 +1,119 @@
+package karma
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// parseUsers the users from idx onwards, up to the first word that isn't one
+func parseUsers(words []string, idx int) []string {
+	var users []string
+	for ; idx < len(words); idx++ {
+		user, ok := parseArgUser(words, idx)
+		if !ok {
+			break
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+// giveMany gives (or takes) delta karma to each of the targets, all or nothing. Every rule of give holds for each target,
+// and the daily limit for the combined amount. One response lists everyone's new karma
+func (p SlackProcessor) giveMany(team, channel, callee string, targets []string, delta int, reason string) (slack.Response, error) {
+	targets = distinct(targets)
+	if len(targets) == 1 {
+		return p.give(team, channel, callee, targets[0], delta, reason)
+	}
+
+	if slices.Contains(targets, callee) {
+		if delta < 0 {
+			return slack.ErrorResponse(msgSubtractSelfTarget), nil
+		}
+		return slack.ErrorResponse(msgAddSelfTarget), nil
+	}
+
+	if delta == 0 {
+		return slack.ErrorResponse(msgNoOp), nil
+	}
+
+	deltas := make([]int, len(targets))
+	for i := range deltas {
+		deltas[i] = delta
+	}
+	ks, resp, err := p.moveMany(team, channel, callee, targets, deltas, reason)
+	if err != nil || ks == nil {
+		return resp, err
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	if delta > 0 {
+		msg.WriteString(MsgGiveKarmaMany(callee, targets, delta, reason))
+	} else {
+		msg.WriteString(MsgTakeKarmaMany(callee, targets, -delta, reason))
+	}
+	msg.WriteString(MsgUsersStatus(targets, ks))
+	att.WriteString(p.Salutation(delta))
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
+
+// moveMany moves deltas[i] karma to targets[i], all or nothing. Every rule of give holds for each target, and the daily limit
+// for the combined amount. ks is nil, with a response saying why, when a rule is broken
+func (p SlackProcessor) moveMany(team, channel, callee string, targets []string, deltas []int, reason string) ([]int, slack.Response, error) {
+	total := 0
+	for _, delta := range deltas {
+		if Abs(delta) > p.config.SingleLimit {
+			return nil, slack.ErrorResponse(MsgDeltaLimit(p.config.SingleLimit)), nil
+		}
+		total += Abs(delta)
+	}
+
+	for _, target := range targets {
+		if resp, banned, err := p.banned(team, callee, target); err != nil || banned {
+			return nil, resp, err
+		}
+	}
+
+	now := p.teamNow()
+	if resp, over, err := p.overDailyLimit(team, callee, total, now); err != nil || over {
+		return nil, resp, err
+	}
+
+	for i, target := range targets {
+		if resp, rejected, err := p.pairRules(team, callee, target, deltas[i], now); err != nil || rejected {
+			return nil, resp, err
+		}
+	}
+
+	ts := make([]Transaction, 0, len(targets))
+	for i, target := range targets {
+		ts = append(ts, Transaction{
+			Team:    team,
+			From:    callee,
+			To:      target,
+			Channel: channel,
+			Delta:   deltas[i],
+			Message: reason,
+		})
+	}
+	ks, err := p.dao.UpdateKarmaDailyMany(ts, now)
+	if err != nil {
+		return nil, slack.Response{}, err
+	}
+	return ks, slack.Response{}, nil
+}
+
+// distinct the users in order, without repeats
+func distinct(users []string) []string {
+	seen := make(map[string]struct{}, len(users))
+	unique := make([]string, 0, len(users))
+	for _, u := range users {
+		if _, ok := seen[u]; !ok {
+			seen[u] = struct{}{}
+			unique = append(unique, u)
+		}
+	}
+	return unique
+}
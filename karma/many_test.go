@@ -0,0 +1,110 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUsers(t *testing.T) {
+	words := []string{"++", "<@UA>", "<@UB|bob>", "UC", "2", "<@UD>"}
+	assert.Equal(t, []string{"UA", "UB", "UC"}, parseUsers(words, 1))
+	assert.Nil(t, parseUsers(words, 4))
+	assert.Nil(t, parseUsers(words, 10))
+}
+
+func TestProcessMany(t *testing.T) {
+	var applied [][]Transaction
+	dao := HappyDao()
+	dao.UpdateKarmaDailyManyMock = func(ts []Transaction, date time.Time) ([]int, error) {
+		applied = append(applied, ts)
+		ks := make([]int, 0, len(ts))
+		for i := range ts {
+			ks = append(ks, i+1)
+		}
+		return ks, nil
+	}
+	p := mockProcessor(dao)
+
+	testcases := []ProcessTestCase{
+		{
+			name:         "++",
+			command:      command("++ <@UA> <@UB> <@UC> 2 for the demo"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 2 karma each to <@UA>, <@UB> and <@UC> for the demo. <@UA> has 1, <@UB> has 2 and <@UC> has 3 karma.",
+			attach:       true,
+		},
+		{
+			name:         "-- without an amount",
+			command:      command("-- <@UA> <@UB> for the outage"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is taking away 1 karma each from <@UA> and <@UB> for the outage. <@UA> has 1 and <@UB> has 2 karma.",
+			attach:       true,
+		},
+		{
+			name:         "alias",
+			command:      command("+3 <@UA> <@UB>"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 3 karma each to <@UA> and <@UB>. <@UA> has 1 and <@UB> has 2 karma.",
+			attach:       true,
+		},
+		{
+			name:         "the same user twice is one user",
+			command:      command("++ <@UA> <@UA> 2"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 2 karma to <@UA>. <@UA> has 3 karma.",
+			attach:       true,
+		},
+		{
+			name:         "self karma",
+			command:      command("++ <@UA> <@UCALLER>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgAddSelfTarget,
+		},
+		{
+			name:         "single limit",
+			command:      command("++ <@UA> <@UB> 6"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgDeltaLimit(5),
+		},
+		{
+			name:         "banned target",
+			command:      command("++ <@UA> <@UBANNED>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgBannedTarget("UBANNED"),
+		},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, p, test)
+	}
+
+	if assert.Len(t, applied, 3) {
+		assert.Equal(t, []Transaction{
+			{From: "UCALLER", To: "UA", Delta: 2, Message: "the demo"},
+			{From: "UCALLER", To: "UB", Delta: 2, Message: "the demo"},
+			{From: "UCALLER", To: "UC", Delta: 2, Message: "the demo"},
+		}, applied[0])
+	}
+}
+
+func TestProcessManyDailyLimit(t *testing.T) {
+	// 5 left today, which is enough for any one of them but not all three
+	dao := NewMockDao(20)
+	dao.UpdateKarmaDailyManyMock = func(ts []Transaction, date time.Time) ([]int, error) {
+		t.Error("nothing should be applied")
+		return nil, nil
+	}
+
+	processHelper(t, mockProcessor(dao), ProcessTestCase{
+		name:         "combined amount",
+		command:      command("++ <@UA> <@UB> <@UC> 2"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         MsgOverDailyLimit(25, 20, 5, time.Date(2026, time.May, 5, 0, 0, 0, 0, time.UTC), mockNow),
+	})
+
+	_, err := sadMockProcessor().Process(command("++ <@UA> <@UB>"))
+	assert.NotNil(t, err)
+}
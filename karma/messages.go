@@ -3,6 +3,7 @@ package karma
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/icemanblues/knave-bot/slack"
 )
@@ -15,6 +16,13 @@ const (
 	cmdSub    = "/karma -- @user"
 	cmdHelp   = "/karma help"
 	cmdTop    = "/karma top"
+	cmdBottom = "/karma bottom"
+	cmdReport = "/karma report"
+	cmdWhy    = "/karma why @user"
+	cmdConfig = "/karma config"
+	cmdAdmin  = "/karma admin"
+	cmdUndo   = "/karma undo"
+	cmdSeason = "/karma season"
 )
 
 // Slack Reponses
@@ -36,22 +44,57 @@ var ResponseHelp = slack.Response{
 				},
 				{
 					Title: cmdStatus,
-					Value: "Provide a @user and return their karma.",
+					Value: "Provide a @user, or a thing like `pizza`, and return their karma.",
 					Short: true,
 				},
 				{
 					Title: cmdAdd,
-					Value: "Provide a @user and increase their karma. Optionally, pass a quantity of karma to give.",
+					Value: "Provide a @user (or several, `@a @b @c`), a group like `@devs`, `@here` or `@channel`, or a thing like `pizza`, `\"pizza party\"` or `#release`, and increase their karma. Optionally, pass a quantity of karma to give each and a reason: `3 for fixing the build`",
 					Short: true,
 				},
 				{
 					Title: cmdSub,
-					Value: "Provide a @user and decrease their karma. Optionally, pass a quantity of karma to take.",
+					Value: "Provide a @user, a group, or a thing, and decrease their karma. Optionally, pass a quantity of karma to take and a reason.",
 					Short: true,
 				},
 				{
 					Title: cmdTop,
-					Value: "Return the top 3 users by karma. Optionally, pass a quantity for the top n users",
+					Value: "Return the top 3 users by karma, this season when one is running. Optionally, pass a quantity for the top n users. `/karma top things` for things, `all` for all time",
+					Short: true,
+				},
+				{
+					Title: cmdWhy,
+					Value: "Provide a @user and return the reasons they were recently given karma.",
+					Short: true,
+				},
+				{
+					Title: cmdBottom,
+					Value: "Return the bottom 3 users by karma. Optionally, pass a quantity for the bottom n users. Also `/karma bot`",
+					Short: true,
+				},
+				{
+					Title: cmdReport,
+					Value: "Summarize the karma given this month. Optionally, pass `week`, `quarter`, `year`, `last month` or dates `2006-01-02 2006-01-31`",
+					Short: true,
+				},
+				{
+					Title: cmdConfig,
+					Value: "Admins only. Show this team's settings. Change one with `set daily_limit 50`, undo it with `reset daily_limit`, and see who changed what with `history`",
+					Short: true,
+				},
+				{
+					Title: cmdAdmin,
+					Value: "Admins only. `set @user 10` or `reset @user` their karma, `ban @user` or `unban @user` from karma, `add @user` or `remove @user` as an admin, `list` admins and bans, and `history`",
+					Short: true,
+				},
+				{
+					Title: cmdSeason,
+					Value: "Show the running season and its leaderboard. `/karma top season:2026Q2` for an old one, `/karma top all` for all time. Admins `start 2026Q3` and `end` seasons",
+					Short: true,
+				},
+				{
+					Title: cmdUndo,
+					Value: "Take back the karma you last gave (or took), if it was in the last few minutes.",
 					Short: true,
 				},
 				{
@@ -69,7 +112,6 @@ const (
 	msgMissingName           = "I need to know whose karma to retrieve."
 	msgNoOp                  = "Don't waste my time. For shame!"
 	msgInvalidUser           = "I'm not sure that name is a valid slack user."
-	msgDeltaLimit            = "Whoa there! Let's keep the karma swings to 5 and under."
 	msgAddMissingTarget      = "To whom do you want to give karma?"
 	msgAddSelfTarget         = "Don't be a weasel. For Shame!"
 	msgAddCantRemove         = "`++` is used to give karma. Try `--` to take away karma."
@@ -77,11 +119,47 @@ const (
 	msgSubtractSelfTarget    = "Do you have something to confess? Why remove your own karma?"
 	msgSubtractCantAdd       = "Negative karma doesn't make sense. Please use positive numbers!"
 	msgNoKarmaForTop         = "Um.. is it possible that there are no users with positive karma :("
+	msgNoKarmaForBottom      = "Nobody has any karma yet. Not even the bad kind."
+	msgNoKarmaForThings      = "No thing has any karma yet. Try `/karma ++ pizza`."
+	msgNoDirectory           = "I can't see who is in a group without a slack bot token."
+	msgHereTimeout           = "Slack took too long to say who is online. Try again, or try a user group instead."
+	msgUndoOff               = "Undo is turned off for this team."
+	msgUndoThing             = "The last karma you gave was to a thing, and karma for things can't be undone."
+	msgNoSeason              = "No season is running. An admin can start one with `/karma season start`."
+	msgSeasonAlreadyRunning  = "A season is already running. End it with `/karma season end` first."
+	msgSeasonUsage           = "Try `/karma season`, or for admins `/karma season start 2026Q3` and `/karma season end`."
+	msgSeasonName            = "A season's name is a word of up to 32 letters, numbers, `-`, `_` or `.`, like `2026Q3`. It can't be `all`."
+	msgReportInvalidPeriod   = "I don't know that period. Try `week`, `month`, `quarter`, `year`, `last month` or dates like `2006-01-02 2006-01-31`."
+	msgNotAdmin              = "Only admins can do that. Nice try though."
+	msgConfigUsage           = "Try `/karma config`, `/karma config set daily_limit 50`, `/karma config reset daily_limit` or `/karma config history`."
+	msgNoAudit               = "Nobody has changed anything. Yet."
+	msgAdminUsage            = "Try `/karma admin set @user 10`, `reset @user`, `ban @user`, `unban @user`, `add @user`, `remove @user`, `list` or `history`."
+	msgBannedGiver           = "You have been banned from giving or taking karma. Take it up with an admin."
 )
 
-// MsgOverDailyLimit generates daily limit error message (string)
-func MsgOverDailyLimit(limit, usage, remainder int) string {
-	return fmt.Sprintf("Ah ah ah! The daily limit is %v and you've given/taken %v karma already. Only %v remaining", limit, usage, remainder)
+// MsgDeltaLimit the most karma that can be given or taken at once
+func MsgDeltaLimit(limit int) string {
+	return fmt.Sprintf("Whoa there! Let's keep the karma swings to %v and under.", limit)
+}
+
+// MsgOverDailyLimit generates daily limit error message (string), with when there will be enough
+func MsgOverDailyLimit(limit, usage, remainder int, at, now time.Time) string {
+	return fmt.Sprintf("Ah ah ah! The daily limit is %v and you've given/taken %v karma already. Only %v remaining. Try again %v.", limit, usage, remainder, msgWhen(at, now))
+}
+
+// MsgPairCooldown the giver gave the same user karma too recently
+func MsgPairCooldown(target string, at, now time.Time) string {
+	return fmt.Sprintf("Easy there! You just gave <@%s> karma. You can give them more %v.", target, msgWhen(at, now))
+}
+
+// MsgPairLimit the giver has given the same user as much karma as they can for a while
+func MsgPairLimit(target string, limit, hours int, at, now time.Time) string {
+	return fmt.Sprintf("You can only give <@%s> %v karma every %vh, and you're at the limit. Try again %v.", target, limit, hours, msgWhen(at, now))
+}
+
+// MsgRecipientLimit the user has received as much karma as they can today
+func MsgRecipientLimit(target string, limit int, at, now time.Time) string {
+	return fmt.Sprintf("<@%s> has had all the karma they can get today (%v). Try again %v.", target, limit, msgWhen(at, now))
 }
 
 // MsgUserStatus the User's Karma status
@@ -94,32 +172,380 @@ func MsgUserDailyLimit(usage, remaining int) string {
 	return fmt.Sprintf("You have given/taken %v karma with %v remaining today.", usage, remaining)
 }
 
+// MsgUserRollingLimit the remaining daily limit, when it is a rolling 24h window
+func MsgUserRollingLimit(usage, remaining int) string {
+	return fmt.Sprintf("You have given/taken %v karma in the last 24h with %v remaining.", usage, remaining)
+}
+
+// MsgAllowanceResets when all of the daily limit is free again
+func MsgAllowanceResets(at, now time.Time) string {
+	return fmt.Sprintf("It all frees up %v.", msgWhen(at, now))
+}
+
+// MsgAllowanceFrees when the oldest karma given leaves the rolling window, and when all of it has
+func MsgAllowanceFrees(next int, at, all, now time.Time) string {
+	return fmt.Sprintf("%v more frees up %v, and all of it %v.", next, msgWhen(at, now), msgWhen(all, now))
+}
+
 // MsgUserStatusTarget lets all users know who requested karma totals
 func MsgUserStatusTarget(callee, target string) string {
 	return fmt.Sprintf("<@%s> has requested karma total for <@%s>. ", callee, target)
 }
 
-// MsgGiveKarma announces who gave how much karma to whom
-func MsgGiveKarma(callee, target string, delta int) string {
-	return fmt.Sprintf("<@%s> is giving %v karma to <@%s>. ", callee, delta, target)
+// MsgGiveKarma announces who gave how much karma to whom, and why
+func MsgGiveKarma(callee, target string, delta int, reason string) string {
+	return fmt.Sprintf("<@%s> is giving %v karma to <@%s>%s. ", callee, delta, target, msgFor(reason))
+}
+
+// MsgTakeKarma announces who took how much karma from whom, and why
+func MsgTakeKarma(callee, target string, delta int, reason string) string {
+	return fmt.Sprintf("<@%s> is taking away %v karma from <@%s>%s. ", callee, delta, target, msgFor(reason))
+}
+
+// MsgGiveKarmaMany announces who gave how much karma to each of several users, and why
+func MsgGiveKarmaMany(callee string, targets []string, delta int, reason string) string {
+	return fmt.Sprintf("<@%s> is giving %v karma each to %s%s. ", callee, delta, msgUserList(targets), msgFor(reason))
+}
+
+// MsgTakeKarmaMany announces who took how much karma from each of several users, and why
+func MsgTakeKarmaMany(callee string, targets []string, delta int, reason string) string {
+	return fmt.Sprintf("<@%s> is taking away %v karma each from %s%s. ", callee, delta, msgUserList(targets), msgFor(reason))
+}
+
+// MsgUsersStatus several users' karma
+func MsgUsersStatus(users []string, ks []int) string {
+	totals := make([]string, 0, len(users))
+	for i, u := range users {
+		totals = append(totals, fmt.Sprintf("<@%s> has %v", u, ks[i]))
+	}
+	return fmt.Sprintf("%v karma.", msgList(totals))
+}
+
+// MsgUndo announces that the callee has taken back the karma they last gave (or took)
+func MsgUndo(callee string, ts []Transaction) string {
+	targets := make([]string, 0, len(ts))
+	for _, t := range ts {
+		targets = append(targets, t.To)
+	}
+
+	if len(ts) == 1 {
+		if ts[0].Delta < 0 {
+			return fmt.Sprintf("<@%s> has given back the %v karma they took from <@%s>. ", callee, -ts[0].Delta, ts[0].To)
+		}
+		return fmt.Sprintf("<@%s> has taken back the %v karma they gave <@%s>. ", callee, ts[0].Delta, ts[0].To)
+	}
+
+	if ts[0].Delta < 0 {
+		return fmt.Sprintf("<@%s> has given back the karma they took from %s. ", callee, msgUserList(targets))
+	}
+	return fmt.Sprintf("<@%s> has taken back the karma they gave %s. ", callee, msgUserList(targets))
+}
+
+// MsgNothingToUndo the callee hasn't given any karma within the undo window
+func MsgNothingToUndo(window int) string {
+	return fmt.Sprintf("There's nothing to undo. Only karma given in the last %v minutes can be taken back.", window)
+}
+
+// MsgGiveGroup announces who gave karma to a group, and why. Split, delta is shared between them, otherwise each gets it
+func MsgGiveGroup(callee, label string, people, delta int, policy, reason string) string {
+	if policy == PolicySplit {
+		return fmt.Sprintf("<@%s> is sharing %v karma between %s%s. ", callee, delta, msgPeople(label, people), msgFor(reason))
+	}
+	return fmt.Sprintf("<@%s> is giving %v karma each to %s%s. ", callee, delta, msgPeople(label, people), msgFor(reason))
+}
+
+// MsgTakeGroup announces who took karma from a group, and why. Split, delta is shared between them, otherwise each loses it
+func MsgTakeGroup(callee, label string, people, delta int, policy, reason string) string {
+	if policy == PolicySplit {
+		return fmt.Sprintf("<@%s> is taking away %v karma, shared between %s%s. ", callee, delta, msgPeople(label, people), msgFor(reason))
+	}
+	return fmt.Sprintf("<@%s> is taking away %v karma each from %s%s. ", callee, delta, msgPeople(label, people), msgFor(reason))
+}
+
+// MsgGroupTooThin there is less karma to split than people in the group
+func MsgGroupTooThin(label string, people, amount int) string {
+	return fmt.Sprintf("There are %v people in %v and only %v karma to share. Give at least 1 each.", people, label, amount)
+}
+
+// MsgGroupTooBig the group has more members than karma is handed out to
+func MsgGroupTooBig(label string, most int) string {
+	return fmt.Sprintf("%v is too big. I only give karma to groups of up to %v people.", label, most)
+}
+
+// MsgHereTooBig the channel has too many people to look up who is online for @here
+func MsgHereTooBig(most int) string {
+	return fmt.Sprintf("This channel is too big for @here. I only look up who is online in channels of up to %v people, try a user group instead.", most)
+}
+
+// MsgEmptyGroup nobody in the group can get karma, once the giver is left out
+func MsgEmptyGroup(label string) string {
+	return fmt.Sprintf("There's nobody in %v to give karma to, besides you.", label)
+}
+
+// msgPeople the 3 people in @devs, without notifying them all
+func msgPeople(label string, people int) string {
+	if people == 1 {
+		return fmt.Sprintf("the 1 person in %v", label)
+	}
+	return fmt.Sprintf("the %v people in %v", people, label)
+}
+
+// msgUserList <@a>, <@b> and <@c>
+func msgUserList(users []string) string {
+	mentions := make([]string, 0, len(users))
+	for _, u := range users {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", u))
+	}
+	return msgList(mentions)
+}
+
+// msgList a, b and c
+func msgList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// MsgGiveThing announces who gave how much karma to a thing, and why
+func MsgGiveThing(callee, thing string, delta int, reason string) string {
+	return fmt.Sprintf("<@%s> is giving %v karma to %s%s. ", callee, delta, msgThing(thing), msgFor(reason))
+}
+
+// MsgTakeThing announces who took how much karma from a thing, and why
+func MsgTakeThing(callee, thing string, delta int, reason string) string {
+	return fmt.Sprintf("<@%s> is taking away %v karma from %s%s. ", callee, delta, msgThing(thing), msgFor(reason))
+}
+
+// MsgThingStatus a thing's karma
+func MsgThingStatus(thing string, k int) string {
+	return fmt.Sprintf("%s has %v karma.", msgThing(thing), k)
+}
+
+// MsgThingStatusTarget lets all users know who requested a thing's karma
+func MsgThingStatusTarget(callee, thing string) string {
+	return fmt.Sprintf("<@%s> has requested karma total for %s. ", callee, msgThing(thing))
+}
+
+// MsgTopThings table for viewing the top things by karma
+func MsgTopThings(things []ThingKarma) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("The top %v things by karma:\n", len(things)))
+	sb.WriteString("Rank\tName\tKarma\n")
+	for i, t := range things {
+		sb.WriteString(fmt.Sprintf("%v\t%v\t%v\n", i+1, msgThing(t.Thing), t.Karma))
+	}
+	return sb.String()
+}
+
+// msgThing a thing's name stands out in bold, so that phrases read as one
+func msgThing(thing string) string {
+	return "*" + thing + "*"
+}
+
+func msgFor(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return " for " + reason
+}
+
+// MsgReasons lists the recent reasons a user was given (or had taken) karma
+func MsgReasons(target string, reasons []Transaction) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("The recent reasons for <@%v>'s karma:\n", target))
+	for _, t := range reasons {
+		sb.WriteString(fmt.Sprintf("%+d from <@%v> for %v (%v)\n", t.Delta, t.From, t.Message, IsoDate(t.CreatedAt)))
+	}
+	return sb.String()
 }
 
-// MsgTakeKarma announces who took how much karma from whom
-func MsgTakeKarma(callee, target string, delta int) string {
-	return fmt.Sprintf("<@%s> is taking away %v karma from <@%s>. ", callee, delta, target)
+// MsgNoReasons nobody has said why
+func MsgNoReasons(target string) string {
+	return fmt.Sprintf("Nobody has given a reason for <@%v>'s karma. Yet.", target)
 }
 
 // MsgTopKarma table for viewing top users by karma
 func MsgTopKarma(topUsers []UserKarma) string {
+	return msgLeaderboard("top", topUsers)
+}
+
+// MsgBottomKarma table for viewing bottom users by karma
+func MsgBottomKarma(bottomUsers []UserKarma) string {
+	return msgLeaderboard("bottom", bottomUsers)
+}
+
+// MsgTopSeason table for viewing the top users of a season
+func MsgTopSeason(name string, topUsers []UserKarma) string {
+	return msgLeaderboardTitled(fmt.Sprintf("The top %v users by karma in season *%v*:\n", len(topUsers), name), topUsers)
+}
+
+// MsgSeasonStarted announces a new season
+func MsgSeasonStarted(callee, name string) string {
+	return fmt.Sprintf("<@%s> has started season *%v*. Everyone's season karma starts at 0, all time karma is kept.", callee, name)
+}
+
+// MsgSeasonEnded announces the end of a season, ahead of its final standings
+func MsgSeasonEnded(callee, name string) string {
+	return fmt.Sprintf("<@%s> has ended season *%v*.\n", callee, name)
+}
+
+// MsgSeasonRunning the running season, and when it started
+func MsgSeasonRunning(name string, started time.Time) string {
+	return fmt.Sprintf("Season *%v* has been running since %v.", name, IsoDate(started))
+}
+
+// MsgSeasonEnds when the running season rolls over to the next
+func MsgSeasonEnds(end, now time.Time) string {
+	return fmt.Sprintf(" It ends %v.", msgWhen(end, now))
+}
+
+// MsgPastSeasons the names of the seasons that have ended, newest first
+func MsgPastSeasons(names []string) string {
+	return fmt.Sprintf("Past seasons: %v", strings.Join(names, ", "))
+}
+
+// MsgSeasonExists the team already has a season by that name
+func MsgSeasonExists(name string) string {
+	return fmt.Sprintf("There has already been a season *%v*. Pick another name.", name)
+}
+
+// MsgUnknownSeason the team has no season by that name
+func MsgUnknownSeason(name string) string {
+	return fmt.Sprintf("There's no season *%v*. `/karma season` lists them.", name)
+}
+
+func msgLeaderboard(end string, users []UserKarma) string {
+	return msgLeaderboardTitled(fmt.Sprintf("The %v %v users by karma:\n", end, len(users)), users)
+}
+
+func msgLeaderboardTitled(title string, users []UserKarma) string {
 	sb := strings.Builder{}
-	sb.WriteString(fmt.Sprintf("The top %v users by karma:\n", len(topUsers)))
+	sb.WriteString(title)
 	sb.WriteString("Rank\tName\tKarma\n")
-	for i, user := range topUsers {
+	for i, user := range users {
 		sb.WriteString(fmt.Sprintf("%v\t<@%v>\t%v\n", i+1, user.User, user.Karma))
 	}
 	return sb.String()
 }
 
+// MsgReportTitle the heading of a karma report
+func MsgReportTitle(period Period) string {
+	if period.Name == "" {
+		return fmt.Sprintf("Karma report for %v", period.Dates())
+	}
+	return fmt.Sprintf("Karma report for %v (%v)", period.Name, period.Dates())
+}
+
+// MsgReportEmpty nothing happened during the period
+func MsgReportEmpty(period Period) string {
+	return fmt.Sprintf("No karma was given or taken during %v. Get out there and appreciate someone!", period)
+}
+
+// MsgReportMoved the net and total karma moved during a report period
+func MsgReportMoved(r Report) string {
+	return fmt.Sprintf("%+d net, %v moved in %v transactions", r.Net, r.Moved, r.Transactions)
+}
+
+// MsgConfig lists a team's settings, and whether they are the team's own or the default
+func MsgConfig(config ProcConfig, overrides map[string]string) string {
+	sb := strings.Builder{}
+	sb.WriteString("This team's karma settings:\n")
+	for _, setting := range Settings {
+		value, _ := config.Setting(setting)
+		source := "default"
+		if _, ok := overrides[setting]; ok {
+			source = "team"
+		}
+		sb.WriteString(fmt.Sprintf("`%v` %v (%v)\n", setting, value, source))
+	}
+	return sb.String()
+}
+
+// MsgConfigSet a team's setting was changed
+func MsgConfigSet(setting, value string) string {
+	return fmt.Sprintf("`%v` is now %v for this team.", setting, value)
+}
+
+// MsgConfigReset a team's setting is back to the default
+func MsgConfigReset(setting, value string) string {
+	return fmt.Sprintf("`%v` is back to the default of %v for this team.", setting, value)
+}
+
+// MsgConfigInvalid the change was not made, and why
+func MsgConfigInvalid(err error) string {
+	return fmt.Sprintf("I can't change that, %v", err)
+}
+
+// MsgAudit the recent changes made by admins
+func MsgAudit(entries []AuditEntry) string {
+	if len(entries) == 0 {
+		return msgNoAudit
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("The recent changes to this team:\n")
+	for _, e := range entries {
+		sb.WriteString(fmt.Sprintf("%v %v `%v` %v (%v)\n", MsgActor(e.Actor), e.Action, e.Target, e.Detail, IsoDate(e.CreatedAt)))
+	}
+	return sb.String()
+}
+
+// MsgActor who made a change: a mention of the user, or the api token it was made with
+func MsgActor(actor string) string {
+	if strings.HasPrefix(actor, tokenActor) {
+		return fmt.Sprintf("`%v`", actor)
+	}
+	return fmt.Sprintf("<@%v>", actor)
+}
+
+// MsgAdminSetKarma announces that an admin has set a user's karma
+func MsgAdminSetKarma(admin, target string, k int) string {
+	return fmt.Sprintf("<@%s> has set <@%s>'s karma to %v.", admin, target, k)
+}
+
+// MsgBannedTarget the user can't be given karma, or have it taken away
+func MsgBannedTarget(target string) string {
+	return fmt.Sprintf("<@%s> has been banned from karma. Leave them be.", target)
+}
+
+// MsgBanned the user is now banned from karma
+func MsgBanned(target string) string {
+	return fmt.Sprintf("<@%s> is banned from giving, taking and receiving karma.", target)
+}
+
+// MsgUnbanned the user is no longer banned from karma
+func MsgUnbanned(target string) string {
+	return fmt.Sprintf("<@%s> is no longer banned from karma.", target)
+}
+
+// MsgAdminAdded the user is now an admin of this team
+func MsgAdminAdded(target string) string {
+	return fmt.Sprintf("<@%s> is now an admin of this team.", target)
+}
+
+// MsgAdminRemoved the user is no longer an admin of this team
+func MsgAdminRemoved(target string) string {
+	return fmt.Sprintf("<@%s> is no longer an admin of this team.", target)
+}
+
+// MsgAdmins lists the global admins, the team's admins, and the banned users
+func MsgAdmins(global, team, banned []string) string {
+	return fmt.Sprintf("Global admins: %v\nTeam admins: %v\nBanned: %v", msgUsers(global), msgUsers(team), msgUsers(banned))
+}
+
+func msgUsers(users []string) string {
+	if len(users) == 0 {
+		return "nobody"
+	}
+
+	mentions := make([]string, 0, len(users))
+	for _, u := range users {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", u))
+	}
+	return strings.Join(mentions, ", ")
+}
+
 // Salutation appends a Salutation (insult or compliment)
 func (p SlackProcessor) Salutation(k int) string {
 	if k > 0 {
@@ -0,0 +1,281 @@
+package karma
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationFS holds the schema migrations of every driver, migrations/<driver>/NNNN_name.(up|down).sql
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migrationLock the postgres advisory lock that is held while migrating, so replicas that start together take turns
+const migrationLock = 0x6b6e617665
+
+// Migration a numbered change to the schema and the sql to undo it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus a migration and when it was applied. AppliedAt is nil while it is pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back the schema migrations of a database
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	bind       func(string) string
+	migrations []Migration
+}
+
+// querier a database, or a transaction in one
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// NewMigrator factory method, for the embedded migrations of the driver
+func NewMigrator(db *sql.DB, driver string) (Migrator, error) {
+	bind := bindQuestion
+	if driver == DriverPostgres {
+		bind = bindDollar
+	}
+
+	migrations, err := loadMigrations(migrationFS, path.Join("migrations", driver))
+	if err != nil {
+		return Migrator{}, err
+	}
+
+	return Migrator{db, driver, bind, migrations}, nil
+}
+
+// loadMigrations reads the migrations in dir, ordered by version. Every version needs an up and a down
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %v", e.Name())
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %v is named both %v and %v", version, migration.Name, m[2])
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if m[3] == "up" {
+			migration.Up = string(b)
+		} else {
+			migration.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %v_%v needs both an up and a down", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func (m Migrator) schemaMigrations(q querier) error {
+	_, err := q.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version		INTEGER PRIMARY KEY,
+		name		TEXT,
+		applied_at	TIMESTAMP
+	);
+	`)
+
+	return err
+}
+
+func (m Migrator) applied(q querier) (map[int]time.Time, error) {
+	if err := m.schemaMigrations(q); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+// begin starts the transaction that migrations are applied (or rolled back) in, with the migrations applied so far.
+// On postgres it first takes the migration lock, held until the transaction ends, so only one replica migrates at a time
+// and the next one sees what it did
+func (m Migrator) begin() (*sql.Tx, map[int]time.Time, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if m.driver == DriverPostgres {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+	}
+
+	applied, err := m.applied(tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	return tx, applied, nil
+}
+
+// Status every known migration, and when it was applied
+func (m Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		s := MigrationStatus{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+
+	return status, nil
+}
+
+// Version the latest migration that was applied, 0 for an empty database
+func (m Migrator) Version() (int, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+
+	return version, nil
+}
+
+// Up applies the pending migrations in a single transaction, returning how many there were
+func (m Migrator) Up() (int, error) {
+	tx, applied, err := m.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if _, err := tx.Exec(migration.Up); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("migration %v_%v: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.Exec(m.bind(`
+		INSERT INTO schema_migrations (version, name, applied_at)
+		VALUES (?, ?, ?)
+		`), migration.Version, migration.Name, time.Now().UTC())
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		n++
+	}
+
+	return n, tx.Commit()
+}
+
+// Down rolls back the latest steps migrations in a single transaction, returning how many were rolled back
+func (m Migrator) Down(steps int) (int, error) {
+	tx, applied, err := m.begin()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for i := len(m.migrations) - 1; i >= 0 && n < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if _, err := tx.Exec(migration.Down); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("migration %v_%v: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.Exec(m.bind(`DELETE FROM schema_migrations WHERE version = ?`), migration.Version)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		n++
+	}
+
+	return n, tx.Commit()
+}
+
+// Migrate brings the database up to the latest schema for the driver
+func Migrate(db *sql.DB, driver string) error {
+	m, err := NewMigrator(db, driver)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Up()
+	return err
+}
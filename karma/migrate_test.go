@@ -0,0 +1,63 @@
+package karma
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_ledger.up.sql":   {Data: []byte("CREATE TABLE ledger;")},
+		"m/0002_ledger.down.sql": {Data: []byte("DROP TABLE ledger;")},
+		"m/0001_karma.up.sql":    {Data: []byte("CREATE TABLE karma;")},
+		"m/0001_karma.down.sql":  {Data: []byte("DROP TABLE karma;")},
+	}
+
+	migrations, err := loadMigrations(fsys, "m")
+	assert.Nil(t, err)
+	assert.Equal(t, []Migration{
+		{1, "karma", "CREATE TABLE karma;", "DROP TABLE karma;"},
+		{2, "ledger", "CREATE TABLE ledger;", "DROP TABLE ledger;"},
+	}, migrations)
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	testcases := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{
+			"m/0001_karma.up.sql": {Data: []byte("CREATE TABLE karma;")},
+		}},
+		{"unexpected file", fstest.MapFS{
+			"m/karma.sql": {Data: []byte("CREATE TABLE karma;")},
+		}},
+		{"names differ", fstest.MapFS{
+			"m/0001_karma.up.sql":    {Data: []byte("CREATE TABLE karma;")},
+			"m/0001_ledger.down.sql": {Data: []byte("DROP TABLE karma;")},
+		}},
+		{"missing directory", fstest.MapFS{}},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadMigrations(test.fsys, "m")
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, driver := range []string{DriverSQLite, DriverPostgres} {
+		t.Run(driver, func(t *testing.T) {
+			migrations, err := loadMigrations(migrationFS, "migrations/"+driver)
+			assert.Nil(t, err)
+			assert.NotEmpty(t, migrations)
+			for i, m := range migrations {
+				assert.Equal(t, i+1, m.Version)
+			}
+		})
+	}
+}
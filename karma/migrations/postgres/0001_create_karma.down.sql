@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS daily_usage;
+DROP TABLE IF EXISTS usage;
+DROP TABLE IF EXISTS karma;
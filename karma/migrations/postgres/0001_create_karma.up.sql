@@ -0,0 +1,33 @@
+-- IF NOT EXISTS adopts databases that were created before schema_migrations
+CREATE TABLE IF NOT EXISTS karma (
+	id			BIGSERIAL PRIMARY KEY,
+	team		TEXT,
+	"user"		TEXT,
+	karma		INTEGER,
+	created_at	TIMESTAMPTZ,
+	updated_at	TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_karma_team_user ON karma (team, "user");
+
+CREATE TABLE IF NOT EXISTS usage (
+	command			TEXT,
+	text			TEXT,
+	enterprise		TEXT,
+	team			TEXT,
+	channel			TEXT,
+	"user"			TEXT,
+	created_at		TIMESTAMPTZ,
+	response		TEXT,
+	response_type	TEXT,
+	attachments		TEXT
+);
+
+CREATE TABLE IF NOT EXISTS daily_usage (
+	team		TEXT,
+	"user"		TEXT,
+	daily		TEXT,
+	usage		INTEGER,
+	created_at	TIMESTAMPTZ,
+	updated_at	TIMESTAMPTZ,
+	PRIMARY KEY (team, "user", daily)
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS karma_ledger (
+	id			BIGSERIAL PRIMARY KEY,
+	team		TEXT,
+	from_user	TEXT,
+	to_user		TEXT,
+	channel		TEXT,
+	delta		INTEGER,
+	message		TEXT,
+	created_at	TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_karma_ledger_team_to ON karma_ledger (team, to_user, created_at);
+CREATE INDEX IF NOT EXISTS idx_karma_ledger_team_from ON karma_ledger (team, from_user, created_at);
+
+-- karma given before the ledger existed becomes an opening balance, so the totals can be rebuilt
+INSERT INTO karma_ledger
+(team, from_user, to_user, channel, delta, message, created_at)
+SELECT	k.team, '', k."user", '', k.karma, 'opening balance', NOW()
+FROM	karma k
+WHERE	k.karma != 0
+AND		NOT EXISTS (SELECT 1 FROM karma_ledger);
@@ -0,0 +1,21 @@
+-- per team overrides of the global karma rules, one row per setting
+CREATE TABLE team_config (
+	team		TEXT,
+	setting		TEXT,
+	value		TEXT,
+	updated_by	TEXT,
+	updated_at	TIMESTAMPTZ,
+	PRIMARY KEY (team, setting)
+);
+
+-- who changed what, and when
+CREATE TABLE audit (
+	id			BIGSERIAL PRIMARY KEY,
+	team		TEXT,
+	actor		TEXT,
+	action		TEXT,
+	target		TEXT,
+	detail		TEXT,
+	created_at	TIMESTAMPTZ
+);
+CREATE INDEX idx_audit_team ON audit (team, created_at);
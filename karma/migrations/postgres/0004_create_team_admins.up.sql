@@ -0,0 +1,17 @@
+-- team admins, on top of the global admins in the config
+CREATE TABLE team_admins (
+	team		TEXT,
+	"user"		TEXT,
+	added_by	TEXT,
+	created_at	TIMESTAMPTZ,
+	PRIMARY KEY (team, "user")
+);
+
+-- users who may neither give nor receive karma
+CREATE TABLE team_bans (
+	team		TEXT,
+	"user"		TEXT,
+	added_by	TEXT,
+	created_at	TIMESTAMPTZ,
+	PRIMARY KEY (team, "user")
+);
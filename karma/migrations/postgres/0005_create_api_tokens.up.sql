@@ -0,0 +1,10 @@
+-- bearer tokens for the REST api. only the sha256 of a token is kept
+CREATE TABLE api_tokens (
+	id			BIGSERIAL PRIMARY KEY,
+	name		TEXT,
+	team		TEXT,
+	scopes		TEXT,
+	token_hash	TEXT UNIQUE,
+	created_at	TIMESTAMPTZ,
+	revoked_at	TIMESTAMPTZ
+);
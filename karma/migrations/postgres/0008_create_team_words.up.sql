@@ -0,0 +1,13 @@
+-- the words a team adds to the insult and compliment kits. They are pending until an admin approves them
+CREATE TABLE team_words (
+	id				BIGSERIAL PRIMARY KEY,
+	team			TEXT,
+	kit				TEXT,
+	col				TEXT,
+	word			TEXT,
+	status			TEXT,
+	submitted_by	TEXT,
+	approved_by		TEXT,
+	created_at		TIMESTAMPTZ,
+	UNIQUE (team, kit, col, word)
+);
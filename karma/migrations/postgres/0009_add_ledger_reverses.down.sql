@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_karma_ledger_reverses;
+ALTER TABLE karma_ledger DROP COLUMN IF EXISTS reverses;
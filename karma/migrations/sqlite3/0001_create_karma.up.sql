@@ -0,0 +1,34 @@
+-- IF NOT EXISTS adopts databases that were created before schema_migrations
+CREATE TABLE IF NOT EXISTS karma (
+	id			INTEGER PRIMARY KEY,
+	team		TEXT,
+	user		TEXT,
+	karma		INTEGER,
+	created_at	TEXT,
+	updated_at	TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_karma_team_user ON karma (team, user);
+
+CREATE TABLE IF NOT EXISTS usage (
+	command			TEXT,
+	text			TEXT,
+	enterprise		TEXT,
+	team			TEXT,
+	channel			TEXT,
+	user			TEXT,
+	created_at		TEXT,
+	response		TEXT,
+	response_type	TEXT,
+	attachments		TEXT
+);
+
+CREATE TABLE IF NOT EXISTS daily_usage (
+	team		TEXT,
+	user		TEXT,
+	daily		TEXT,
+	usage		INTEGER,
+	created_at	TEXT,
+	updated_at	TEXT,
+	PRIMARY KEY (team, user, daily)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_daily_usage_team_user_daily ON daily_usage (team, user, daily);
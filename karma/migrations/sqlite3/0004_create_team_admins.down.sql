@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS team_bans;
+DROP TABLE IF EXISTS team_admins;
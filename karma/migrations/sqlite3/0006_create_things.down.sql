@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS thing_ledger;
+DROP TABLE IF EXISTS things;
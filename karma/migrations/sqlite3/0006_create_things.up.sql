@@ -0,0 +1,22 @@
+-- karma for things that aren't users: words, phrases and channels, by their normalized name
+CREATE TABLE things (
+	team		TEXT,
+	thing		TEXT,
+	karma		INTEGER,
+	created_at	TIMESTAMP,
+	updated_at	TIMESTAMP,
+	PRIMARY KEY (team, thing)
+);
+
+-- the ledger of things, kept apart so things never show up as users
+CREATE TABLE thing_ledger (
+	id			INTEGER PRIMARY KEY,
+	team		TEXT,
+	from_user	TEXT,
+	thing		TEXT,
+	channel		TEXT,
+	delta		INTEGER,
+	message		TEXT,
+	created_at	TIMESTAMP
+);
+CREATE INDEX idx_thing_ledger_team_from ON thing_ledger (team, from_user, created_at);
@@ -0,0 +1,18 @@
+-- named seasons, like 2026Q2. A season's leaderboard is the karma given in it, worked out from the ledger
+CREATE TABLE seasons (
+	id			INTEGER PRIMARY KEY,
+	team		TEXT,
+	name		TEXT,
+	started_at	TIMESTAMP,
+	ended_at	TIMESTAMP,
+	UNIQUE (team, name)
+);
+
+-- the final standings of the seasons that have ended
+CREATE TABLE season_standings (
+	team		TEXT,
+	season		TEXT,
+	"user"		TEXT,
+	karma		INTEGER,
+	PRIMARY KEY (team, season, "user")
+);
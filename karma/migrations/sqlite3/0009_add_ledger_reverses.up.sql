@@ -0,0 +1,3 @@
+-- an undo leaves the karma it takes back in the ledger, and adds the opposite karma that reverses it
+ALTER TABLE karma_ledger ADD COLUMN reverses INTEGER REFERENCES karma_ledger (id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_karma_ledger_reverses ON karma_ledger (reverses);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_team_words_word;
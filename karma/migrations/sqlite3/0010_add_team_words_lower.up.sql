@@ -0,0 +1,15 @@
+-- a word is the same word in any case. Words added before that are kept once, the approved one or else the first
+DELETE FROM team_words
+WHERE EXISTS (
+	SELECT	1
+	FROM	team_words k
+	WHERE	k.team = team_words.team
+	AND		k.kit = team_words.kit
+	AND		k.col = team_words.col
+	AND		LOWER(k.word) = LOWER(team_words.word)
+	AND		k.id != team_words.id
+	AND		(CASE WHEN k.status = 'approved' THEN 0 ELSE 1 END < CASE WHEN team_words.status = 'approved' THEN 0 ELSE 1 END
+			OR (CASE WHEN k.status = 'approved' THEN 0 ELSE 1 END = CASE WHEN team_words.status = 'approved' THEN 0 ELSE 1 END
+				AND k.id < team_words.id))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_words_word ON team_words (team, kit, col, LOWER(word));
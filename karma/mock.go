@@ -4,19 +4,65 @@ import (
 	"errors"
 	"fmt"
 	"github.com/icemanblues/knave-bot/slack"
+	"strings"
 	"time"
 )
 
 // MockDAO a mock dao for karma whose mock functions can be monkeypatched
 type MockDAO struct {
-	GetKarmaMock         func(team, user string) (int, error)
-	UpdateKarmaMock      func(team, user string, delta int) (int, error)
-	DeleteKarmaMock      func(team, user string) (int, error)
-	UsageMock            func(slack.CommandData, slack.Response) error
-	TopMock              func(team string, n int) ([]UserKarma, error)
-	GetDailyMock         func(team, user string, date time.Time) (int, error)
-	UpdateDailyMock      func(team, user string, date time.Time, karma int) (int, error)
-	UpdateKarmaDailyMock func(team, callee, target string, delta int, date time.Time) (int, error)
+	GetKarmaMock             func(team, user string) (int, error)
+	UpdateKarmaMock          func(team, user string, delta int) (int, error)
+	DeleteKarmaMock          func(team, user string) (int, error)
+	UsageMock                func(slack.CommandData, slack.Response) error
+	TopMock                  func(team string, n int) ([]UserKarma, error)
+	BottomMock               func(team string, n int) ([]UserKarma, error)
+	GetDailyMock             func(team, user string, date time.Time) (int, error)
+	UpdateDailyMock          func(team, user string, date time.Time, karma int) (int, error)
+	UpdateKarmaDailyMock     func(t Transaction, date time.Time) (int, error)
+	UpdateKarmaDailyManyMock func(ts []Transaction, date time.Time) ([]int, error)
+	ReceivedMock             func(team, user string, limit, offset int) ([]Transaction, error)
+	GivenMock                func(team, user string, limit, offset int) ([]Transaction, error)
+	ReasonsMock              func(team, user string, n int) ([]Transaction, error)
+	PairHistoryMock          func(team, from, to string, since time.Time) ([]Transaction, error)
+	ReceivedSinceMock        func(team, user string, since time.Time) (int, error)
+	GivenSinceMock           func(team, user string, since time.Time) ([]Transaction, error)
+	LastGivenMock            func(team, user string, since time.Time) ([]Transaction, error)
+	UndoMock                 func(ts []Transaction, date time.Time) ([]int, error)
+	GetThingMock             func(team, thing string) (int, error)
+	UpdateThingDailyMock     func(t Transaction, date time.Time) (int, error)
+	TopThingsMock            func(team string, n int) ([]ThingKarma, error)
+	CurrentSeasonMock        func(team string) (Season, error)
+	GetSeasonMock            func(team, name string) (Season, error)
+	SeasonsMock              func(team string) ([]Season, error)
+	RunningSeasonsMock       func() ([]Season, error)
+	StartSeasonMock          func(team, name string, at time.Time) (Season, error)
+	EndSeasonMock            func(team string, at time.Time) (Season, error)
+	RolloverSeasonMock       func(team, next string, at time.Time) (Season, error)
+	SeasonTopMock            func(team string, since time.Time, n int) ([]UserKarma, error)
+	ArchivedTopMock          func(team, season string, n int) ([]UserKarma, error)
+	TeamWordsMock            func(team string) ([]TeamWord, error)
+	AddTeamWordMock          func(w TeamWord) (TeamWord, error)
+	ApproveTeamWordMock      func(team string, id int64, actor string) (TeamWord, error)
+	RemoveTeamWordMock       func(team string, id int64, actor string) (TeamWord, error)
+	RebuildKarmaMock         func(team string) error
+	ReportMock               func(team string, from, to time.Time, n int) (Report, error)
+	TeamConfigMock           func(team string) (map[string]string, error)
+	SetTeamConfigMock        func(team, setting, value, actor string) error
+	ResetTeamConfigMock      func(team, setting, actor string) error
+	AuditMock                func(team string, n int) ([]AuditEntry, error)
+	SetKarmaMock             func(team, user string, karma int, actor string) error
+	IsAdminMock              func(team, user string) (bool, error)
+	AdminsMock               func(team string) ([]string, error)
+	AddAdminMock             func(team, user, actor string) error
+	RemoveAdminMock          func(team, user, actor string) error
+	IsBannedMock             func(team, user string) (bool, error)
+	BannedMock               func(team string) ([]string, error)
+	BanMock                  func(team, user, actor string) error
+	UnbanMock                func(team, user, actor string) error
+	TokenByHashMock          func(hash string) (APIToken, error)
+	CreateTokenMock          func(t APIToken, hash string) (APIToken, error)
+	TokensMock               func(team string) ([]APIToken, error)
+	RevokeTokenMock          func(id int64) error
 }
 
 // GetKarma .
@@ -44,6 +90,11 @@ func (m MockDAO) Top(team string, n int) ([]UserKarma, error) {
 	return m.TopMock(team, n)
 }
 
+// Bottom .
+func (m MockDAO) Bottom(team string, n int) ([]UserKarma, error) {
+	return m.BottomMock(team, n)
+}
+
 // GetDaily .
 func (m MockDAO) GetDaily(team, user string, date time.Time) (int, error) {
 	return m.GetDailyMock(team, user, date)
@@ -55,8 +106,241 @@ func (m MockDAO) UpdateDaily(team, user string, date time.Time, karma int) (int,
 }
 
 // UpdateKarmaDaily .
-func (m MockDAO) UpdateKarmaDaily(team, callee, target string, delta int, date time.Time) (int, error) {
-	return m.UpdateKarmaDailyMock(team, callee, target, delta, date)
+func (m MockDAO) UpdateKarmaDaily(t Transaction, date time.Time) (int, error) {
+	return m.UpdateKarmaDailyMock(t, date)
+}
+
+// UpdateKarmaDailyMany .
+func (m MockDAO) UpdateKarmaDailyMany(ts []Transaction, date time.Time) ([]int, error) {
+	return m.UpdateKarmaDailyManyMock(ts, date)
+}
+
+// Received .
+func (m MockDAO) Received(team, user string, limit, offset int) ([]Transaction, error) {
+	return m.ReceivedMock(team, user, limit, offset)
+}
+
+// Given .
+func (m MockDAO) Given(team, user string, limit, offset int) ([]Transaction, error) {
+	return m.GivenMock(team, user, limit, offset)
+}
+
+// Reasons .
+func (m MockDAO) Reasons(team, user string, n int) ([]Transaction, error) {
+	return m.ReasonsMock(team, user, n)
+}
+
+// PairHistory .
+func (m MockDAO) PairHistory(team, from, to string, since time.Time) ([]Transaction, error) {
+	return m.PairHistoryMock(team, from, to, since)
+}
+
+// ReceivedSince .
+func (m MockDAO) ReceivedSince(team, user string, since time.Time) (int, error) {
+	return m.ReceivedSinceMock(team, user, since)
+}
+
+// GivenSince .
+func (m MockDAO) GivenSince(team, user string, since time.Time) ([]Transaction, error) {
+	return m.GivenSinceMock(team, user, since)
+}
+
+// LastGiven .
+func (m MockDAO) LastGiven(team, user string, since time.Time) ([]Transaction, error) {
+	return m.LastGivenMock(team, user, since)
+}
+
+// Undo .
+func (m MockDAO) Undo(ts []Transaction, date time.Time) ([]int, error) {
+	return m.UndoMock(ts, date)
+}
+
+// GetThing .
+func (m MockDAO) GetThing(team, thing string) (int, error) {
+	return m.GetThingMock(team, thing)
+}
+
+// UpdateThingDaily .
+func (m MockDAO) UpdateThingDaily(t Transaction, date time.Time) (int, error) {
+	return m.UpdateThingDailyMock(t, date)
+}
+
+// TopThings .
+func (m MockDAO) TopThings(team string, n int) ([]ThingKarma, error) {
+	return m.TopThingsMock(team, n)
+}
+
+// CurrentSeason .
+func (m MockDAO) CurrentSeason(team string) (Season, error) {
+	return m.CurrentSeasonMock(team)
+}
+
+// GetSeason .
+func (m MockDAO) GetSeason(team, name string) (Season, error) {
+	return m.GetSeasonMock(team, name)
+}
+
+// Seasons .
+func (m MockDAO) Seasons(team string) ([]Season, error) {
+	return m.SeasonsMock(team)
+}
+
+// RunningSeasons .
+func (m MockDAO) RunningSeasons() ([]Season, error) {
+	return m.RunningSeasonsMock()
+}
+
+// StartSeason .
+func (m MockDAO) StartSeason(team, name string, at time.Time) (Season, error) {
+	return m.StartSeasonMock(team, name, at)
+}
+
+// EndSeason .
+func (m MockDAO) EndSeason(team string, at time.Time) (Season, error) {
+	return m.EndSeasonMock(team, at)
+}
+
+// RolloverSeason .
+func (m MockDAO) RolloverSeason(team, next string, at time.Time) (Season, error) {
+	return m.RolloverSeasonMock(team, next, at)
+}
+
+// SeasonTop .
+func (m MockDAO) SeasonTop(team string, since time.Time, n int) ([]UserKarma, error) {
+	return m.SeasonTopMock(team, since, n)
+}
+
+// ArchivedTop .
+func (m MockDAO) ArchivedTop(team, season string, n int) ([]UserKarma, error) {
+	return m.ArchivedTopMock(team, season, n)
+}
+
+// TeamWords .
+func (m MockDAO) TeamWords(team string) ([]TeamWord, error) {
+	return m.TeamWordsMock(team)
+}
+
+// AddTeamWord .
+func (m MockDAO) AddTeamWord(w TeamWord) (TeamWord, error) {
+	return m.AddTeamWordMock(w)
+}
+
+// ApproveTeamWord .
+func (m MockDAO) ApproveTeamWord(team string, id int64, actor string) (TeamWord, error) {
+	return m.ApproveTeamWordMock(team, id, actor)
+}
+
+// RemoveTeamWord .
+func (m MockDAO) RemoveTeamWord(team string, id int64, actor string) (TeamWord, error) {
+	return m.RemoveTeamWordMock(team, id, actor)
+}
+
+// RebuildKarma .
+func (m MockDAO) RebuildKarma(team string) error {
+	return m.RebuildKarmaMock(team)
+}
+
+// Report .
+func (m MockDAO) Report(team string, from, to time.Time, n int) (Report, error) {
+	return m.ReportMock(team, from, to, n)
+}
+
+// TeamConfig .
+func (m MockDAO) TeamConfig(team string) (map[string]string, error) {
+	return m.TeamConfigMock(team)
+}
+
+// SetTeamConfig .
+func (m MockDAO) SetTeamConfig(team, setting, value, actor string) error {
+	return m.SetTeamConfigMock(team, setting, value, actor)
+}
+
+// ResetTeamConfig .
+func (m MockDAO) ResetTeamConfig(team, setting, actor string) error {
+	return m.ResetTeamConfigMock(team, setting, actor)
+}
+
+// Audit .
+func (m MockDAO) Audit(team string, n int) ([]AuditEntry, error) {
+	return m.AuditMock(team, n)
+}
+
+// SetKarma .
+func (m MockDAO) SetKarma(team, user string, karma int, actor string) error {
+	return m.SetKarmaMock(team, user, karma, actor)
+}
+
+// IsAdmin .
+func (m MockDAO) IsAdmin(team, user string) (bool, error) {
+	return m.IsAdminMock(team, user)
+}
+
+// Admins .
+func (m MockDAO) Admins(team string) ([]string, error) {
+	return m.AdminsMock(team)
+}
+
+// AddAdmin .
+func (m MockDAO) AddAdmin(team, user, actor string) error {
+	return m.AddAdminMock(team, user, actor)
+}
+
+// RemoveAdmin .
+func (m MockDAO) RemoveAdmin(team, user, actor string) error {
+	return m.RemoveAdminMock(team, user, actor)
+}
+
+// IsBanned .
+func (m MockDAO) IsBanned(team, user string) (bool, error) {
+	return m.IsBannedMock(team, user)
+}
+
+// Banned .
+func (m MockDAO) Banned(team string) ([]string, error) {
+	return m.BannedMock(team)
+}
+
+// Ban .
+func (m MockDAO) Ban(team, user, actor string) error {
+	return m.BanMock(team, user, actor)
+}
+
+// Unban .
+func (m MockDAO) Unban(team, user, actor string) error {
+	return m.UnbanMock(team, user, actor)
+}
+
+// TokenByHash .
+func (m MockDAO) TokenByHash(hash string) (APIToken, error) {
+	return m.TokenByHashMock(hash)
+}
+
+// CreateToken .
+func (m MockDAO) CreateToken(t APIToken, hash string) (APIToken, error) {
+	return m.CreateTokenMock(t, hash)
+}
+
+// Tokens .
+func (m MockDAO) Tokens(team string) ([]APIToken, error) {
+	return m.TokensMock(team)
+}
+
+// RevokeToken .
+func (m MockDAO) RevokeToken(id int64) error {
+	return m.RevokeTokenMock(id)
+}
+
+// mock bearer tokens for team nycfc, one per scope
+const (
+	mockReadToken  = "read-token"
+	mockWriteToken = "write-token"
+	mockAdminToken = "admin-token"
+)
+
+var mockTokens = map[string]APIToken{
+	HashToken(mockReadToken):  {ID: 1, Name: "reader", Team: "nycfc", Scopes: []Scope{ScopeRead}},
+	HashToken(mockWriteToken): {ID: 2, Name: "writer", Team: "nycfc", Scopes: []Scope{ScopeWrite}},
+	HashToken(mockAdminToken): {ID: 3, Name: "admin", Team: "nycfc", Scopes: []Scope{ScopeAdmin}},
 }
 
 // NewMockDao constructor func for making mock dao
@@ -83,18 +367,269 @@ func NewMockDao(usage int) MockDAO {
 			}
 			return r, nil
 		},
+		BottomMock: func(team string, n int) ([]UserKarma, error) {
+			r := make([]UserKarma, 0, n)
+			for i := 0; i < n; i++ {
+				name := fmt.Sprintf("USER%v", i)
+				karma := -100 + i
+				r = append(r, UserKarma{name, karma})
+			}
+			return r, nil
+		},
 		GetDailyMock: func(team, user string, date time.Time) (int, error) {
 			return usage, nil
 		},
 		UpdateDailyMock: func(team, user string, date time.Time, karma int) (int, error) {
 			return karma + usage, nil
 		},
-		UpdateKarmaDailyMock: func(team, callee, target string, delta int, date time.Time) (int, error) {
-			return delta + 1, nil
+		UpdateKarmaDailyMock: func(t Transaction, date time.Time) (int, error) {
+			return t.Delta + 1, nil
+		},
+		UpdateKarmaDailyManyMock: func(ts []Transaction, date time.Time) ([]int, error) {
+			ks := make([]int, 0, len(ts))
+			for _, t := range ts {
+				ks = append(ks, t.Delta+1)
+			}
+			return ks, nil
+		},
+		ReceivedMock: func(team, user string, limit, offset int) ([]Transaction, error) {
+			return mockTransactions(team, "USER", user, limit), nil
+		},
+		GivenMock: func(team, user string, limit, offset int) ([]Transaction, error) {
+			return mockTransactions(team, user, "USER", limit), nil
+		},
+		ReasonsMock: func(team, user string, n int) ([]Transaction, error) {
+			r := mockTransactions(team, "USER", user, n)
+			for i := range r {
+				r[i].Message = fmt.Sprintf("reason %v", i)
+				r[i].CreatedAt = time.Date(2019, time.November, 9, 0, 0, 0, 0, time.UTC)
+			}
+			return r, nil
+		},
+		PairHistoryMock: func(team, from, to string, since time.Time) ([]Transaction, error) {
+			return nil, nil
+		},
+		ReceivedSinceMock: func(team, user string, since time.Time) (int, error) {
+			return 0, nil
+		},
+		GivenSinceMock: func(team, user string, since time.Time) ([]Transaction, error) {
+			return nil, nil
+		},
+		LastGivenMock: func(team, user string, since time.Time) ([]Transaction, error) {
+			r := mockTransactions(team, user, "USER", 1)
+			r[0].CreatedAt = since.Add(time.Minute)
+			return r, nil
+		},
+		UndoMock: func(ts []Transaction, date time.Time) ([]int, error) {
+			ks := make([]int, 0, len(ts))
+			for _, t := range ts {
+				ks = append(ks, 1-t.Delta)
+			}
+			return ks, nil
+		},
+		GetThingMock: func(team, thing string) (int, error) {
+			return 3, nil
+		},
+		UpdateThingDailyMock: func(t Transaction, date time.Time) (int, error) {
+			return 3 + t.Delta, nil
+		},
+		TopThingsMock: func(team string, n int) ([]ThingKarma, error) {
+			things := make([]ThingKarma, 0, n)
+			for i := 0; i < n; i++ {
+				things = append(things, ThingKarma{fmt.Sprintf("thing%v", i), n - i})
+			}
+			return things, nil
+		},
+		CurrentSeasonMock: func(team string) (Season, error) {
+			return Season{}, ErrNoSeason
+		},
+		GetSeasonMock: func(team, name string) (Season, error) {
+			if name != mockSeason.Name {
+				return Season{}, ErrNoSeason
+			}
+			s := mockSeason
+			s.Team = team
+			return s, nil
+		},
+		SeasonsMock: func(team string) ([]Season, error) {
+			s := mockSeason
+			s.Team = team
+			return []Season{s}, nil
+		},
+		RunningSeasonsMock: func() ([]Season, error) {
+			return nil, nil
+		},
+		StartSeasonMock: func(team, name string, at time.Time) (Season, error) {
+			return Season{ID: 2, Team: team, Name: name, StartedAt: at}, nil
+		},
+		EndSeasonMock: func(team string, at time.Time) (Season, error) {
+			return Season{ID: 2, Team: team, Name: "2026Q2", StartedAt: mockSeasonEnd, EndedAt: &at}, nil
+		},
+		RolloverSeasonMock: func(team, next string, at time.Time) (Season, error) {
+			return Season{ID: 3, Team: team, Name: next, StartedAt: at}, nil
+		},
+		SeasonTopMock: func(team string, since time.Time, n int) ([]UserKarma, error) {
+			r := make([]UserKarma, 0, n)
+			for i := 0; i < n; i++ {
+				r = append(r, UserKarma{fmt.Sprintf("USER%v", i), n - i})
+			}
+			return r, nil
+		},
+		ArchivedTopMock: func(team, season string, n int) ([]UserKarma, error) {
+			r := make([]UserKarma, 0, n)
+			for i := 0; i < n; i++ {
+				r = append(r, UserKarma{fmt.Sprintf("USER%v", i), 10 * (n - i)})
+			}
+			return r, nil
+		},
+		TeamWordsMock: func(team string) ([]TeamWord, error) {
+			return mockTeamWords, nil
+		},
+		AddTeamWordMock: func(w TeamWord) (TeamWord, error) {
+			for _, m := range mockTeamWords {
+				if m.Kit == w.Kit && m.Column == w.Column && strings.EqualFold(m.Word, w.Word) {
+					return TeamWord{}, ErrWordExists
+				}
+			}
+			w.ID = 3
+			return w, nil
+		},
+		ApproveTeamWordMock: func(team string, id int64, actor string) (TeamWord, error) {
+			w, err := mockTeamWord(id)
+			w.Status, w.ApprovedBy = WordApproved, actor
+			return w, err
+		},
+		RemoveTeamWordMock: func(team string, id int64, actor string) (TeamWord, error) {
+			return mockTeamWord(id)
+		},
+		RebuildKarmaMock: func(team string) error {
+			return nil
+		},
+		ReportMock: func(team string, from, to time.Time, n int) (Report, error) {
+			return Report{
+				Team:         team,
+				From:         from,
+				To:           to,
+				Gainers:      []UserKarma{{"USER0", 10}, {"USER1", 4}},
+				Givers:       []UserKarma{{"USER2", 8}, {"USER0", 6}},
+				Channels:     []ChannelKarma{{"CGENERAL", 7, 12}},
+				Transactions: 9,
+				Net:          10,
+				Moved:        16,
+			}, nil
+		},
+		TeamConfigMock: func(team string) (map[string]string, error) {
+			return map[string]string{}, nil
+		},
+		SetTeamConfigMock: func(team, setting, value, actor string) error {
+			return nil
+		},
+		ResetTeamConfigMock: func(team, setting, actor string) error {
+			return nil
+		},
+		AuditMock: func(team string, n int) ([]AuditEntry, error) {
+			r := make([]AuditEntry, 0, n)
+			for i := 0; i < n; i++ {
+				r = append(r, AuditEntry{
+					ID:        int64(i + 1),
+					Team:      team,
+					Actor:     "UADMIN",
+					Action:    auditConfigSet,
+					Target:    settingDailyLimit,
+					Detail:    fmt.Sprintf("from default to %v", i),
+					CreatedAt: time.Date(2019, time.November, 9, 0, 0, 0, 0, time.UTC),
+				})
+			}
+			return r, nil
+		},
+		SetKarmaMock: func(team, user string, karma int, actor string) error {
+			return nil
+		},
+		IsAdminMock: func(team, user string) (bool, error) {
+			return user == "UADMIN", nil
+		},
+		AdminsMock: func(team string) ([]string, error) {
+			return []string{"UADMIN"}, nil
+		},
+		AddAdminMock: func(team, user, actor string) error {
+			return nil
+		},
+		RemoveAdminMock: func(team, user, actor string) error {
+			return nil
+		},
+		IsBannedMock: func(team, user string) (bool, error) {
+			return user == "UBANNED", nil
+		},
+		BannedMock: func(team string) ([]string, error) {
+			return []string{"UBANNED"}, nil
+		},
+		BanMock: func(team, user, actor string) error {
+			return nil
+		},
+		UnbanMock: func(team, user, actor string) error {
+			return nil
+		},
+		TokenByHashMock: func(hash string) (APIToken, error) {
+			t, ok := mockTokens[hash]
+			if !ok {
+				return APIToken{}, ErrUnknownToken
+			}
+			return t, nil
+		},
+		CreateTokenMock: func(t APIToken, hash string) (APIToken, error) {
+			t.ID = 4
+			return t, nil
+		},
+		TokensMock: func(team string) ([]APIToken, error) {
+			tokens := make([]APIToken, 0, len(mockTokens))
+			for _, t := range mockTokens {
+				tokens = append(tokens, t)
+			}
+			return tokens, nil
+		},
+		RevokeTokenMock: func(id int64) error {
+			return nil
 		},
 	}
 }
 
+// mockSeason the season that has ended in the happy mock dao, 2026Q1
+var (
+	mockSeasonStart = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mockSeasonEnd   = time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+	mockSeason      = Season{ID: 1, Name: "2026Q1", StartedAt: mockSeasonStart, EndedAt: &mockSeasonEnd}
+)
+
+// mockTeamWords the words team nycfc has added in the happy mock dao, one approved and one pending
+var mockTeamWords = []TeamWord{
+	{ID: 1, Team: "nycfc", Kit: "insult", Column: "B", Word: "beetle-headed", Status: WordApproved, SubmittedBy: "UCALLER", ApprovedBy: "UADMIN", CreatedAt: mockSeasonStart},
+	{ID: 2, Team: "nycfc", Kit: "insult", Column: "C", Word: "knave-nut", Status: WordPending, SubmittedBy: "UCALLER", CreatedAt: mockSeasonEnd},
+}
+
+func mockTeamWord(id int64) (TeamWord, error) {
+	for _, w := range mockTeamWords {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return TeamWord{}, ErrNoWord
+}
+
+// mockTransactions n transactions of 1 karma each, from one user to another
+func mockTransactions(team, from, to string, n int) []Transaction {
+	r := make([]Transaction, 0, n)
+	for i := 0; i < n; i++ {
+		r = append(r, Transaction{
+			ID:    int64(i + 1),
+			Team:  team,
+			From:  from,
+			To:    to,
+			Delta: 1,
+		})
+	}
+	return r
+}
+
 // HappyDao factory method for a mock dao that will always succeed
 func HappyDao() MockDAO {
 	return NewMockDao(0)
@@ -123,14 +658,149 @@ func SadDao() MockDAO {
 		TopMock: func(team string, n int) ([]UserKarma, error) {
 			return nil, errors.New("TopMock")
 		},
+		BottomMock: func(team string, n int) ([]UserKarma, error) {
+			return nil, errors.New("BottomMock")
+		},
 		GetDailyMock: func(team, user string, date time.Time) (int, error) {
 			return 0, errors.New("GetDailyMock")
 		},
 		UpdateDailyMock: func(team, user string, date time.Time, karma int) (int, error) {
 			return 0, errors.New("UpdateDailyMock")
 		},
-		UpdateKarmaDailyMock: func(team, callee, target string, delta int, date time.Time) (int, error) {
+		UpdateKarmaDailyMock: func(t Transaction, date time.Time) (int, error) {
 			return 0, errors.New("UpdateKarmaDailyMock")
 		},
+		UpdateKarmaDailyManyMock: func(ts []Transaction, date time.Time) ([]int, error) {
+			return nil, errors.New("UpdateKarmaDailyManyMock")
+		},
+		ReceivedMock: func(team, user string, limit, offset int) ([]Transaction, error) {
+			return nil, errors.New("ReceivedMock")
+		},
+		GivenMock: func(team, user string, limit, offset int) ([]Transaction, error) {
+			return nil, errors.New("GivenMock")
+		},
+		ReasonsMock: func(team, user string, n int) ([]Transaction, error) {
+			return nil, errors.New("ReasonsMock")
+		},
+		PairHistoryMock: func(team, from, to string, since time.Time) ([]Transaction, error) {
+			return nil, errors.New("PairHistoryMock")
+		},
+		ReceivedSinceMock: func(team, user string, since time.Time) (int, error) {
+			return 0, errors.New("ReceivedSinceMock")
+		},
+		GivenSinceMock: func(team, user string, since time.Time) ([]Transaction, error) {
+			return nil, errors.New("GivenSinceMock")
+		},
+		LastGivenMock: func(team, user string, since time.Time) ([]Transaction, error) {
+			return nil, errors.New("LastGivenMock")
+		},
+		UndoMock: func(ts []Transaction, date time.Time) ([]int, error) {
+			return nil, errors.New("UndoMock")
+		},
+		GetThingMock: func(team, thing string) (int, error) {
+			return 0, errors.New("GetThingMock")
+		},
+		UpdateThingDailyMock: func(t Transaction, date time.Time) (int, error) {
+			return 0, errors.New("UpdateThingDailyMock")
+		},
+		TopThingsMock: func(team string, n int) ([]ThingKarma, error) {
+			return nil, errors.New("TopThingsMock")
+		},
+		CurrentSeasonMock: func(team string) (Season, error) {
+			return Season{}, errors.New("CurrentSeasonMock")
+		},
+		GetSeasonMock: func(team, name string) (Season, error) {
+			return Season{}, errors.New("GetSeasonMock")
+		},
+		SeasonsMock: func(team string) ([]Season, error) {
+			return nil, errors.New("SeasonsMock")
+		},
+		RunningSeasonsMock: func() ([]Season, error) {
+			return nil, errors.New("RunningSeasonsMock")
+		},
+		StartSeasonMock: func(team, name string, at time.Time) (Season, error) {
+			return Season{}, errors.New("StartSeasonMock")
+		},
+		EndSeasonMock: func(team string, at time.Time) (Season, error) {
+			return Season{}, errors.New("EndSeasonMock")
+		},
+		RolloverSeasonMock: func(team, next string, at time.Time) (Season, error) {
+			return Season{}, errors.New("RolloverSeasonMock")
+		},
+		SeasonTopMock: func(team string, since time.Time, n int) ([]UserKarma, error) {
+			return nil, errors.New("SeasonTopMock")
+		},
+		ArchivedTopMock: func(team, season string, n int) ([]UserKarma, error) {
+			return nil, errors.New("ArchivedTopMock")
+		},
+		TeamWordsMock: func(team string) ([]TeamWord, error) {
+			return nil, errors.New("TeamWordsMock")
+		},
+		AddTeamWordMock: func(w TeamWord) (TeamWord, error) {
+			return TeamWord{}, errors.New("AddTeamWordMock")
+		},
+		ApproveTeamWordMock: func(team string, id int64, actor string) (TeamWord, error) {
+			return TeamWord{}, errors.New("ApproveTeamWordMock")
+		},
+		RemoveTeamWordMock: func(team string, id int64, actor string) (TeamWord, error) {
+			return TeamWord{}, errors.New("RemoveTeamWordMock")
+		},
+		RebuildKarmaMock: func(team string) error {
+			return errors.New("RebuildKarmaMock")
+		},
+		ReportMock: func(team string, from, to time.Time, n int) (Report, error) {
+			return Report{}, errors.New("ReportMock")
+		},
+		TeamConfigMock: func(team string) (map[string]string, error) {
+			return nil, errors.New("TeamConfigMock")
+		},
+		SetTeamConfigMock: func(team, setting, value, actor string) error {
+			return errors.New("SetTeamConfigMock")
+		},
+		ResetTeamConfigMock: func(team, setting, actor string) error {
+			return errors.New("ResetTeamConfigMock")
+		},
+		AuditMock: func(team string, n int) ([]AuditEntry, error) {
+			return nil, errors.New("AuditMock")
+		},
+		SetKarmaMock: func(team, user string, karma int, actor string) error {
+			return errors.New("SetKarmaMock")
+		},
+		IsAdminMock: func(team, user string) (bool, error) {
+			return false, errors.New("IsAdminMock")
+		},
+		AdminsMock: func(team string) ([]string, error) {
+			return nil, errors.New("AdminsMock")
+		},
+		AddAdminMock: func(team, user, actor string) error {
+			return errors.New("AddAdminMock")
+		},
+		RemoveAdminMock: func(team, user, actor string) error {
+			return errors.New("RemoveAdminMock")
+		},
+		IsBannedMock: func(team, user string) (bool, error) {
+			return false, errors.New("IsBannedMock")
+		},
+		BannedMock: func(team string) ([]string, error) {
+			return nil, errors.New("BannedMock")
+		},
+		BanMock: func(team, user, actor string) error {
+			return errors.New("BanMock")
+		},
+		UnbanMock: func(team, user, actor string) error {
+			return errors.New("UnbanMock")
+		},
+		TokenByHashMock: func(hash string) (APIToken, error) {
+			return APIToken{}, errors.New("TokenByHashMock")
+		},
+		CreateTokenMock: func(t APIToken, hash string) (APIToken, error) {
+			return APIToken{}, errors.New("CreateTokenMock")
+		},
+		TokensMock: func(team string) ([]APIToken, error) {
+			return nil, errors.New("TokensMock")
+		},
+		RevokeTokenMock: func(id int64) error {
+			return errors.New("RevokeTokenMock")
+		},
 	}
 }
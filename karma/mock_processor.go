@@ -1,11 +1,20 @@
 package karma
 
-import "github.com/icemanblues/knave-bot/shakespeare"
+import (
+	"time"
+
+	"github.com/icemanblues/knave-bot/shakespeare"
+)
+
+// mockNow the time it always is for the mock processors, in UTC which is their timezone
+var mockNow = time.Date(2026, time.May, 4, 15, 0, 0, 0, time.UTC)
 
 func mockProcessor(dao DAO) SlackProcessor {
-	return NewProcessor(DefaultConfig, dao,
+	config := DefaultConfig
+	config.Timezone = "UTC"
+	return NewProcessor(config, dao,
 		shakespeare.New("insult", "", nil),
-		shakespeare.New("compliment", "", nil))
+		shakespeare.New("compliment", "", nil)).WithClock(FixedClock(mockNow))
 }
 
 func happyMockProcessor() SlackProcessor {
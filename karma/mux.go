@@ -5,16 +5,39 @@ import (
 )
 
 // BindRoutes bind handlers to router
-func BindRoutes(karmaGroup *gin.RouterGroup, knaveGroup *gin.RouterGroup, karmaHandler Handler) {
+// verify is applied to the slack integrations, so only signed requests reach the handlers
+// auth is applied to the REST api, each route requires an api token with the scope it needs
+func BindRoutes(karmaGroup *gin.RouterGroup, knaveGroup *gin.RouterGroup, karmaHandler Handler, verify gin.HandlerFunc, auth TokenAuth) {
+	read, write, admin := auth.Require(ScopeRead), auth.Require(ScopeWrite), auth.Require(ScopeAdmin)
+
 	v1 := karmaGroup.Group("/v1")
 	// team
-	v1.GET("/team/:team", karmaHandler.TopKarma)
+	v1.GET("/team/:team", read, karmaHandler.TopKarma)
+	v1.GET("/team/:team/report", read, karmaHandler.ReportKarma)
+	v1.GET("/team/:team/rankings/top", read, karmaHandler.RankingsTop)
+	v1.GET("/team/:team/rankings/bottom", read, karmaHandler.RankingsBottom)
+	v1.GET("/team/:team/config", read, karmaHandler.GetTeamConfig)
+	v1.PUT("/team/:team/config/:setting", admin, karmaHandler.SetTeamConfig)
+	v1.DELETE("/team/:team/config/:setting", admin, karmaHandler.ResetTeamConfig)
+	v1.GET("/team/:team/audit", admin, karmaHandler.AuditLog)
 	// team user
-	v1.GET("/team/:team/:user", karmaHandler.GetKarma)
-	v1.PUT("/team/:team/:user", karmaHandler.AddKarma)
-	v1.DELETE("/team/:team/:user", karmaHandler.DelKarma)
+	v1.GET("/team/:team/:user", read, karmaHandler.GetKarma)
+	v1.PUT("/team/:team/:user", write, karmaHandler.AddKarma)
+	v1.DELETE("/team/:team/:user", write, karmaHandler.DelKarma)
+
+	// v2 json api, documented by its OpenAPI spec
+	v2 := karmaGroup.Group("/v2")
+	v2.GET("/openapi.json", OpenAPIJSON)
+	v2auth := auth.enveloped()
+	handlers := v2Handlers(karmaHandler)
+	for _, op := range V2Operations {
+		v2.Handle(op.Method, op.ginPath(), v2auth.Require(op.Scope), handlers[op.ID])
+	}
 
 	// slack slash command integration
 	slash := knaveGroup.Group("v1")
-	slash.POST("/cmd/karma", karmaHandler.SlashKarma)
+	slash.POST("/cmd/karma", verify, karmaHandler.SlashKarma)
+
+	// slack events api integration
+	slash.POST("/events", verify, karmaHandler.SlackEvent)
 }
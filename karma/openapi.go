@@ -0,0 +1,298 @@
+package karma
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// v2 operation ids, which also name the handler of each route
+const (
+	opGetKarma   = "getKarma"
+	opGiveKarma  = "giveKarma"
+	opResetKarma = "resetKarma"
+	opHistory    = "getHistory"
+	opRankings   = "getRankings"
+	opTeamStats  = "getTeamStats"
+)
+
+// Param a path or query parameter of an api operation
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Type        string
+	Enum        []string
+	Default     interface{}
+}
+
+// Operation a route of the v2 api. It is used to bind the route and to document it in the OpenAPI spec
+// Path is in the OpenAPI style, /teams/{team}. Request is the body, nil when there isn't one
+type Operation struct {
+	ID       string
+	Method   string
+	Path     string
+	Summary  string
+	Scope    Scope
+	Params   []Param
+	Request  interface{}
+	Example  interface{}
+	Response interface{}
+}
+
+var (
+	paramTeam   = Param{Name: "team", In: "path", Description: "The slack team id", Type: "string"}
+	paramUser   = Param{Name: "user", In: "path", Description: "The slack user id", Type: "string"}
+	paramLimit  = Param{Name: "limit", In: "query", Description: "The size of the page, at most 100", Type: "integer", Default: defaultPageLimit}
+	paramOffset = Param{Name: "offset", In: "query", Description: "Where the page starts, the next_offset of the previous page", Type: "integer", Default: 0}
+)
+
+// V2Operations every route of the v2 api
+var V2Operations = []Operation{
+	{
+		ID:       opGetKarma,
+		Method:   http.MethodGet,
+		Path:     "/teams/{team}/users/{user}",
+		Summary:  "A user's karma",
+		Scope:    ScopeRead,
+		Params:   []Param{paramTeam, paramUser},
+		Response: KarmaResponse{},
+	},
+	{
+		ID:       opGiveKarma,
+		Method:   http.MethodPost,
+		Path:     "/teams/{team}/users/{user}/karma",
+		Summary:  "Give a user karma, a negative delta takes it away",
+		Scope:    ScopeWrite,
+		Params:   []Param{paramTeam, paramUser},
+		Request:  KarmaRequest{},
+		Example:  KarmaRequest{Delta: 2},
+		Response: KarmaResponse{},
+	},
+	{
+		ID:       opResetKarma,
+		Method:   http.MethodDelete,
+		Path:     "/teams/{team}/users/{user}/karma",
+		Summary:  "Reset a user's karma to zero",
+		Scope:    ScopeWrite,
+		Params:   []Param{paramTeam, paramUser},
+		Response: KarmaResponse{},
+	},
+	{
+		ID:      opHistory,
+		Method:  http.MethodGet,
+		Path:    "/teams/{team}/users/{user}/history",
+		Summary: "The karma a user has received or given, most recent first",
+		Scope:   ScopeRead,
+		Params: []Param{paramTeam, paramUser, paramLimit, paramOffset,
+			{Name: "direction", In: "query", Description: "Karma received by the user, or given by them", Type: "string", Enum: []string{received, given}, Default: received},
+		},
+		Response: HistoryResponse{},
+	},
+	{
+		ID:      opRankings,
+		Method:  http.MethodGet,
+		Path:    "/teams/{team}/rankings",
+		Summary: "A team's leaderboard. Users with the same karma share a rank",
+		Scope:   ScopeRead,
+		Params: []Param{paramTeam, paramLimit, paramOffset,
+			{Name: "order", In: "query", Description: "From the most karma, or the least", Type: "string", Enum: []string{top, bottom}, Default: top},
+		},
+		Response: RankingsResponse{},
+	},
+	{
+		ID:      opTeamStats,
+		Method:  http.MethodGet,
+		Path:    "/teams/{team}/stats",
+		Summary: "The karma moved in a team during a period, this month by default",
+		Scope:   ScopeRead,
+		Params: []Param{paramTeam,
+			{Name: "period", In: "query", Description: "The current calendar period", Type: "string", Enum: []string{week, month, quarter, year}},
+			{Name: "last", In: "query", Description: "The previous full calendar period instead", Type: "string", Enum: []string{"true"}},
+			{Name: "from", In: "query", Description: "The first day, 2006-01-02. Instead of a period", Type: "string"},
+			{Name: "to", In: "query", Description: "The last day, 2006-01-02. Defaults to today", Type: "string"},
+			{Name: "n", In: "query", Description: "The size of each ranking", Type: "integer", Default: 5},
+		},
+		Response: TeamStatsResponse{},
+	},
+}
+
+// v2Handlers the handler of each v2 operation
+func v2Handlers(h Handler) map[string]gin.HandlerFunc {
+	return map[string]gin.HandlerFunc{
+		opGetKarma:   h.KarmaV2,
+		opGiveKarma:  h.GiveKarmaV2,
+		opResetKarma: h.ResetKarmaV2,
+		opHistory:    h.HistoryV2,
+		opRankings:   h.RankingsV2,
+		opTeamStats:  h.TeamStatsV2,
+	}
+}
+
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// ginPath the operation's path with gin's :param placeholders
+func (op Operation) ginPath() string {
+	return pathParam.ReplaceAllString(op.Path, ":$1")
+}
+
+// OpenAPI the OpenAPI 3 spec of the v2 api, generated from V2Operations and the request and response types
+func OpenAPI() map[string]interface{} {
+	schemas := make(jsonSchemas)
+	errorRef := schemas.of(reflect.TypeOf(ErrorEnvelope{}))
+	errorResponse := func(status int) map[string]interface{} {
+		return map[string]interface{}{
+			"description": http.StatusText(status),
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}},
+		}
+	}
+
+	paths := make(map[string]interface{})
+	for _, op := range V2Operations {
+		params := make([]interface{}, 0, len(op.Params))
+		for _, p := range op.Params {
+			schema := map[string]interface{}{"type": p.Type}
+			if p.Enum != nil {
+				schema["enum"] = p.Enum
+			}
+			if p.Default != nil {
+				schema["default"] = p.Default
+			}
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"description": p.Description,
+				"required":    p.In == "path",
+				"schema":      schema,
+			})
+		}
+
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schemas.of(reflect.TypeOf(op.Response))}},
+			},
+			"400": errorResponse(400),
+			"401": errorResponse(401),
+			"403": errorResponse(403),
+			"500": errorResponse(500),
+		}
+
+		operation := map[string]interface{}{
+			"operationId": op.ID,
+			"summary":     op.Summary,
+			"parameters":  params,
+			"responses":   responses,
+			"security":    []interface{}{map[string]interface{}{"bearerAuth": []string{}}},
+			"x-scope":     op.Scope,
+		}
+		if op.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{"application/json": map[string]interface{}{
+					"schema":  schemas.of(reflect.TypeOf(op.Request)),
+					"example": op.Example,
+				}},
+			}
+		}
+
+		methods, ok := paths[op.Path].(map[string]interface{})
+		if !ok {
+			methods = make(map[string]interface{})
+			paths[op.Path] = methods
+		}
+		methods[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "knave-bot karma",
+			"version":     "2.0.0",
+			"description": "Karma for slack teams. Every route needs an api token with its x-scope, minted with `knave-bot token create`",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": "/karmabot/v2"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// OpenAPIJSON serves the OpenAPI spec of the v2 api
+func OpenAPIJSON(c *gin.Context) {
+	c.JSON(200, OpenAPI())
+}
+
+// jsonSchemas the named schemas of the spec, by type name
+type jsonSchemas map[string]interface{}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// of the schema of a type. Structs are added to the named schemas, and referenced
+func (s jsonSchemas) of(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		schema := s.of(t.Elem())
+		schema["nullable"] = true
+		return schema
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		if _, ok := s[t.Name()]; !ok {
+			s[t.Name()] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() == reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+
+	return map[string]interface{}{}
+}
+
+// object the schema of a struct, by its json tags. Fields without omitempty are required
+func (s jsonSchemas) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = s.of(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
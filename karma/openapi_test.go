@@ -0,0 +1,212 @@
+package karma
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// spec fetches the served OpenAPI spec, as a client would see it
+func spec(t *testing.T, r *gin.Engine) map[string]interface{} {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/karmabot/v2/openapi.json", nil)
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	return doc
+}
+
+// validate checks a decoded json value against a schema of the spec
+func validate(doc map[string]interface{}, schema map[string]interface{}, value interface{}, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v: unknown schema %v", at, ref)
+		}
+		return validate(doc, resolved, value, at)
+	}
+
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return fmt.Errorf("%v: is null", at)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v: %v is not an object", at, value)
+		}
+		for _, name := range schema["required"].([]interface{}) {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Errorf("%v: is missing %v", at, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, v := range obj {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if extra, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+					property = extra
+				} else {
+					return fmt.Errorf("%v: has unexpected property %v", at, name)
+				}
+			}
+			if err := validate(doc, property, v, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v: %v is not an array", at, value)
+		}
+		for i, v := range arr {
+			if err := validate(doc, schema["items"].(map[string]interface{}), v, fmt.Sprintf("%v[%v]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%v: %v is not a string", at, value)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				return fmt.Errorf("%v: %v is not a date-time", at, s)
+			}
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%v: %v is not an integer", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%v: %v is not a boolean", at, value)
+		}
+	default:
+		return fmt.Errorf("%v: unexpected schema %v", at, schema)
+	}
+
+	return nil
+}
+
+// contractRequest a request for an operation of the spec, with example path params and body
+func contractRequest(method, path string, operation map[string]interface{}, query string) *http.Request {
+	path = strings.NewReplacer("{team}", "nycfc", "{user}", "davidvilla").Replace(path)
+
+	var body string
+	if rb, ok := operation["requestBody"].(map[string]interface{}); ok {
+		example := rb["content"].(map[string]interface{})["application/json"].(map[string]interface{})["example"]
+		b, _ := json.Marshal(example)
+		body = string(b)
+	}
+
+	req, _ := http.NewRequest(strings.ToUpper(method), "/karmabot/v2"+path+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// checkResponse the response must be documented for the operation, and match its schema
+func checkResponse(t *testing.T, doc, operation map[string]interface{}, w *httptest.ResponseRecorder) {
+	response, ok := operation["responses"].(map[string]interface{})[fmt.Sprint(w.Code)].(map[string]interface{})
+	if !assert.True(t, ok, "undocumented status %v", w.Code) {
+		return
+	}
+	schema := response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+
+	var body interface{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	assert.Nil(t, validate(doc, schema, body, "body"), w.Body.String())
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	r := setup(HappyDao())
+	doc := spec(t, r)
+	assert.Equal(t, "3.0.3", doc["openapi"])
+
+	var documented []string
+	for path, methods := range doc["paths"].(map[string]interface{}) {
+		for method := range methods.(map[string]interface{}) {
+			documented = append(documented, strings.ToUpper(method)+" "+pathParam.ReplaceAllString(path, ":$1"))
+		}
+	}
+
+	var served []string
+	for _, route := range r.Routes() {
+		path, ok := strings.CutPrefix(route.Path, "/karmabot/v2")
+		if ok && path != "/openapi.json" {
+			served = append(served, route.Method+" "+path)
+		}
+	}
+
+	sort.Strings(documented)
+	sort.Strings(served)
+	assert.Equal(t, documented, served)
+}
+
+func TestOpenAPIContract(t *testing.T) {
+	happy, sad := setup(HappyDao()), setup(SadDao())
+	doc := spec(t, happy)
+
+	for path, methods := range doc["paths"].(map[string]interface{}) {
+		for method, op := range methods.(map[string]interface{}) {
+			operation := op.(map[string]interface{})
+
+			t.Run(operation["operationId"].(string), func(t *testing.T) {
+				check := func(r *gin.Engine, query, token string, code int) {
+					w := httptest.NewRecorder()
+					req := contractRequest(method, path, operation, query)
+					if token != "" {
+						req.Header.Set("Authorization", "Bearer "+token)
+					}
+					r.ServeHTTP(w, req)
+
+					assert.Equal(t, code, w.Code, "%v %v%v %v", method, path, query, w.Body.String())
+					checkResponse(t, doc, operation, w)
+				}
+
+				check(happy, "", mockAdminToken, 200)
+				check(happy, "", "", 401)
+				check(happy, "", "nope", 401)
+				check(sad, "", mockAdminToken, 500)
+				if operation["x-scope"] != string(ScopeRead) {
+					check(happy, "", mockReadToken, 403)
+				}
+
+				// every query param is exercised with an allowed and a bad value
+				for _, p := range operation["parameters"].([]interface{}) {
+					param := p.(map[string]interface{})
+					if param["in"] != "query" {
+						continue
+					}
+					name, schema := param["name"].(string), param["schema"].(map[string]interface{})
+
+					if enum, ok := schema["enum"].([]interface{}); ok {
+						for _, value := range enum {
+							check(happy, fmt.Sprintf("?%v=%v", name, value), mockAdminToken, 200)
+						}
+					}
+					if schema["type"] == "integer" {
+						check(happy, fmt.Sprintf("?%v=2", name), mockAdminToken, 200)
+					}
+					check(happy, fmt.Sprintf("?%v=bad-value", name), mockAdminToken, 400)
+				}
+			})
+		}
+	}
+}
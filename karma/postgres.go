@@ -0,0 +1,34 @@
+package karma
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq" // postgres db driver
+)
+
+// InitPostgres connects to a PostgreSQL database and migrates it to the latest schema
+func InitPostgres(dataSourceName string) (*sql.DB, error) {
+	db, err := connectPostgres(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Migrate(db, DriverPostgres); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func connectPostgres(dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open(DriverPostgres, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -1,6 +1,9 @@
 package karma
 
 import (
+	"errors"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,19 +23,45 @@ func Abs(x int) int {
 // Processor processes slash-commands into slack responses
 type Processor interface {
 	Process(cd slack.CommandData) (slack.Response, error)
+	ProcessMessage(m slack.MessageEvent) ([]slack.Response, error)
+	TeamConfig(team string) (ProcConfig, map[string]string, error)
+	SetTeamConfig(team, setting, value, actor string) (ProcConfig, error)
+	ResetTeamConfig(team, setting, actor string) (ProcConfig, error)
 }
 
 // SlackProcessor an implementation of KarmaProcessor that uses SQLite
+// config is the team's own during a command, defaults is always the global one
 type SlackProcessor struct {
 	config     ProcConfig
+	defaults   ProcConfig
 	dao        DAO
 	insult     shakespeare.Generator
 	compliment shakespeare.Generator
+	now        Clock
+	dir        slack.Directory
 }
 
 // NewProcessor factory method
 func NewProcessor(config ProcConfig, dao DAO, insult, compliment shakespeare.Generator) SlackProcessor {
-	return SlackProcessor{config, dao, insult, compliment}
+	return SlackProcessor{config, config, dao, insult, compliment, SystemClock, nil}
+}
+
+// WithDirectory the same processor, looking up the members of user groups, @here and @channel in the directory.
+// Without one, karma can't be given to a group
+func (p SlackProcessor) WithDirectory(dir slack.Directory) SlackProcessor {
+	p.dir = dir
+	return p
+}
+
+// WithClock the same processor, telling the time (for limits, cooldowns and reports) with the clock
+func (p SlackProcessor) WithClock(now Clock) SlackProcessor {
+	p.now = now
+	return p
+}
+
+// teamNow the time in the team's timezone
+func (p SlackProcessor) teamNow() time.Time {
+	return p.now().In(p.config.Location())
 }
 
 // Process handles Karma processing from slack API
@@ -41,7 +70,7 @@ func (p SlackProcessor) Process(c slack.CommandData) (slack.Response, error) {
 		return p.help()
 	}
 
-	words := strings.Fields(c.Text)
+	words := splitWords(c.Text)
 	if len(words) == 0 {
 		return p.help()
 	}
@@ -63,11 +92,132 @@ func (p SlackProcessor) Process(c slack.CommandData) (slack.Response, error) {
 	return p.help()
 }
 
+// Mention a karma change typed into a channel message, like `<@U123> ++ for the review` or `#release++`.
+// Thing is true when the target is a thing's name rather than a user id. Group is set, instead of Target, for a group
+type Mention struct {
+	Target string
+	Delta  int
+	Reason string
+	Thing  bool
+	Group  slack.Group
+}
+
+// `<@U123> ++` gives 1, every extra `+` (or `-`) adds one more: `<@U123> +++` gives 2
+var mentionRegex = regexp.MustCompile(`<@(U[A-Z0-9]+)(?:\|[^>]*)?>:?\s?(\+{2,}|-{2,})`)
+
+// `<!subteam^S123|@devs> ++`, `<!here> ++` and `<!channel> ++` give karma to a whole group
+var groupMentionRegex = regexp.MustCompile(`(<![a-z]+(?:\^S[A-Z0-9]+)?(?:\|[^>]*)?>):?\s?(\+{2,}|-{2,})`)
+
+// parseMentions finds every karma mention, of users, groups and things, in a message in the order they were written.
+// The text following a mention, up to the next one, is its reason when it starts with "for".
+// Unlike the slash command, "for" is required since the rest of a message is usually just conversation
+func parseMentions(text string) []Mention {
+	text = curlyQuotes.Replace(text)
+
+	var found []mentionAt
+	for _, m := range mentionRegex.FindAllStringSubmatchIndex(text, -1) {
+		found = append(found, mentionAt{Mention{Target: text[m[2]:m[3]], Delta: opDelta(text[m[4]:m[5]])}, m[0], m[1]})
+	}
+	for _, m := range groupMentionRegex.FindAllStringSubmatchIndex(text, -1) {
+		if group, ok := slack.IsSlackGroup(text[m[2]:m[3]]); ok {
+			found = append(found, mentionAt{Mention{Group: group, Delta: opDelta(text[m[4]:m[5]])}, m[0], m[1]})
+		}
+	}
+	for _, thing := range thingMentions(text) {
+		// a user's mention is never a thing too
+		if !thing.overlaps(found) {
+			found = append(found, thing)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+
+	mentions := make([]Mention, 0, len(found))
+	for i, m := range found {
+		end := len(text)
+		if i+1 < len(found) {
+			end = found[i+1].start
+		}
+		if rest := strings.Fields(text[m.end:end]); len(rest) > 1 && strings.EqualFold(rest[0], "for") {
+			m.Reason = parseReason(rest, 0)
+		}
+		mentions = append(mentions, m.Mention)
+	}
+
+	return mentions
+}
+
+// mentionAt a mention, and where it is in the message
+type mentionAt struct {
+	Mention
+	start, end int
+}
+
+func (m mentionAt) overlaps(others []mentionAt) bool {
+	for _, o := range others {
+		if m.start < o.end && o.start < m.end {
+			return true
+		}
+	}
+	return false
+}
+
+// opDelta the karma of a `++` or `--`, every extra `+` (or `-`) adds one more
+func opDelta(op string) int {
+	delta := len(op) - 1
+	if op[0] == '-' {
+		return -delta
+	}
+	return delta
+}
+
+// ProcessMessage handles karma mentions in an ordinary channel message (Events API).
+// Every mention is held to the same rules as the slash command, one response per mention
+func (p SlackProcessor) ProcessMessage(m slack.MessageEvent) ([]slack.Response, error) {
+	mentions := parseMentions(m.Text)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	// the rules are the team's own
+	cfg, _, err := p.TeamConfig(m.Team)
+	if err != nil {
+		return nil, err
+	}
+	p.config = cfg
+
+	responses := make([]slack.Response, 0, len(mentions))
+	for _, mention := range mentions {
+		var res slack.Response
+		switch {
+		case mention.Group.Kind != "":
+			res, err = p.giveGroup(m.Team, m.Channel, m.User, mention.Group, mention.Delta, mention.Reason)
+		case mention.Thing:
+			res, err = p.giveThing(m.Team, m.Channel, m.User, mention.Target, mention.Delta, mention.Reason)
+		default:
+			res, err = p.give(m.Team, m.Channel, m.User, mention.Target, mention.Delta, mention.Reason)
+		}
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, res)
+	}
+
+	return responses, nil
+}
+
 func (p SlackProcessor) processCommand(words []string, c slack.CommandData) (slack.Response, error) {
-	switch words[0] {
-	case help:
+	if words[0] == help {
 		return p.help()
+	}
+
+	// the rules are the team's own. p is a copy, so this only lasts for this command
+	cfg, _, err := p.TeamConfig(c.TeamID)
+	if err != nil {
+		return slack.Response{}, err
+	}
+	p.config = cfg
 
+	switch words[0] {
 	case me:
 		return p.me(c.TeamID, c.UserID)
 
@@ -75,13 +225,34 @@ func (p SlackProcessor) processCommand(words []string, c slack.CommandData) (sla
 		return p.status(c.TeamID, c.UserID, words)
 
 	case add:
-		return p.add(c.TeamID, c.UserID, words)
+		return p.add(c.TeamID, c.ChannelID, c.UserID, words)
 
 	case sub:
-		return p.subtract(c.TeamID, c.UserID, words)
+		return p.subtract(c.TeamID, c.ChannelID, c.UserID, words)
 
 	case top:
 		return p.top(c.TeamID, words)
+
+	case bottom, bot:
+		return p.bottom(c.TeamID, words)
+
+	case why:
+		return p.why(c.TeamID, words)
+
+	case report:
+		return p.report(c.TeamID, words)
+
+	case config:
+		return p.teamConfig(c.TeamID, c.UserID, words)
+
+	case admin:
+		return p.admin(c.TeamID, c.UserID, words)
+
+	case undo:
+		return p.undo(c.TeamID, c.UserID)
+
+	case season:
+		return p.season(c.TeamID, c.UserID, words)
 	}
 
 	return p.help()
@@ -104,15 +275,17 @@ func addSubCmdAlias(words []string) []string {
 
 	d := strconv.Itoa(Abs(delta))
 
-	if delta > 0 {
-		w := []string{add, words[1], d}
-		w = append(w, words[2:]...)
-		return w
+	// the amount goes after every target: /karma +2 @a @b => /karma ++ @a @b 2
+	n := 1 + max(1, len(parseUsers(words, 1)))
+	op := add
+	if delta < 0 {
+		op = sub
 	}
 
-	// must be negative
-	w := []string{sub, words[1], d}
-	w = append(w, words[2:]...)
+	w := []string{op}
+	w = append(w, words[1:n]...)
+	w = append(w, d)
+	w = append(w, words[n:]...)
 	return w
 }
 
@@ -157,6 +330,20 @@ func parseArgInt(words []string, idx int, d int) (int, bool) {
 	return i, true
 }
 
+// parseReason everything from idx onwards is the reason karma was given, with or without a leading "for"
+func parseReason(words []string, idx int) string {
+	if idx >= len(words) || idx < 0 {
+		return ""
+	}
+
+	reason := words[idx:]
+	if strings.EqualFold(reason[0], "for") {
+		reason = reason[1:]
+	}
+
+	return strings.Join(reason, " ")
+}
+
 func parseArgUser(words []string, idx int) (string, bool) {
 	s, ok := parseArg(words, idx)
 	if !ok {
@@ -176,17 +363,16 @@ func (p SlackProcessor) me(team, userID string) (slack.Response, error) {
 		return slack.Response{}, err
 	}
 
-	// daily usage check
-	usage, err := p.dao.GetDaily(team, userID, time.Now())
+	now := p.teamNow()
+	a, err := p.allowance(team, userID, now)
 	if err != nil {
 		return slack.Response{}, err
 	}
-	available := p.config.DailyLimit - usage
 
 	msg, att := &strings.Builder{}, &strings.Builder{}
 	msg.WriteString(MsgUserStatus(userID, k))
 	msg.WriteString("\n")
-	msg.WriteString(MsgUserDailyLimit(usage, available))
+	msg.WriteString(a.String(now))
 	att.WriteString(p.Salutation(k))
 	return slack.DirectResponse(msg.String(), att.String()), nil
 }
@@ -197,10 +383,13 @@ func (p SlackProcessor) status(team, callee string, words []string) (slack.Respo
 		return slack.DirectResponse(msgMissingName, cmdStatus), nil
 	}
 
-	target, ok := slack.IsSlackUser(name)
+	target, thing, ok := parseTarget(name)
 	if !ok {
 		return slack.DirectResponse(msgInvalidUser, cmdStatus), nil
 	}
+	if thing {
+		return p.thingStatus(team, callee, target)
+	}
 
 	k, err := p.dao.GetKarma(team, target)
 	if err != nil {
@@ -214,7 +403,8 @@ func (p SlackProcessor) status(team, callee string, words []string) (slack.Respo
 	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
 }
 
-func (p SlackProcessor) top(team string, words []string) (slack.Response, error) {
+// leaderboardSize the number of users to list on a leaderboard, within the guard rails
+func (p SlackProcessor) leaderboardSize(words []string) int {
 	n, _ := parseArgInt(words, 1, p.config.TopUserDefault)
 
 	// no negatives are allowed
@@ -226,6 +416,31 @@ func (p SlackProcessor) top(team string, words []string) (slack.Response, error)
 		n = p.config.TopUserMax
 	}
 
+	return n
+}
+
+func (p SlackProcessor) top(team string, words []string) (slack.Response, error) {
+	arg, _ := parseArg(words, 1)
+	switch {
+	case arg == things:
+		return p.topThings(team, words)
+	case strings.HasPrefix(arg, seasonPrefix):
+		return p.topSeason(team, strings.TrimPrefix(arg, seasonPrefix), p.leaderboardSize(words[1:]))
+	case arg == allTime:
+		words = words[1:]
+	default:
+		// the running season, when there is one
+		s, err := p.dao.CurrentSeason(team)
+		if err == nil {
+			return p.seasonLeaderboard(s, p.leaderboardSize(words))
+		}
+		if !errors.Is(err, ErrNoSeason) {
+			return slack.Response{}, err
+		}
+	}
+
+	n := p.leaderboardSize(words)
+
 	topUsers, err := p.dao.Top(team, n)
 	if err != nil {
 		return slack.Response{}, err
@@ -241,99 +456,186 @@ func (p SlackProcessor) top(team string, words []string) (slack.Response, error)
 	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
 }
 
-func (p SlackProcessor) add(team, callee string, words []string) (slack.Response, error) {
+func (p SlackProcessor) bottom(team string, words []string) (slack.Response, error) {
+	n := p.leaderboardSize(words)
+
+	bottomUsers, err := p.dao.Bottom(team, n)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	if len(bottomUsers) == 0 {
+		return slack.DirectResponse(msgNoKarmaForBottom, ""), nil
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	msg.WriteString(MsgBottomKarma(bottomUsers))
+	att.WriteString(p.insult.Sentence())
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
+
+func (p SlackProcessor) why(team string, words []string) (slack.Response, error) {
 	name, ok := parseArg(words, 1)
 	if !ok {
-		return slack.DirectResponse(msgAddMissingTarget, cmdAdd), nil
+		return slack.DirectResponse(msgMissingName, cmdWhy), nil
 	}
 
 	target, ok := slack.IsSlackUser(name)
 	if !ok {
-		return slack.DirectResponse(msgInvalidUser, cmdAdd), nil
+		return slack.DirectResponse(msgInvalidUser, cmdWhy), nil
 	}
 
-	if target == callee {
-		return slack.ErrorResponse(msgAddSelfTarget), nil
+	reasons, err := p.dao.Reasons(team, target, p.leaderboardSize(words[1:]))
+	if err != nil {
+		return slack.Response{}, err
 	}
 
-	delta, _ := parseArgInt(words, 2, 1)
-	if delta == 0 {
-		return slack.ErrorResponse(msgNoOp), nil
+	if len(reasons) == 0 {
+		return slack.DirectResponse(MsgNoReasons(target), ""), nil
 	}
-	if delta < 0 {
-		return slack.ErrorResponse(msgAddCantRemove), nil
-	}
-	if delta > p.config.SingleLimit {
-		return slack.ErrorResponse(msgDeltaLimit), nil
+
+	return slack.DirectResponse(MsgReasons(target, reasons), ""), nil
+}
+
+func (p SlackProcessor) add(team, channel, callee string, words []string) (slack.Response, error) {
+	name, ok := parseArg(words, 1)
+	if !ok {
+		return slack.DirectResponse(msgAddMissingTarget, cmdAdd), nil
 	}
 
-	// daily usage check
-	usage, err := p.dao.GetDaily(team, callee, time.Now())
-	if err != nil {
-		return slack.Response{}, err
+	if group, ok := slack.IsSlackGroup(name); ok {
+		delta, ok := parseArgInt(words, 2, 1)
+		if delta < 0 {
+			return slack.ErrorResponse(msgAddCantRemove), nil
+		}
+		return p.giveGroup(team, channel, callee, group, delta, parseReason(words, reasonIndex(0, ok)))
 	}
-	available := p.config.DailyLimit - usage
-	if available < delta {
-		return slack.ErrorResponse(MsgOverDailyLimit(p.config.DailyLimit, usage, available)), nil
+
+	target, thing, ok := parseTarget(name)
+	if !ok {
+		return slack.DirectResponse(msgInvalidUser, cmdAdd), nil
 	}
+	users := parseUsers(words, 1)
 
-	k, err := p.dao.UpdateKarmaDaily(team, callee, target, delta, time.Now())
-	if err != nil {
-		return slack.Response{}, err
+	// optional: an amount and then the reason
+	delta, ok := parseArgInt(words, 1+max(1, len(users)), 1)
+	if delta < 0 {
+		return slack.ErrorResponse(msgAddCantRemove), nil
 	}
+	reason := parseReason(words, reasonIndex(len(users), ok))
 
-	msg, att := &strings.Builder{}, &strings.Builder{}
-	msg.WriteString(MsgGiveKarma(callee, target, delta))
-	msg.WriteString(MsgUserStatus(target, k))
-	att.WriteString(p.Salutation(delta))
-	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+	if len(users) > 1 {
+		return p.giveMany(team, channel, callee, users, delta, reason)
+	}
+	if thing {
+		return p.giveThing(team, channel, callee, target, delta, reason)
+	}
+	return p.give(team, channel, callee, target, delta, reason)
 }
 
-func (p SlackProcessor) subtract(team, callee string, words []string) (slack.Response, error) {
+func (p SlackProcessor) subtract(team, channel, callee string, words []string) (slack.Response, error) {
 	name, ok := parseArg(words, 1)
 	if !ok {
 		return slack.DirectResponse(msgSubtractMissingTarget, cmdSub), nil
 	}
 
-	target, ok := slack.IsSlackUser(name)
-	if !ok {
-		return slack.DirectResponse(msgInvalidUser, cmdSub), nil
+	if group, ok := slack.IsSlackGroup(name); ok {
+		delta, ok := parseArgInt(words, 2, 1)
+		if delta == 0 {
+			return slack.DirectResponse(msgNoOp, cmdSub), nil
+		}
+		if delta < 0 {
+			return slack.DirectResponse(msgSubtractCantAdd, cmdSub), nil
+		}
+		return p.giveGroup(team, channel, callee, group, -delta, parseReason(words, reasonIndex(0, ok)))
 	}
 
-	if target == callee {
-		return slack.ErrorResponse(msgSubtractSelfTarget), nil
+	target, thing, ok := parseTarget(name)
+	if !ok {
+		return slack.DirectResponse(msgInvalidUser, cmdSub), nil
 	}
+	users := parseUsers(words, 1)
 
 	// optional: see if next parameter is an amount, if so, use it
-	delta, _ := parseArgInt(words, 2, 1)
+	delta, ok := parseArgInt(words, 1+max(1, len(users)), 1)
 	if delta == 0 {
 		return slack.DirectResponse(msgNoOp, cmdSub), nil
 	}
 	if delta < 0 {
 		return slack.DirectResponse(msgSubtractCantAdd, cmdSub), nil
 	}
-	if delta > p.config.SingleLimit {
-		return slack.ErrorResponse(msgDeltaLimit), nil
+
+	reason := parseReason(words, reasonIndex(len(users), ok))
+
+	if len(users) > 1 {
+		return p.giveMany(team, channel, callee, users, -delta, reason)
+	}
+	if thing {
+		return p.giveThing(team, channel, callee, target, -delta, reason)
 	}
+	return p.give(team, channel, callee, target, -delta, reason)
+}
 
-	// daily usage check
-	usage, err := p.dao.GetDaily(team, callee, time.Now())
-	if err != nil {
-		return slack.Response{}, err
+// reasonIndex the reason follows the amount, or the targets when there is no amount. There is always one target,
+// a thing when there are no users
+func reasonIndex(users int, hasAmount bool) int {
+	idx := 1 + max(1, users)
+	if hasAmount {
+		idx++
+	}
+	return idx
+}
+
+// give applies the karma rules (no self karma, single limit, bans, the daily limit and the pair rules) and then moves delta karma to target.
+// A negative delta takes karma away
+func (p SlackProcessor) give(team, channel, callee, target string, delta int, reason string) (slack.Response, error) {
+	if target == callee {
+		if delta < 0 {
+			return slack.ErrorResponse(msgSubtractSelfTarget), nil
+		}
+		return slack.ErrorResponse(msgAddSelfTarget), nil
 	}
-	available := p.config.DailyLimit - usage
-	if available < delta {
-		return slack.ErrorResponse(MsgOverDailyLimit(p.config.DailyLimit, usage, available)), nil
+
+	if delta == 0 {
+		return slack.ErrorResponse(msgNoOp), nil
+	}
+	if Abs(delta) > p.config.SingleLimit {
+		return slack.ErrorResponse(MsgDeltaLimit(p.config.SingleLimit)), nil
 	}
 
-	k, err := p.dao.UpdateKarmaDaily(team, callee, target, -delta, time.Now())
+	if resp, banned, err := p.banned(team, callee, target); err != nil || banned {
+		return resp, err
+	}
+
+	now := p.teamNow()
+	if resp, over, err := p.overDailyLimit(team, callee, delta, now); err != nil || over {
+		return resp, err
+	}
+
+	if resp, rejected, err := p.pairRules(team, callee, target, delta, now); err != nil || rejected {
+		return resp, err
+	}
+
+	t := Transaction{
+		Team:    team,
+		From:    callee,
+		To:      target,
+		Channel: channel,
+		Delta:   delta,
+		Message: reason,
+	}
+	k, err := p.dao.UpdateKarmaDaily(t, now)
 	if err != nil {
 		return slack.Response{}, err
 	}
 
 	msg, att := &strings.Builder{}, &strings.Builder{}
-	msg.WriteString(MsgTakeKarma(callee, target, delta))
+	if delta > 0 {
+		msg.WriteString(MsgGiveKarma(callee, target, delta, reason))
+	} else {
+		msg.WriteString(MsgTakeKarma(callee, target, -delta, reason))
+	}
 	msg.WriteString(MsgUserStatus(target, k))
-	att.WriteString(p.Salutation(-delta))
+	att.WriteString(p.Salutation(delta))
 	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
 }
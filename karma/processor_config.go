@@ -1,5 +1,11 @@
 package karma
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 // Command my own string type for commands (think of it as an enum)
 type Command string
 
@@ -10,6 +16,14 @@ const (
 	add    string = "++"
 	sub    string = "--"
 	top    string = "top"
+	bottom string = "bottom"
+	bot    string = "bot"
+	report string = "report"
+	why    string = "why"
+	config string = "config"
+	admin  string = "admin"
+	undo   string = "undo"
+	season string = "season"
 )
 
 // Commands a set of the support commands by this processor
@@ -20,18 +34,103 @@ var Commands = map[string]struct{}{
 	add:    struct{}{},
 	sub:    struct{}{},
 	top:    struct{}{},
+	bottom: struct{}{},
+	bot:    struct{}{},
+	report: struct{}{},
+	why:    struct{}{},
+	config: struct{}{},
+	admin:  struct{}{},
+	undo:   struct{}{},
+	season: struct{}{},
 }
 
 // ProcConfig processor config object to contain all of these customizations
 // SingleLimit one time karma swings are capped at 5 (default)
 // DailyLimit this is the default daily limit for giving/ taking karma
-// used by top function as guard rails
-// used by top function as guard rails
+// TopUserDefault and TopUserMax are the guard rails for the leaderboards
+// PairCooldown minutes a giver must wait before giving the same person karma again, 0 is off
+// PairLimit the most karma a giver can give the same person in PairWindow hours, 0 is off
+// RecipientDailyLimit the most karma a user can receive in a day, 0 is off
+// Timezone the IANA timezone (or Local) that the team's days start and end in
+// DailyWindow when the daily limit frees up: day at midnight, or rolling 24h after each karma was given
+// UndoWindow minutes a giver has to take back their last karma with `/karma undo`, 0 is off
+// GroupPolicy how karma for a user group, @here or @channel is handed out: split between the members, or replicated to each
+// SeasonLength when the server rolls a running season over to the next: off, at the end of each month, or each quarter
+// Admins may change a team's settings with `/karma config`. It is global only, teams can't override it
 type ProcConfig struct {
-	SingleLimit    int
-	DailyLimit     int
-	TopUserDefault int
-	TopUserMax     int
+	SingleLimit    int `yaml:"single_limit" toml:"single_limit" json:"single_limit"`
+	DailyLimit     int `yaml:"daily_limit" toml:"daily_limit" json:"daily_limit"`
+	TopUserDefault int `yaml:"top_user_default" toml:"top_user_default" json:"top_user_default"`
+	TopUserMax     int `yaml:"top_user_max" toml:"top_user_max" json:"top_user_max"`
+
+	PairCooldown        int `yaml:"pair_cooldown_minutes" toml:"pair_cooldown_minutes" json:"pair_cooldown_minutes"`
+	PairLimit           int `yaml:"pair_limit" toml:"pair_limit" json:"pair_limit"`
+	PairWindow          int `yaml:"pair_window_hours" toml:"pair_window_hours" json:"pair_window_hours"`
+	RecipientDailyLimit int `yaml:"recipient_daily_limit" toml:"recipient_daily_limit" json:"recipient_daily_limit"`
+	UndoWindow          int `yaml:"undo_window_minutes" toml:"undo_window_minutes" json:"undo_window_minutes"`
+
+	Timezone     string `yaml:"timezone" toml:"timezone" json:"timezone"`
+	DailyWindow  string `yaml:"daily_window" toml:"daily_window" json:"daily_window"`
+	GroupPolicy  string `yaml:"group_policy" toml:"group_policy" json:"group_policy"`
+	SeasonLength string `yaml:"season_length" toml:"season_length" json:"season_length"`
+
+	Admins []string `yaml:"admins" toml:"admins" json:"-"`
+}
+
+// Validate checks that the limits make sense together
+func (c ProcConfig) Validate() error {
+	var errs []error
+	if c.SingleLimit < 1 {
+		errs = append(errs, fmt.Errorf("single_limit must be at least 1, got %v", c.SingleLimit))
+	}
+	if c.DailyLimit < c.SingleLimit {
+		errs = append(errs, fmt.Errorf("daily_limit (%v) must be at least single_limit (%v)", c.DailyLimit, c.SingleLimit))
+	}
+	if c.TopUserDefault < 1 {
+		errs = append(errs, fmt.Errorf("top_user_default must be at least 1, got %v", c.TopUserDefault))
+	}
+	if c.TopUserMax < c.TopUserDefault {
+		errs = append(errs, fmt.Errorf("top_user_max (%v) must be at least top_user_default (%v)", c.TopUserMax, c.TopUserDefault))
+	}
+	if c.PairCooldown < 0 {
+		errs = append(errs, fmt.Errorf("pair_cooldown_minutes must be 0 (off) or more, got %v", c.PairCooldown))
+	}
+	if c.PairLimit < 0 {
+		errs = append(errs, fmt.Errorf("pair_limit must be 0 (off) or more, got %v", c.PairLimit))
+	}
+	if c.PairWindow < 1 {
+		errs = append(errs, fmt.Errorf("pair_window_hours must be at least 1, got %v", c.PairWindow))
+	}
+	if c.RecipientDailyLimit < 0 {
+		errs = append(errs, fmt.Errorf("recipient_daily_limit must be 0 (off) or more, got %v", c.RecipientDailyLimit))
+	}
+	if c.UndoWindow < 0 {
+		errs = append(errs, fmt.Errorf("undo_window_minutes must be 0 (off) or more, got %v", c.UndoWindow))
+	}
+
+	if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "" {
+		errs = append(errs, fmt.Errorf("timezone must be an IANA timezone like America/New_York, or Local, got %q", c.Timezone))
+	}
+	if c.DailyWindow != WindowDay && c.DailyWindow != WindowRolling {
+		errs = append(errs, fmt.Errorf("daily_window must be %v or %v, got %q", WindowDay, WindowRolling, c.DailyWindow))
+	}
+	if c.GroupPolicy != PolicySplit && c.GroupPolicy != PolicyReplicate {
+		errs = append(errs, fmt.Errorf("group_policy must be %v or %v, got %q", PolicySplit, PolicyReplicate, c.GroupPolicy))
+	}
+	if c.SeasonLength != SeasonOff && c.SeasonLength != SeasonMonth && c.SeasonLength != SeasonQuarter {
+		errs = append(errs, fmt.Errorf("season_length must be %v, %v or %v, got %q", SeasonOff, SeasonMonth, SeasonQuarter, c.SeasonLength))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Location the team's timezone. Validate has already checked that it loads
+func (c ProcConfig) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
 }
 
 // DefaultConfig default settings for the Processor
@@ -40,4 +139,29 @@ var DefaultConfig ProcConfig = ProcConfig{
 	DailyLimit:     25,
 	TopUserDefault: 3,
 	TopUserMax:     10,
+	PairWindow:     24,
+	UndoWindow:     5,
+	Timezone:       "Local",
+	DailyWindow:    WindowDay,
+	GroupPolicy:    PolicySplit,
+	SeasonLength:   SeasonOff,
 }
+
+// daily windows
+const (
+	WindowDay     = "day"
+	WindowRolling = "rolling"
+)
+
+// group policies
+const (
+	PolicySplit     = "split"
+	PolicyReplicate = "replicate"
+)
+
+// season lengths
+const (
+	SeasonOff     = "off"
+	SeasonMonth   = "month"
+	SeasonQuarter = "quarter"
+)
@@ -2,6 +2,7 @@ package karma
 
 import (
 	"testing"
+	"time"
 
 	"github.com/icemanblues/knave-bot/slack"
 	"github.com/stretchr/testify/assert"
@@ -276,6 +277,11 @@ func TestAddSubCmdAlias(t *testing.T) {
 			args:     []string{"0", "USER"},
 			expected: []string{"0", "USER"},
 		},
+		{
+			name:     "several users",
+			args:     []string{"+2", "<@UA>", "<@UB>", "thanks"},
+			expected: []string{"++", "<@UA>", "<@UB>", "2", "thanks"},
+		},
 	}
 
 	for _, test := range testcases {
@@ -328,7 +334,7 @@ func TestProcessStatus(t *testing.T) {
 		},
 		{
 			name:         "status malformed user",
-			command:      command("status blah"),
+			command:      command("status @blah"),
 			responseType: slack.ResponseType.Ephemeral,
 			text:         msgInvalidUser,
 		},
@@ -408,13 +414,25 @@ func TestProcessAdd(t *testing.T) {
 			name:         "++ quantity out-of-bounds",
 			command:      command("++ <@USER> 9000"),
 			responseType: slack.ResponseType.Ephemeral,
-			text:         msgDeltaLimit,
+			text:         MsgDeltaLimit(DefaultConfig.SingleLimit),
 		},
 		{
 			name:         "++ quantity message",
 			command:      command("++ <@USER> thanks you so much"),
 			responseType: slack.ResponseType.InChannel,
-			text:         "<@UCALLER> is giving 1 karma to <@USER>. <@USER> has 2 karma.",
+			text:         "<@UCALLER> is giving 1 karma to <@USER> for thanks you so much. <@USER> has 2 karma.",
+		},
+		{
+			name:         "++ quantity reason",
+			command:      command("++ <@USER> 3 for fixing the build"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 3 karma to <@USER> for fixing the build. <@USER> has 4 karma.",
+		},
+		{
+			name:         "++ reason without for",
+			command:      command("++ <@USER> 2 fixing the build"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 2 karma to <@USER> for fixing the build. <@USER> has 3 karma.",
 		},
 		{
 			name:         "++ quantity negative",
@@ -442,7 +460,7 @@ func TestProcessAdd(t *testing.T) {
 		},
 		{
 			name:         "++ malformed target",
-			command:      command("++ yikes"),
+			command:      command("++ @yikes"),
 			responseType: slack.ResponseType.Ephemeral,
 			text:         msgInvalidUser,
 		},
@@ -472,13 +490,19 @@ func TestProcessSubtract(t *testing.T) {
 			name:         "-- quantity out-of-bounds",
 			command:      command("-- <@USER> 9000"),
 			responseType: slack.ResponseType.Ephemeral,
-			text:         msgDeltaLimit,
+			text:         MsgDeltaLimit(DefaultConfig.SingleLimit),
 		},
 		{
 			name:         "-- quantity message",
 			command:      command("-- <@USER> be better next time"),
 			responseType: slack.ResponseType.InChannel,
-			text:         "<@UCALLER> is taking away 1 karma from <@USER>. <@USER> has 0 karma.",
+			text:         "<@UCALLER> is taking away 1 karma from <@USER> for be better next time. <@USER> has 0 karma.",
+		},
+		{
+			name:         "-- quantity reason",
+			command:      command("-- <@USER> 2 for breaking the build"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is taking away 2 karma from <@USER> for breaking the build. <@USER> has -1 karma.",
 		},
 		{
 			name:         "-- quantity negative",
@@ -506,7 +530,7 @@ func TestProcessSubtract(t *testing.T) {
 		},
 		{
 			name:         "-- malformed target",
-			command:      command("-- yikes"),
+			command:      command("-- @yikes"),
 			responseType: slack.ResponseType.Ephemeral,
 			text:         msgInvalidUser,
 		},
@@ -519,7 +543,7 @@ func TestProcessSubtract(t *testing.T) {
 
 func TestProcessDailyLimit(t *testing.T) {
 	p := fullUsageMockProcessor()
-	msgFull := "Ah ah ah! The daily limit is 25 and you've given/taken 25 karma already. Only 0 remaining"
+	msgFull := "Ah ah ah! The daily limit is 25 and you've given/taken 25 karma already. Only 0 remaining. Try again <!date^1777939200^{date_short_pretty} at {time}|2026-05-05 00:00 UTC> (in 9h)."
 	testcases := []ProcessTestCase{
 		{
 			name:         "--",
@@ -602,13 +626,13 @@ func TestProcessAliasMinusMinus(t *testing.T) {
 			name:         "USER -- quantity out-of-bounds",
 			command:      command("<@USER> -- 9000"),
 			responseType: slack.ResponseType.Ephemeral,
-			text:         msgDeltaLimit,
+			text:         MsgDeltaLimit(DefaultConfig.SingleLimit),
 		},
 		{
 			name:         "USER -- quantity message",
 			command:      command("<@USER> -- 2 be better next time"),
 			responseType: slack.ResponseType.InChannel,
-			text:         "<@UCALLER> is taking away 2 karma from <@USER>. <@USER> has -1 karma.",
+			text:         "<@UCALLER> is taking away 2 karma from <@USER> for be better next time. <@USER> has -1 karma.",
 		},
 	}
 
@@ -692,3 +716,345 @@ func TestProcessError(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMentions(t *testing.T) {
+	testcases := []struct {
+		name     string
+		text     string
+		expected []Mention
+	}{
+		{
+			name:     "no mentions",
+			text:     "just chatting about the build",
+			expected: []Mention{},
+		},
+		{
+			name:     "mention without karma",
+			text:     "hey <@USER> can you look at this?",
+			expected: []Mention{},
+		},
+		{
+			name:     "plus plus",
+			text:     "<@USER> ++",
+			expected: []Mention{{Target: "USER", Delta: 1}},
+		},
+		{
+			name:     "plus plus no space",
+			text:     "<@USER>++ for the fix",
+			expected: []Mention{{Target: "USER", Delta: 1, Reason: "the fix"}},
+		},
+		{
+			name:     "plus plus plus",
+			text:     "<@USER> +++",
+			expected: []Mention{{Target: "USER", Delta: 2}},
+		},
+		{
+			name:     "minus minus",
+			text:     "<@USER|simon> --",
+			expected: []Mention{{Target: "USER", Delta: -1}},
+		},
+		{
+			name:     "colon",
+			text:     "<@USER>: ++++",
+			expected: []Mention{{Target: "USER", Delta: 3}},
+		},
+		{
+			name:     "several",
+			text:     "thanks <@UA> ++ and <@UB> ++ but <@UC> --",
+			expected: []Mention{{Target: "UA", Delta: 1}, {Target: "UB", Delta: 1}, {Target: "UC", Delta: -1}},
+		},
+		{
+			name:     "reasons",
+			text:     "<@UA> ++ for the review <@UB> -- for breaking it",
+			expected: []Mention{{Target: "UA", Delta: 1, Reason: "the review"}, {Target: "UB", Delta: -1, Reason: "breaking it"}},
+		},
+		{
+			name:     "conversation is not a reason",
+			text:     "<@UA> ++ nice work",
+			expected: []Mention{{Target: "UA", Delta: 1}},
+		},
+		{
+			name:     "single plus",
+			text:     "<@USER> +",
+			expected: []Mention{},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual := parseMentions(test.text)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func message(text string) slack.MessageEvent {
+	return slack.MessageEvent{
+		Type: slack.EventMessage,
+		User: "UCALLER",
+		Text: text,
+	}
+}
+
+func TestProcessMessage(t *testing.T) {
+	testcases := []struct {
+		name      string
+		processor SlackProcessor
+		message   slack.MessageEvent
+		expected  []string
+	}{
+		{
+			name:      "no mentions",
+			processor: happyMockProcessor(),
+			message:   message("hello world"),
+			expected:  []string{},
+		},
+		{
+			name:      "plus plus",
+			processor: happyMockProcessor(),
+			message:   message("<@USER> ++"),
+			expected:  []string{"<@UCALLER> is giving 1 karma to <@USER>. <@USER> has 2 karma."},
+		},
+		{
+			name:      "minus minus minus",
+			processor: happyMockProcessor(),
+			message:   message("<@USER> ---"),
+			expected:  []string{"<@UCALLER> is taking away 2 karma from <@USER>. <@USER> has -1 karma."},
+		},
+		{
+			name:      "several",
+			processor: happyMockProcessor(),
+			message:   message("<@UA> ++ <@UB> ++"),
+			expected: []string{
+				"<@UCALLER> is giving 1 karma to <@UA>. <@UA> has 2 karma.",
+				"<@UCALLER> is giving 1 karma to <@UB>. <@UB> has 2 karma.",
+			},
+		},
+		{
+			name:      "self karma",
+			processor: happyMockProcessor(),
+			message:   message("<@UCALLER> ++"),
+			expected:  []string{msgAddSelfTarget},
+		},
+		{
+			name:      "single limit",
+			processor: happyMockProcessor(),
+			message:   message("<@USER> ++++++++"),
+			expected:  []string{MsgDeltaLimit(DefaultConfig.SingleLimit)},
+		},
+		{
+			name:      "daily limit",
+			processor: fullUsageMockProcessor(),
+			message:   message("<@USER> ++"),
+			expected:  []string{"Ah ah ah! The daily limit is 25 and you've given/taken 25 karma already. Only 0 remaining. Try again <!date^1777939200^{date_short_pretty} at {time}|2026-05-05 00:00 UTC> (in 9h)."},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			responses, err := test.processor.ProcessMessage(test.message)
+			assert.Nil(t, err)
+
+			actual := make([]string, 0, len(responses))
+			for _, r := range responses {
+				actual = append(actual, r.Text)
+			}
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := sadMockProcessor().ProcessMessage(message("<@USER> ++"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestProcessTransaction(t *testing.T) {
+	testcases := []struct {
+		name     string
+		text     string
+		expected Transaction
+	}{
+		{
+			name:     "++",
+			text:     "++ <@USER> 2",
+			expected: Transaction{Team: "nycfc", From: "UCALLER", To: "USER", Channel: "CGENERAL", Delta: 2},
+		},
+		{
+			name:     "++ reason",
+			text:     "<@USER> ++ 2 for the code review",
+			expected: Transaction{Team: "nycfc", From: "UCALLER", To: "USER", Channel: "CGENERAL", Delta: 2, Message: "the code review"},
+		},
+		{
+			name:     "--",
+			text:     "-- <@USER> 3",
+			expected: Transaction{Team: "nycfc", From: "UCALLER", To: "USER", Channel: "CGENERAL", Delta: -3},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			var actual Transaction
+			dao := HappyDao()
+			dao.UpdateKarmaDailyMock = func(tx Transaction, date time.Time) (int, error) {
+				actual = tx
+				return tx.Delta, nil
+			}
+
+			c := command(test.text)
+			c.TeamID = "nycfc"
+			c.ChannelID = "CGENERAL"
+			_, err := mockProcessor(dao).Process(c)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestProcessBottom(t *testing.T) {
+	p := happyMockProcessor()
+	testcases := []ProcessTestCase{
+		{
+			name:         "bottom",
+			command:      command("bottom"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "The bottom 3 users by karma:\nRank\tName\tKarma\n1\t<@USER0>\t-100\n2\t<@USER1>\t-99\n3\t<@USER2>\t-98\n",
+		},
+		{
+			name:         "bot",
+			command:      command("bot 2"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "The bottom 2 users by karma:\nRank\tName\tKarma\n1\t<@USER0>\t-100\n2\t<@USER1>\t-99\n",
+		},
+		{
+			name:         "bottom negative",
+			command:      command("bottom -5"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "The bottom 3 users by karma:\nRank\tName\tKarma\n1\t<@USER0>\t-100\n2\t<@USER1>\t-99\n3\t<@USER2>\t-98\n",
+		},
+		{
+			name:         "bottom over max",
+			command:      command("bottom 100"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "The bottom 10 users by karma:\nRank\tName\tKarma\n1\t<@USER0>\t-100\n2\t<@USER1>\t-99\n3\t<@USER2>\t-98\n4\t<@USER3>\t-97\n5\t<@USER4>\t-96\n6\t<@USER5>\t-95\n7\t<@USER6>\t-94\n8\t<@USER7>\t-93\n9\t<@USER8>\t-92\n10\t<@USER9>\t-91\n",
+		},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, p, test)
+	}
+
+	dao := HappyDao()
+	dao.BottomMock = func(team string, n int) ([]UserKarma, error) {
+		return []UserKarma{}, nil
+	}
+	processHelper(t, mockProcessor(dao), ProcessTestCase{
+		name:         "bottom empty",
+		command:      command("bottom"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         msgNoKarmaForBottom,
+	})
+}
+
+func TestParseReason(t *testing.T) {
+	testcases := []struct {
+		name     string
+		words    []string
+		idx      int
+		expected string
+	}{
+		{"nothing", []string{"++", "USER", "3"}, 3, ""},
+		{"negative", []string{"++", "USER", "3"}, -1, ""},
+		{"reason", []string{"++", "USER", "3", "fixing", "the", "build"}, 3, "fixing the build"},
+		{"for reason", []string{"++", "USER", "3", "for", "fixing", "the", "build"}, 3, "fixing the build"},
+		{"For reason", []string{"++", "USER", "For", "lunch"}, 2, "lunch"},
+		{"just for", []string{"++", "USER", "for"}, 2, ""},
+		{"forward", []string{"++", "USER", "forward", "thinking"}, 2, "forward thinking"},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual := parseReason(test.words, test.idx)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestProcessWhy(t *testing.T) {
+	p := happyMockProcessor()
+	testcases := []ProcessTestCase{
+		{
+			name:         "why",
+			command:      command("why <@USER>"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "The recent reasons for <@USER>'s karma:\n+1 from <@USER> for reason 0 (2019-11-09)\n+1 from <@USER> for reason 1 (2019-11-09)\n+1 from <@USER> for reason 2 (2019-11-09)\n",
+		},
+		{
+			name:         "why 1",
+			command:      command("<@USER> why 1"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "The recent reasons for <@USER>'s karma:\n+1 from <@USER> for reason 0 (2019-11-09)\n",
+		},
+		{
+			name:         "why no user",
+			command:      command("why"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgMissingName,
+		},
+		{
+			name:         "why malformed user",
+			command:      command("why blah"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgInvalidUser,
+		},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, p, test)
+	}
+
+	dao := HappyDao()
+	dao.ReasonsMock = func(team, user string, n int) ([]Transaction, error) {
+		return []Transaction{}, nil
+	}
+	processHelper(t, mockProcessor(dao), ProcessTestCase{
+		name:         "why nobody said",
+		command:      command("why <@USER>"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         "Nobody has given a reason for <@USER>'s karma. Yet.",
+	})
+
+	// as many reasons as a leaderboard has people
+	var sizes []int
+	dao.ReasonsMock = func(team, user string, n int) ([]Transaction, error) {
+		sizes = append(sizes, n)
+		return nil, nil
+	}
+	for _, text := range []string{"why <@USER>", "why <@USER> 4", "why <@USER> -2", "why <@USER> 500"} {
+		_, err := mockProcessor(dao).Process(command(text))
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, []int{DefaultConfig.TopUserDefault, 4, DefaultConfig.TopUserDefault, DefaultConfig.TopUserMax}, sizes)
+}
+
+func TestProcConfigValidate(t *testing.T) {
+	testcases := []struct {
+		name   string
+		config ProcConfig
+		valid  bool
+	}{
+		{"default", DefaultConfig, true},
+		{"single limit zero", ProcConfig{SingleLimit: 0, DailyLimit: 25, TopUserDefault: 3, TopUserMax: 10}, false},
+		{"daily below single", ProcConfig{SingleLimit: 5, DailyLimit: 4, TopUserDefault: 3, TopUserMax: 10}, false},
+		{"top default zero", ProcConfig{SingleLimit: 5, DailyLimit: 25, TopUserDefault: 0, TopUserMax: 10}, false},
+		{"top max below default", ProcConfig{SingleLimit: 5, DailyLimit: 25, TopUserDefault: 3, TopUserMax: 2}, false},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			assert.Equal(t, test.valid, err == nil, err)
+		})
+	}
+}
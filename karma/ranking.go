@@ -0,0 +1,26 @@
+package karma
+
+// Ranking a user's place on a leaderboard
+// Users with the same karma share a rank (1, 2, 2, 4) and are marked as tied
+type Ranking struct {
+	Rank  int    `json:"rank"`
+	User  string `json:"user"`
+	Karma int    `json:"karma"`
+	Tied  bool   `json:"tied"`
+}
+
+// Rankings ranks an ordered leaderboard
+func Rankings(users []UserKarma) []Ranking {
+	rankings := make([]Ranking, 0, len(users))
+	for i, u := range users {
+		r := Ranking{Rank: i + 1, User: u.User, Karma: u.Karma}
+		if i > 0 && users[i-1].Karma == u.Karma {
+			r.Rank = rankings[i-1].Rank
+			r.Tied = true
+			rankings[i-1].Tied = true
+		}
+		rankings = append(rankings, r)
+	}
+
+	return rankings
+}
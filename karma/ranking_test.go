@@ -0,0 +1,57 @@
+package karma
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankings(t *testing.T) {
+	testcases := []struct {
+		name     string
+		users    []UserKarma
+		expected []Ranking
+	}{
+		{
+			name:     "empty",
+			users:    nil,
+			expected: []Ranking{},
+		},
+		{
+			name:  "no ties",
+			users: []UserKarma{{"UA", 10}, {"UB", 5}, {"UC", 1}},
+			expected: []Ranking{
+				{Rank: 1, User: "UA", Karma: 10},
+				{Rank: 2, User: "UB", Karma: 5},
+				{Rank: 3, User: "UC", Karma: 1},
+			},
+		},
+		{
+			name:  "tie in the middle",
+			users: []UserKarma{{"UA", 10}, {"UB", 5}, {"UC", 5}, {"UD", 1}},
+			expected: []Ranking{
+				{Rank: 1, User: "UA", Karma: 10},
+				{Rank: 2, User: "UB", Karma: 5, Tied: true},
+				{Rank: 2, User: "UC", Karma: 5, Tied: true},
+				{Rank: 4, User: "UD", Karma: 1},
+			},
+		},
+		{
+			name:  "three way tie for first",
+			users: []UserKarma{{"UA", -1}, {"UB", -1}, {"UC", -1}, {"UD", 3}},
+			expected: []Ranking{
+				{Rank: 1, User: "UA", Karma: -1, Tied: true},
+				{Rank: 1, User: "UB", Karma: -1, Tied: true},
+				{Rank: 1, User: "UC", Karma: -1, Tied: true},
+				{Rank: 4, User: "UD", Karma: 3},
+			},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual := Rankings(test.users)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
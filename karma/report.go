@@ -0,0 +1,188 @@
+package karma
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// report periods
+const (
+	week    = "week"
+	month   = "month"
+	quarter = "quarter"
+	year    = "year"
+	last    = "last"
+)
+
+// Period a span of time [From, To) that a report covers
+// Name is empty for periods given as explicit dates
+type Period struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// Dates the first and last day of the period, both inclusive
+func (p Period) Dates() string {
+	return fmt.Sprintf("%v to %v", IsoDate(p.From), IsoDate(p.To.Add(-time.Nanosecond)))
+}
+
+// String the period's name, or its dates when it doesn't have one
+func (p Period) String() string {
+	if p.Name == "" {
+		return p.Dates()
+	}
+	return p.Name
+}
+
+// startOf the beginning of the calendar week (monday), month, quarter or year that t falls in
+func startOf(unit string, t time.Time) (time.Time, bool) {
+	y, m, d := t.Date()
+	switch unit {
+	case week:
+		monday := d - (int(t.Weekday())+6)%7
+		return time.Date(y, m, monday, 0, 0, 0, 0, t.Location()), true
+	case month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location()), true
+	case quarter:
+		q := time.Month((int(m)-1)/3*3 + 1)
+		return time.Date(y, q, 1, 0, 0, 0, 0, t.Location()), true
+	case year:
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, t.Location()), true
+	}
+
+	return time.Time{}, false
+}
+
+// previous steps back one week, month, quarter or year
+func previous(unit string, t time.Time) time.Time {
+	switch unit {
+	case week:
+		return t.AddDate(0, 0, -7)
+	case month:
+		return t.AddDate(0, -1, 0)
+	case quarter:
+		return t.AddDate(0, -3, 0)
+	}
+
+	return t.AddDate(-1, 0, 0)
+}
+
+// parsePeriod turns report arguments into a Period. No arguments is this month so far.
+// `week|month|quarter|year` the current calendar period up to now
+// `last week|month|quarter|year` the previous full calendar period
+// `2006-01-02 [2006-01-02]` explicit dates, both inclusive. The end defaults to now
+func parsePeriod(args []string, now time.Time) (Period, bool) {
+	if len(args) == 0 {
+		args = []string{month}
+	}
+
+	if args[0] == last {
+		unit, ok := parseArg(args, 1)
+		if !ok {
+			return Period{}, false
+		}
+		end, ok := startOf(unit, now)
+		if !ok {
+			return Period{}, false
+		}
+		return Period{Name: "last " + unit, From: previous(unit, end), To: end}, true
+	}
+
+	if start, ok := startOf(args[0], now); ok {
+		return Period{Name: "this " + args[0], From: start, To: now}, true
+	}
+
+	from, err := time.ParseInLocation(isoDate, args[0], now.Location())
+	if err != nil {
+		return Period{}, false
+	}
+
+	to := now
+	if end, ok := parseArg(args, 1); ok {
+		t, err := time.ParseInLocation(isoDate, end, now.Location())
+		if err != nil {
+			return Period{}, false
+		}
+		// the end date is inclusive
+		to = t.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return Period{}, false
+	}
+
+	return Period{From: from, To: to}, true
+}
+
+func (p SlackProcessor) report(team string, words []string) (slack.Response, error) {
+	period, ok := parsePeriod(words[1:], p.teamNow())
+	if !ok {
+		return slack.DirectResponse(msgReportInvalidPeriod, cmdReport), nil
+	}
+
+	r, err := p.dao.Report(team, period.From, period.To, p.config.TopUserDefault)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	if r.Transactions == 0 {
+		return slack.DirectResponse(MsgReportEmpty(period), ""), nil
+	}
+
+	return ResponseReport(period, r), nil
+}
+
+// ResponseReport formats a karma report as a slack response, one field per ranking
+func ResponseReport(period Period, r Report) slack.Response {
+	title := MsgReportTitle(period)
+
+	gainers := &strings.Builder{}
+	for i, u := range r.Gainers {
+		gainers.WriteString(fmt.Sprintf("%v. <@%v> %+d\n", i+1, u.User, u.Karma))
+	}
+
+	givers := &strings.Builder{}
+	for i, u := range r.Givers {
+		givers.WriteString(fmt.Sprintf("%v. <@%v> %v\n", i+1, u.User, u.Karma))
+	}
+
+	channels := &strings.Builder{}
+	for i, c := range r.Channels {
+		channels.WriteString(fmt.Sprintf("%v. <#%v> %v transactions\n", i+1, c.Channel, c.Transactions))
+	}
+
+	return slack.Response{
+		ResponseType: slack.ResponseType.InChannel,
+		Text:         title,
+		Attachments: []slack.Attachments{
+			{
+				Fallback: title,
+				Fields: []slack.Field{
+					{
+						Title: "Biggest gainers",
+						Value: strings.TrimSpace(gainers.String()),
+						Short: true,
+					},
+					{
+						Title: "Most generous",
+						Value: strings.TrimSpace(givers.String()),
+						Short: true,
+					},
+					{
+						Title: "Most active channels",
+						Value: strings.TrimSpace(channels.String()),
+						Short: true,
+					},
+					{
+						Title: "Karma moved",
+						Value: MsgReportMoved(r),
+						Short: true,
+					},
+				},
+			},
+		},
+	}
+}
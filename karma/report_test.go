@@ -0,0 +1,103 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+// Saturday, October 17th 2026
+var reportNow = time.Date(2026, time.October, 17, 15, 30, 0, 0, time.UTC)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParsePeriod(t *testing.T) {
+	testcases := []struct {
+		name     string
+		args     []string
+		expected Period
+		ok       bool
+	}{
+		{"default", nil, Period{"this month", day(2026, time.October, 1), reportNow}, true},
+		{"week", []string{"week"}, Period{"this week", day(2026, time.October, 12), reportNow}, true},
+		{"month", []string{"month"}, Period{"this month", day(2026, time.October, 1), reportNow}, true},
+		{"quarter", []string{"quarter"}, Period{"this quarter", day(2026, time.October, 1), reportNow}, true},
+		{"year", []string{"year"}, Period{"this year", day(2026, time.January, 1), reportNow}, true},
+		{"last week", []string{"last", "week"}, Period{"last week", day(2026, time.October, 5), day(2026, time.October, 12)}, true},
+		{"last month", []string{"last", "month"}, Period{"last month", day(2026, time.September, 1), day(2026, time.October, 1)}, true},
+		{"last quarter", []string{"last", "quarter"}, Period{"last quarter", day(2026, time.July, 1), day(2026, time.October, 1)}, true},
+		{"last year", []string{"last", "year"}, Period{"last year", day(2025, time.January, 1), day(2026, time.January, 1)}, true},
+		{"dates", []string{"2026-01-01", "2026-03-31"}, Period{"", day(2026, time.January, 1), day(2026, time.April, 1)}, true},
+		{"start date", []string{"2026-10-01"}, Period{"", day(2026, time.October, 1), reportNow}, true},
+		{"last nothing", []string{"last"}, Period{}, false},
+		{"last fortnight", []string{"last", "fortnight"}, Period{}, false},
+		{"gibberish", []string{"forever"}, Period{}, false},
+		{"bad end date", []string{"2026-01-01", "march"}, Period{}, false},
+		{"backwards", []string{"2026-03-31", "2026-01-01"}, Period{}, false},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok := parsePeriod(test.args, reportNow)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestReportTitle(t *testing.T) {
+	named := Period{"last month", day(2026, time.September, 1), day(2026, time.October, 1)}
+	assert.Equal(t, "Karma report for last month (2026-09-01 to 2026-09-30)", MsgReportTitle(named))
+
+	dates := Period{"", day(2026, time.January, 1), day(2026, time.April, 1)}
+	assert.Equal(t, "Karma report for 2026-01-01 to 2026-03-31", MsgReportTitle(dates))
+}
+
+func TestProcessReport(t *testing.T) {
+	p := happyMockProcessor()
+
+	actual, err := p.Process(command("report last month"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, slack.ResponseType.InChannel, actual.ResponseType)
+	assert.Contains(t, actual.Text, "Karma report for last month (")
+	assert.Len(t, actual.Attachments, 1)
+	assert.Equal(t, []slack.Field{
+		{Title: "Biggest gainers", Value: "1. <@USER0> +10\n2. <@USER1> +4", Short: true},
+		{Title: "Most generous", Value: "1. <@USER2> 8\n2. <@USER0> 6", Short: true},
+		{Title: "Most active channels", Value: "1. <#CGENERAL> 7 transactions", Short: true},
+		{Title: "Karma moved", Value: "+10 net, 16 moved in 9 transactions", Short: true},
+	}, actual.Attachments[0].Fields)
+}
+
+func TestProcessReportInvalid(t *testing.T) {
+	processHelper(t, happyMockProcessor(), ProcessTestCase{
+		name:         "report forever",
+		command:      command("report forever"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         msgReportInvalidPeriod,
+	})
+}
+
+func TestProcessReportEmpty(t *testing.T) {
+	dao := HappyDao()
+	dao.ReportMock = func(team string, from, to time.Time, n int) (Report, error) {
+		return Report{Team: team, From: from, To: to}, nil
+	}
+
+	processHelper(t, mockProcessor(dao), ProcessTestCase{
+		name:         "report empty",
+		command:      command("report week"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         "No karma was given or taken during this week. Get out there and appreciate someone!",
+	})
+}
+
+func TestProcessReportError(t *testing.T) {
+	_, err := sadMockProcessor().Process(command("report"))
+	assert.NotNil(t, err)
+}
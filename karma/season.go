@@ -0,0 +1,245 @@
+package karma
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// season sub-commands, and the leaderboards of `/karma top`
+const (
+	seasonStart  = "start"
+	seasonEnd    = "end"
+	seasonPrefix = "season:"
+	allTime      = "all"
+)
+
+// maxRolloverNames how far a rollover counts up, 2026Q3-2, 2026Q3-3..., looking for a name no admin took
+const maxRolloverNames = 10
+
+// seasonNameRegex names like 2026Q2, 2026-04 or summer_of_code
+var seasonNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,31}$`)
+
+// season `/karma season [start [name]|end]`. Anyone can see the season, only admins start and end it
+func (p SlackProcessor) season(team, callee string, words []string) (slack.Response, error) {
+	sub, ok := parseArg(words, 1)
+	if !ok {
+		return p.seasonStatus(team)
+	}
+	if sub != seasonStart && sub != seasonEnd {
+		return slack.DirectResponse(msgSeasonUsage, cmdSeason), nil
+	}
+
+	allowed, err := p.IsAdmin(team, callee)
+	if err != nil {
+		return slack.Response{}, err
+	}
+	if !allowed {
+		return slack.ErrorResponse(msgNotAdmin), nil
+	}
+
+	now := p.teamNow()
+	if sub == seasonStart {
+		// named after the month or quarter by default
+		name, ok := parseArg(words, 2)
+		if !ok {
+			start, _ := seasonPeriod(now, p.config.SeasonLength)
+			name = seasonName(start, p.config.SeasonLength)
+		}
+		if !seasonNameRegex.MatchString(name) || name == allTime {
+			return slack.DirectResponse(msgSeasonName, cmdSeason), nil
+		}
+
+		s, err := p.dao.StartSeason(team, name, now)
+		switch {
+		case errors.Is(err, ErrSeasonRunning):
+			return slack.ErrorResponse(msgSeasonAlreadyRunning), nil
+		case errors.Is(err, ErrSeasonExists):
+			return slack.ErrorResponse(MsgSeasonExists(name)), nil
+		case err != nil:
+			return slack.Response{}, err
+		}
+		return slack.ChannelResponse(MsgSeasonStarted(callee, s.Name)), nil
+	}
+
+	s, err := p.dao.EndSeason(team, now)
+	if errors.Is(err, ErrNoSeason) {
+		return slack.ErrorResponse(msgNoSeason), nil
+	}
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	top, err := p.dao.ArchivedTop(team, s.Name, p.config.TopUserDefault)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	msg.WriteString(MsgSeasonEnded(callee, s.Name))
+	if len(top) > 0 {
+		msg.WriteString(MsgTopSeason(s.Name, top))
+		att.WriteString(p.compliment.Sentence())
+	}
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
+
+// seasonStatus the running season, its leaderboard so far and the seasons before it
+func (p SlackProcessor) seasonStatus(team string) (slack.Response, error) {
+	seasons, err := p.dao.Seasons(team)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	var current *Season
+	past := make([]string, 0, len(seasons))
+	for i, s := range seasons {
+		if s.EndedAt == nil {
+			current = &seasons[i]
+			continue
+		}
+		past = append(past, s.Name)
+	}
+
+	msg := &strings.Builder{}
+	if current == nil {
+		msg.WriteString(msgNoSeason)
+		msg.WriteString("\n")
+	} else {
+		now := p.teamNow()
+		msg.WriteString(MsgSeasonRunning(current.Name, current.StartedAt.In(now.Location())))
+		if p.config.SeasonLength != SeasonOff {
+			_, end := seasonPeriod(current.StartedAt.In(now.Location()), p.config.SeasonLength)
+			msg.WriteString(MsgSeasonEnds(end, now))
+		}
+		msg.WriteString("\n")
+
+		top, err := p.dao.SeasonTop(team, current.StartedAt, p.config.TopUserDefault)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		if len(top) > 0 {
+			msg.WriteString(MsgTopSeason(current.Name, top))
+		}
+	}
+	if len(past) > 0 {
+		msg.WriteString(MsgPastSeasons(past))
+	}
+
+	return slack.DirectResponse(msg.String(), ""), nil
+}
+
+// topSeason the leaderboard of a season by name: so far when it is running, its final standings when it has ended
+func (p SlackProcessor) topSeason(team, name string, n int) (slack.Response, error) {
+	s, err := p.dao.GetSeason(team, name)
+	if errors.Is(err, ErrNoSeason) {
+		return slack.DirectResponse(MsgUnknownSeason(name), cmdSeason), nil
+	}
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	return p.seasonLeaderboard(s, n)
+}
+
+// seasonLeaderboard the top n users of a season
+func (p SlackProcessor) seasonLeaderboard(s Season, n int) (slack.Response, error) {
+	var top []UserKarma
+	var err error
+	if s.EndedAt == nil {
+		top, err = p.dao.SeasonTop(s.Team, s.StartedAt, n)
+	} else {
+		top, err = p.dao.ArchivedTop(s.Team, s.Name, n)
+	}
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	if len(top) == 0 {
+		return slack.DirectResponse(msgNoKarmaForTop, ""), nil
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	msg.WriteString(MsgTopSeason(s.Name, top))
+	att.WriteString(p.compliment.Sentence())
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
+
+// RolloverSeasons ends every running season whose month or quarter is over, and starts the next one when it ended,
+// for the teams with a season_length. A server that was down for a while catches up a season at a time.
+// Returns the seasons that were started
+func (p SlackProcessor) RolloverSeasons() ([]Season, error) {
+	running, err := p.dao.RunningSeasons()
+	if err != nil {
+		return nil, err
+	}
+
+	var started []Season
+	var errs []error
+	for _, s := range running {
+		cfg, _, err := p.TeamConfig(s.Team)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("team %v: %w", s.Team, err))
+			continue
+		}
+		if cfg.SeasonLength == SeasonOff {
+			continue
+		}
+
+		now := p.now().In(cfg.Location())
+		for {
+			_, end := seasonPeriod(s.StartedAt.In(cfg.Location()), cfg.SeasonLength)
+			if now.Before(end) {
+				break
+			}
+
+			next, err := p.rollover(s.Team, seasonName(end, cfg.SeasonLength), end)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("team %v: %w", s.Team, err))
+				break
+			}
+			started = append(started, next)
+			s = next
+		}
+	}
+
+	return started, errors.Join(errs...)
+}
+
+// rollover rolls the team's season over to the next one. When an admin already took its name, it is 2026Q3-2, then 2026Q3-3
+func (p SlackProcessor) rollover(team, name string, at time.Time) (Season, error) {
+	next := name
+	for i := 2; ; i++ {
+		s, err := p.dao.RolloverSeason(team, next, at)
+		if !errors.Is(err, ErrSeasonExists) || i > maxRolloverNames {
+			return s, err
+		}
+		next = fmt.Sprintf("%v-%v", name, i)
+	}
+}
+
+// seasonPeriod the month, or quarter, that t is in. A season that isn't rolled over is a quarter
+func seasonPeriod(t time.Time, length string) (time.Time, time.Time) {
+	y, m, _ := t.Date()
+	if length != SeasonMonth {
+		m -= (m - 1) % 3
+	}
+	start := time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+
+	if length == SeasonMonth {
+		return start, start.AddDate(0, 1, 0)
+	}
+	return start, start.AddDate(0, 3, 0)
+}
+
+// seasonName the name of the season starting at the start of a month (2026-04) or quarter (2026Q2)
+func seasonName(start time.Time, length string) string {
+	if length == SeasonMonth {
+		return start.Format("2006-01")
+	}
+	return fmt.Sprintf("%vQ%v", start.Year(), (int(start.Month())-1)/3+1)
+}
@@ -0,0 +1,33 @@
+package karma
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SeasonCheckInterval how often the server looks for seasons to roll over
+const SeasonCheckInterval = 5 * time.Minute
+
+// RunSeasonRollover rolls seasons over, now and then every interval, until the context is done
+func RunSeasonRollover(ctx context.Context, p SlackProcessor, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		started, err := p.RolloverSeasons()
+		if err != nil {
+			log.Errorf("Unable to roll over every season %v", err)
+		}
+		for _, s := range started {
+			log.Infof("Started season %v for team %v", s.Name, s.Team)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
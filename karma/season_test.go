@@ -0,0 +1,250 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeasonPeriod(t *testing.T) {
+	testcases := []struct {
+		name   string
+		t      time.Time
+		length string
+		start  time.Time
+		end    time.Time
+		season string
+	}{
+		{"quarter", mockNow, SeasonQuarter, time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC), "2026Q2"},
+		{"last quarter", time.Date(2026, time.December, 31, 23, 0, 0, 0, time.UTC), SeasonQuarter, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC), "2026Q4"},
+		{"month", mockNow, SeasonMonth, time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC), "2026-05"},
+		{"off is a quarter", time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), SeasonOff, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC), "2026Q1"},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := seasonPeriod(test.t, test.length)
+			assert.Equal(t, test.start, start)
+			assert.Equal(t, test.end, end)
+			assert.Equal(t, test.season, seasonName(start, test.length))
+		})
+	}
+}
+
+// runningSeason the happy mock dao, with 2026Q2 running since the start of the quarter
+func runningSeason() MockDAO {
+	dao := HappyDao()
+	current := Season{ID: 2, Name: "2026Q2", StartedAt: mockSeasonEnd}
+	dao.CurrentSeasonMock = func(team string) (Season, error) {
+		current.Team = team
+		return current, nil
+	}
+	dao.SeasonsMock = func(team string) ([]Season, error) {
+		current.Team = team
+		return []Season{current, mockSeason}, nil
+	}
+	dao.StartSeasonMock = func(team, name string, at time.Time) (Season, error) {
+		return Season{}, ErrSeasonRunning
+	}
+	return dao
+}
+
+func TestProcessSeason(t *testing.T) {
+	happy := happyMockProcessor()
+	running := mockProcessor(runningSeason())
+	quarterly := mockProcessor(runningSeason())
+	quarterly.defaults.SeasonLength = SeasonQuarter
+
+	testcases := []struct {
+		ProcessTestCase
+		p SlackProcessor
+	}{
+		{ProcessTestCase{
+			name:         "no season",
+			command:      command("season"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgNoSeason + "\nPast seasons: 2026Q1",
+		}, happy},
+		{ProcessTestCase{
+			name:         "running",
+			command:      command("season"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "Season *2026Q2* has been running since 2026-04-01.\nThe top 3 users by karma in season *2026Q2*:\nRank\tName\tKarma\n1\t<@USER0>\t3\n2\t<@USER1>\t2\n3\t<@USER2>\t1\nPast seasons: 2026Q1",
+		}, running},
+		{ProcessTestCase{
+			name:         "rolls over",
+			command:      command("season"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "Season *2026Q2* has been running since 2026-04-01. It ends " + msgWhen(time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC), mockNow) + ".\nThe top 3 users by karma in season *2026Q2*:\nRank\tName\tKarma\n1\t<@USER0>\t3\n2\t<@USER1>\t2\n3\t<@USER2>\t1\nPast seasons: 2026Q1",
+		}, quarterly},
+		{ProcessTestCase{
+			name:         "start",
+			command:      adminCommand("season start summer"),
+			responseType: slack.ResponseType.InChannel,
+			text:         MsgSeasonStarted("UADMIN", "summer"),
+		}, happy},
+		{ProcessTestCase{
+			name:         "start named after the quarter",
+			command:      adminCommand("season start"),
+			responseType: slack.ResponseType.InChannel,
+			text:         MsgSeasonStarted("UADMIN", "2026Q2"),
+		}, happy},
+		{ProcessTestCase{
+			name:         "start a bad name",
+			command:      adminCommand("season start all"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgSeasonName,
+		}, happy},
+		{ProcessTestCase{
+			name:         "start while running",
+			command:      adminCommand("season start summer"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgSeasonAlreadyRunning,
+		}, running},
+		{ProcessTestCase{
+			name:         "end",
+			command:      adminCommand("season end"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UADMIN> has ended season *2026Q2*.\nThe top 3 users by karma in season *2026Q2*:\nRank\tName\tKarma\n1\t<@USER0>\t30\n2\t<@USER1>\t20\n3\t<@USER2>\t10\n",
+			attach:       true,
+		}, happy},
+		{ProcessTestCase{
+			name:         "not an admin",
+			command:      command("season end"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgNotAdmin,
+		}, happy},
+		{ProcessTestCase{
+			name:         "usage",
+			command:      command("season nope"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgSeasonUsage,
+		}, happy},
+		{ProcessTestCase{
+			name:         "top this season",
+			command:      command("top 2"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "The top 2 users by karma in season *2026Q2*:\nRank\tName\tKarma\n1\t<@USER0>\t2\n2\t<@USER1>\t1\n",
+			attach:       true,
+		}, running},
+		{ProcessTestCase{
+			name:         "top all time",
+			command:      command("top all 2"),
+			responseType: slack.ResponseType.InChannel,
+			text:         MsgTopKarma([]UserKarma{{"USER0", 100}, {"USER1", 101}}),
+			attach:       true,
+		}, running},
+		{ProcessTestCase{
+			name:         "top of an old season",
+			command:      command("top season:2026Q1 1"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "The top 1 users by karma in season *2026Q1*:\nRank\tName\tKarma\n1\t<@USER0>\t10\n",
+			attach:       true,
+		}, happy},
+		{ProcessTestCase{
+			name:         "top of an unknown season",
+			command:      command("top season:1999Q1"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgUnknownSeason("1999Q1"),
+		}, happy},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, test.p, test.ProcessTestCase)
+	}
+
+	ended := HappyDao()
+	ended.EndSeasonMock = func(team string, at time.Time) (Season, error) {
+		return Season{}, ErrNoSeason
+	}
+	processHelper(t, mockProcessor(ended), ProcessTestCase{
+		name:         "end without a season",
+		command:      adminCommand("season end"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         msgNoSeason,
+	})
+
+	_, err := sadMockProcessor().Process(command("top"))
+	assert.NotNil(t, err)
+}
+
+func TestRolloverSeasons(t *testing.T) {
+	type rollover struct {
+		team, next string
+		at         time.Time
+	}
+	var rolled []rollover
+
+	dao := HappyDao()
+	dao.RunningSeasonsMock = func() ([]Season, error) {
+		return []Season{
+			// two quarters behind
+			{ID: 1, Team: "TLATE", Name: "2025Q4", StartedAt: time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: 2, Team: "TCURRENT", Name: "2026Q2", StartedAt: time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: 3, Team: "TOFF", Name: "forever", StartedAt: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		}, nil
+	}
+	dao.TeamConfigMock = func(team string) (map[string]string, error) {
+		if team == "TOFF" {
+			return map[string]string{}, nil
+		}
+		return map[string]string{settingSeasonLength: SeasonQuarter}, nil
+	}
+	dao.RolloverSeasonMock = func(team, next string, at time.Time) (Season, error) {
+		rolled = append(rolled, rollover{team, next, at})
+		return Season{Team: team, Name: next, StartedAt: at}, nil
+	}
+
+	started, err := mockProcessor(dao).RolloverSeasons()
+	assert.Nil(t, err)
+	assert.Len(t, started, 2)
+	assert.Equal(t, []rollover{
+		{"TLATE", "2026Q1", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"TLATE", "2026Q2", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+	}, rolled)
+
+	_, err = sadMockProcessor().RolloverSeasons()
+	assert.NotNil(t, err)
+}
+
+func TestRolloverSeasonsNameTaken(t *testing.T) {
+	var tried []string
+	taken := map[string]bool{"2026Q2": true, "2026Q2-2": true}
+
+	dao := HappyDao()
+	dao.RunningSeasonsMock = func() ([]Season, error) {
+		return []Season{{ID: 1, Team: "TEAM", Name: "2026Q1", StartedAt: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)}}, nil
+	}
+	dao.TeamConfigMock = func(team string) (map[string]string, error) {
+		return map[string]string{settingSeasonLength: SeasonQuarter}, nil
+	}
+	dao.RolloverSeasonMock = func(team, next string, at time.Time) (Season, error) {
+		tried = append(tried, next)
+		if taken[next] {
+			return Season{}, ErrSeasonExists
+		}
+		return Season{Team: team, Name: next, StartedAt: at}, nil
+	}
+	p := mockProcessor(dao)
+
+	// an admin already started a 2026Q2, so it is the next free name
+	started, err := p.RolloverSeasons()
+	assert.Nil(t, err)
+	if assert.Len(t, started, 1) {
+		assert.Equal(t, "2026Q2-3", started[0].Name)
+	}
+	assert.Equal(t, []string{"2026Q2", "2026Q2-2", "2026Q2-3"}, tried)
+
+	// it gives up eventually, rather than counting forever
+	tried = nil
+	dao.RolloverSeasonMock = func(team, next string, at time.Time) (Season, error) {
+		tried = append(tried, next)
+		return Season{}, ErrSeasonExists
+	}
+	p.dao = dao
+	_, err = p.RolloverSeasons()
+	assert.ErrorIs(t, err, ErrSeasonExists)
+	assert.Len(t, tried, maxRolloverNames)
+}
@@ -8,14 +8,14 @@ import (
 	_ "github.com/mattn/go-sqlite3" // sqlite db driver
 )
 
-// InitDB initializes the db and tables that we require
+// InitDB initializes the db and migrates it to the latest schema
 func InitDB(dataSourceName string) (*sql.DB, error) {
 	db, err := createDB(dataSourceName)
 	if err != nil {
 		return nil, err
 	}
 
-	err = schema(db)
+	err = Migrate(db, DriverSQLite)
 	if err != nil {
 		return nil, err
 	}
@@ -31,81 +31,10 @@ func createDB(dataSourceName string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("sqlite3", dataSourceName)
+	db, err := sql.Open(DriverSQLite, dataSourceName)
 	if err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
-
-func schema(db *sql.DB) error {
-	// karma table
-	if err := schemaKarma(db); err != nil {
-		return err
-	}
-
-	// usage table
-	if err := schemaUsage(db); err != nil {
-		return err
-	}
-
-	// daily usage table
-	if err := schemaDailyUsage(db); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func schemaKarma(db *sql.DB) error {
-	_, err := db.Exec(`
-	CREATE TABLE IF NOT EXISTS karma (
-		id			INTEGER PRIMARY KEY, 
-		team		TEXT, 
-		user		TEXT,
-		karma 		INTEGER,
-		created_at	TEXT,
-		updated_at	TEXT
-	);
-	CREATE UNIQUE INDEX IF NOT EXISTS idx_karma_team_user ON karma (team, user);
-	`)
-
-	return err
-}
-
-func schemaUsage(db *sql.DB) error {
-	_, err := db.Exec(`
-	CREATE TABLE IF NOT EXISTS usage (
-		command			TEXT,
-		text			TEXT,
-		enterprise		TEXT,
-		team			TEXT,
-		channel			TEXT,
-		user			TEXT,
-		created_at		TEXT,
-		response		TEXT,
-		response_type	TEXT,
-		attachments 	TEXT
-	);
-	`)
-
-	return err
-}
-
-func schemaDailyUsage(db *sql.DB) error {
-	_, err := db.Exec(`
-	CREATE TABLE IF NOT EXISTS daily_usage (
-		team		TEXT, 
-		user		TEXT,
-		daily		TEXT,
-		usage 		INTEGER,
-		created_at	TEXT,
-		updated_at	TEXT,
-		PRIMARY KEY (team, user, daily)
-	);
-	CREATE UNIQUE INDEX IF NOT EXISTS idx_daily_usage_team_user_daily ON daily_usage (team, user, daily);
-	`)
-
-	return err
-}
@@ -0,0 +1,266 @@
+package karma
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icemanblues/knave-bot/slack"
+	log "github.com/sirupsen/logrus"
+)
+
+// per team settings, named after their config file keys
+const (
+	settingSingleLimit    = "single_limit"
+	settingDailyLimit     = "daily_limit"
+	settingTopUserDefault = "top_user_default"
+	settingTopUserMax     = "top_user_max"
+	settingPairCooldown   = "pair_cooldown_minutes"
+	settingPairLimit      = "pair_limit"
+	settingPairWindow     = "pair_window_hours"
+	settingRecipientLimit = "recipient_daily_limit"
+	settingUndoWindow     = "undo_window_minutes"
+	settingTimezone       = "timezone"
+	settingDailyWindow    = "daily_window"
+	settingGroupPolicy    = "group_policy"
+	settingSeasonLength   = "season_length"
+)
+
+// Settings the ProcConfig fields that a team can override, in display order
+var Settings = []string{
+	settingSingleLimit,
+	settingDailyLimit,
+	settingTopUserDefault,
+	settingTopUserMax,
+	settingPairCooldown,
+	settingPairLimit,
+	settingPairWindow,
+	settingRecipientLimit,
+	settingUndoWindow,
+	settingTimezone,
+	settingDailyWindow,
+	settingGroupPolicy,
+	settingSeasonLength,
+}
+
+// config sub-commands
+const (
+	configSet     = "set"
+	configReset   = "reset"
+	configHistory = "history"
+)
+
+// ErrUnknownSetting the setting does not exist, or cannot be changed per team
+var ErrUnknownSetting = errors.New("unknown setting")
+
+// ErrInvalidConfig the change would leave a team with settings that don't make sense
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Setting the value of a per team setting, by name
+func (c ProcConfig) Setting(name string) (string, bool) {
+	if s := c.stringPtr(name); s != nil {
+		return *s, true
+	}
+
+	p := c.settingPtr(name)
+	if p == nil {
+		return "", false
+	}
+	return strconv.Itoa(*p), true
+}
+
+// Set changes a per team setting, by name
+func (c *ProcConfig) Set(name, value string) error {
+	// strings are checked by Validate, like the rest of the config
+	if s := c.stringPtr(name); s != nil {
+		*s = value
+		return nil
+	}
+
+	p := c.settingPtr(name)
+	if p == nil {
+		return fmt.Errorf("%w %q, expected one of %v", ErrUnknownSetting, name, strings.Join(Settings, ", "))
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%v must be a number, got %q", name, value)
+	}
+	*p = n
+	return nil
+}
+
+func (c *ProcConfig) settingPtr(name string) *int {
+	switch name {
+	case settingSingleLimit:
+		return &c.SingleLimit
+	case settingDailyLimit:
+		return &c.DailyLimit
+	case settingTopUserDefault:
+		return &c.TopUserDefault
+	case settingTopUserMax:
+		return &c.TopUserMax
+	case settingPairCooldown:
+		return &c.PairCooldown
+	case settingPairLimit:
+		return &c.PairLimit
+	case settingPairWindow:
+		return &c.PairWindow
+	case settingRecipientLimit:
+		return &c.RecipientDailyLimit
+	case settingUndoWindow:
+		return &c.UndoWindow
+	}
+	return nil
+}
+
+func (c *ProcConfig) stringPtr(name string) *string {
+	switch name {
+	case settingTimezone:
+		return &c.Timezone
+	case settingDailyWindow:
+		return &c.DailyWindow
+	case settingGroupPolicy:
+		return &c.GroupPolicy
+	case settingSeasonLength:
+		return &c.SeasonLength
+	}
+	return nil
+}
+
+// WithOverrides the config with a team's settings applied on top, which must still be valid
+func (c ProcConfig) WithOverrides(overrides map[string]string) (ProcConfig, error) {
+	for name, value := range overrides {
+		if err := c.Set(name, value); err != nil {
+			return c, err
+		}
+	}
+
+	return c, c.Validate()
+}
+
+// TeamConfig the effective config of a team, and the settings that it overrides
+func (p SlackProcessor) TeamConfig(team string) (ProcConfig, map[string]string, error) {
+	overrides, err := p.dao.TeamConfig(team)
+	if err != nil {
+		return ProcConfig{}, nil, err
+	}
+
+	cfg, err := p.defaults.WithOverrides(overrides)
+	if err != nil {
+		// the global defaults may have moved since the team's settings were made
+		log.Warnf("Ignoring the invalid config of team %v: %v", team, err)
+		return p.defaults, overrides, nil
+	}
+
+	return cfg, overrides, nil
+}
+
+// SetTeamConfig overrides a team's setting, as long as the result is valid
+func (p SlackProcessor) SetTeamConfig(team, setting, value, actor string) (ProcConfig, error) {
+	overrides, err := p.dao.TeamConfig(team)
+	if err != nil {
+		return ProcConfig{}, err
+	}
+	overrides[setting] = value
+
+	cfg, err := p.defaults.WithOverrides(overrides)
+	if err != nil {
+		return ProcConfig{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	if err := p.dao.SetTeamConfig(team, setting, value, actor); err != nil {
+		return ProcConfig{}, err
+	}
+
+	return cfg, nil
+}
+
+// ResetTeamConfig puts a team's setting back to the global default, as long as the result is valid
+func (p SlackProcessor) ResetTeamConfig(team, setting, actor string) (ProcConfig, error) {
+	if _, ok := p.defaults.Setting(setting); !ok {
+		return ProcConfig{}, fmt.Errorf("%w: %w %q", ErrInvalidConfig, ErrUnknownSetting, setting)
+	}
+
+	overrides, err := p.dao.TeamConfig(team)
+	if err != nil {
+		return ProcConfig{}, err
+	}
+	delete(overrides, setting)
+
+	cfg, err := p.defaults.WithOverrides(overrides)
+	if err != nil {
+		return ProcConfig{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	if err := p.dao.ResetTeamConfig(team, setting, actor); err != nil {
+		return ProcConfig{}, err
+	}
+
+	return cfg, nil
+}
+
+// teamConfig `/karma config [set <setting> <value>|reset <setting>|history [n]]`, for admins only
+func (p SlackProcessor) teamConfig(team, callee string, words []string) (slack.Response, error) {
+	allowed, err := p.IsAdmin(team, callee)
+	if err != nil {
+		return slack.Response{}, err
+	}
+	if !allowed {
+		return slack.ErrorResponse(msgNotAdmin), nil
+	}
+
+	sub, ok := parseArg(words, 1)
+	if !ok {
+		cfg, overrides, err := p.TeamConfig(team)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgConfig(cfg, overrides), ""), nil
+	}
+
+	switch sub {
+	case configSet:
+		setting, sok := parseArg(words, 2)
+		value, vok := parseArg(words, 3)
+		if !sok || !vok {
+			return slack.DirectResponse(msgConfigUsage, cmdConfig), nil
+		}
+
+		_, err := p.SetTeamConfig(team, setting, value, callee)
+		if errors.Is(err, ErrInvalidConfig) {
+			return slack.ErrorResponse(MsgConfigInvalid(err)), nil
+		}
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgConfigSet(setting, value), ""), nil
+
+	case configReset:
+		setting, ok := parseArg(words, 2)
+		if !ok {
+			return slack.DirectResponse(msgConfigUsage, cmdConfig), nil
+		}
+
+		cfg, err := p.ResetTeamConfig(team, setting, callee)
+		if errors.Is(err, ErrInvalidConfig) {
+			return slack.ErrorResponse(MsgConfigInvalid(err)), nil
+		}
+		if err != nil {
+			return slack.Response{}, err
+		}
+		value, _ := cfg.Setting(setting)
+		return slack.DirectResponse(MsgConfigReset(setting, value), ""), nil
+
+	case configHistory:
+		n := p.leaderboardSize(words[1:])
+		entries, err := p.dao.Audit(team, n)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgAudit(entries), ""), nil
+	}
+
+	return slack.DirectResponse(msgConfigUsage, cmdConfig), nil
+}
@@ -0,0 +1,203 @@
+package karma
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/shakespeare"
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminProcessor a processor where UCALLER is an admin, and the team has overridden its settings
+func adminProcessor(dao MockDAO, overrides map[string]string) SlackProcessor {
+	dao.TeamConfigMock = func(team string) (map[string]string, error) {
+		copied := make(map[string]string)
+		for k, v := range overrides {
+			copied[k] = v
+		}
+		return copied, nil
+	}
+
+	config := DefaultConfig
+	config.Admins = []string{"UCALLER"}
+	return NewProcessor(config, dao,
+		shakespeare.New("insult", "", nil),
+		shakespeare.New("compliment", "", nil))
+}
+
+func TestWithOverrides(t *testing.T) {
+	testcases := []struct {
+		name      string
+		overrides map[string]string
+		expected  ProcConfig
+		valid     bool
+	}{
+		{"none", nil, DefaultConfig, true},
+		{"daily limit", map[string]string{settingDailyLimit: "50"}, ProcConfig{SingleLimit: 5, DailyLimit: 50, TopUserDefault: 3, TopUserMax: 10, PairWindow: 24, UndoWindow: 5, Timezone: "Local", DailyWindow: WindowDay, GroupPolicy: PolicySplit, SeasonLength: SeasonOff}, true},
+		{"every setting", map[string]string{
+			settingSingleLimit:    "1",
+			settingDailyLimit:     "2",
+			settingTopUserDefault: "4",
+			settingTopUserMax:     "8",
+		}, ProcConfig{SingleLimit: 1, DailyLimit: 2, TopUserDefault: 4, TopUserMax: 8, PairWindow: 24, UndoWindow: 5, Timezone: "Local", DailyWindow: WindowDay, GroupPolicy: PolicySplit, SeasonLength: SeasonOff}, true},
+		{"timezone and window", map[string]string{settingTimezone: "Europe/Paris", settingDailyWindow: WindowRolling},
+			ProcConfig{SingleLimit: 5, DailyLimit: 25, TopUserDefault: 3, TopUserMax: 10, PairWindow: 24, UndoWindow: 5, Timezone: "Europe/Paris", DailyWindow: WindowRolling, GroupPolicy: PolicySplit, SeasonLength: SeasonOff}, true},
+		{"group policy", map[string]string{settingGroupPolicy: PolicyReplicate},
+			ProcConfig{SingleLimit: 5, DailyLimit: 25, TopUserDefault: 3, TopUserMax: 10, PairWindow: 24, UndoWindow: 5, Timezone: "Local", DailyWindow: WindowDay, GroupPolicy: PolicyReplicate, SeasonLength: SeasonOff}, true},
+		{"unknown timezone", map[string]string{settingTimezone: "Mars/Olympus_Mons"}, DefaultConfig, false},
+		{"unknown window", map[string]string{settingDailyWindow: "weekly"}, DefaultConfig, false},
+		{"unknown policy", map[string]string{settingGroupPolicy: "raffle"}, DefaultConfig, false},
+		{"unknown", map[string]string{"admins": "UCALLER"}, DefaultConfig, false},
+		{"not a number", map[string]string{settingDailyLimit: "lots"}, DefaultConfig, false},
+		{"invalid", map[string]string{settingDailyLimit: "1"}, ProcConfig{SingleLimit: 5, DailyLimit: 1, TopUserDefault: 3, TopUserMax: 10, PairWindow: 24, UndoWindow: 5, Timezone: "Local", DailyWindow: WindowDay, GroupPolicy: PolicySplit, SeasonLength: SeasonOff}, false},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := DefaultConfig.WithOverrides(test.overrides)
+			assert.Equal(t, test.valid, err == nil, err)
+			if test.valid {
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTeamRulesApply(t *testing.T) {
+	p := adminProcessor(HappyDao(), map[string]string{settingSingleLimit: "2"})
+
+	processHelper(t, p, ProcessTestCase{
+		name:         "over the team's single limit",
+		command:      command("++ <@USER> 3"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         MsgDeltaLimit(2),
+	})
+
+	processHelper(t, p, ProcessTestCase{
+		name:         "within the team's single limit",
+		command:      command("++ <@USER> 2"),
+		responseType: slack.ResponseType.InChannel,
+		text:         "<@UCALLER> is giving 2 karma to <@USER>. <@USER> has 3 karma.",
+		attach:       true,
+	})
+
+	responses, err := p.ProcessMessage(message("<@USER> ++++"))
+	assert.Nil(t, err)
+	assert.Equal(t, MsgDeltaLimit(2), responses[0].Text)
+}
+
+func TestTeamRulesInvalidFallBack(t *testing.T) {
+	// the team's settings no longer agree with the global defaults
+	p := adminProcessor(HappyDao(), map[string]string{settingDailyLimit: "2"})
+
+	cfg, overrides, err := p.TeamConfig("TEAM")
+	assert.Nil(t, err)
+	assert.Equal(t, p.config, cfg)
+	assert.Equal(t, map[string]string{settingDailyLimit: "2"}, overrides)
+}
+
+func TestProcessConfig(t *testing.T) {
+	p := adminProcessor(HappyDao(), map[string]string{settingDailyLimit: "50"})
+
+	testcases := []ProcessTestCase{
+		{
+			name:         "show",
+			command:      command("config"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "This team's karma settings:\n`single_limit` 5 (default)\n`daily_limit` 50 (team)\n`top_user_default` 3 (default)\n`top_user_max` 10 (default)\n`pair_cooldown_minutes` 0 (default)\n`pair_limit` 0 (default)\n`pair_window_hours` 24 (default)\n`recipient_daily_limit` 0 (default)\n`undo_window_minutes` 5 (default)\n`timezone` Local (default)\n`daily_window` day (default)\n`group_policy` split (default)\n`season_length` off (default)\n",
+		},
+		{
+			name:         "set",
+			command:      command("config set single_limit 10"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "`single_limit` is now 10 for this team.",
+		},
+		{
+			name:         "set invalid",
+			command:      command("config set single_limit 100"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "I can't change that, invalid config: daily_limit (50) must be at least single_limit (100)",
+		},
+		{
+			name:         "set unknown",
+			command:      command("config set admins UCALLER"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "I can't change that, invalid config: unknown setting \"admins\", expected one of single_limit, daily_limit, top_user_default, top_user_max, pair_cooldown_minutes, pair_limit, pair_window_hours, recipient_daily_limit, undo_window_minutes, timezone, daily_window, group_policy, season_length",
+		},
+		{
+			name:         "set missing value",
+			command:      command("config set single_limit"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgConfigUsage,
+		},
+		{
+			name:         "reset",
+			command:      command("config reset daily_limit"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "`daily_limit` is back to the default of 25 for this team.",
+		},
+		{
+			name:         "reset unknown",
+			command:      command("config reset nope"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "I can't change that, invalid config: unknown setting \"nope\"",
+		},
+		{
+			name:         "history",
+			command:      command("config history 1"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         "The recent changes to this team:\n<@UADMIN> config set `daily_limit` from default to 0 (2019-11-09)\n",
+		},
+		{
+			name:         "unknown sub-command",
+			command:      command("config sideways"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgConfigUsage,
+		},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, p, test)
+	}
+}
+
+func TestProcessConfigNotAdmin(t *testing.T) {
+	for _, text := range []string{"config", "config set daily_limit 100", "config reset daily_limit", "config history"} {
+		processHelper(t, happyMockProcessor(), ProcessTestCase{
+			name:         text,
+			command:      command(text),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgNotAdmin,
+		})
+	}
+}
+
+func TestSetTeamConfig(t *testing.T) {
+	dao := HappyDao()
+	var saved []string
+	dao.SetTeamConfigMock = func(team, setting, value, actor string) error {
+		saved = append(saved, team, setting, value, actor)
+		return nil
+	}
+	p := adminProcessor(dao, nil)
+
+	cfg, err := p.SetTeamConfig("TEAM", settingTopUserMax, "20", "UCALLER")
+	assert.Nil(t, err)
+	assert.Equal(t, 20, cfg.TopUserMax)
+	assert.Equal(t, []string{"TEAM", settingTopUserMax, "20", "UCALLER"}, saved)
+
+	// invalid changes are not saved
+	saved = nil
+	_, err = p.SetTeamConfig("TEAM", settingTopUserMax, "1", "UCALLER")
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.Nil(t, saved)
+}
+
+func TestResetTeamConfigInvalid(t *testing.T) {
+	// resetting the single limit back to 5 would put it over the team's daily limit
+	p := adminProcessor(HappyDao(), map[string]string{settingSingleLimit: "2", settingDailyLimit: "3"})
+
+	_, err := p.ResetTeamConfig("TEAM", settingSingleLimit, "UCALLER")
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
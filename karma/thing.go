@@ -0,0 +1,206 @@
+package karma
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// things the argument of `/karma top things`
+const things = "things"
+
+// the shortest and longest name a thing can have, in characters, not counting the # of a channel
+const (
+	minThingLength = 2
+	maxThingLength = 64
+)
+
+var (
+	// `<#C123|release>` is how slack sends a #release channel reference
+	channelRef = regexp.MustCompile(`^<#(C[A-Z0-9]+)(?:\|([^>]*))?>$`)
+	// a word, or a #channel that slack didn't escape. It can't end with the - or . of a `--`
+	thingWord = regexp.MustCompile(`^#?[\pL\pN_](?:[\pL\pN_.'-]*[\pL\pN_])?$`)
+	// `"pizza party"++`, `#release--` or `<#C024BE7LR|release>++` in a channel message. A #word needs the ++ right after it.
+	// Bare words aren't things there, or C++ and i++ would be giving karma away. They are in a slash command
+	thingRegex = regexp.MustCompile(`("[^"<>]+"\s?|<#C[A-Z0-9]+(?:\|[^>]*)?>\s?|#[\pL\pN_](?:[\pL\pN_.'-]*[\pL\pN_])?)(\+{2,}|-{2,})`)
+	// `inline code` and ```code blocks``` in a message, where ++ and -- are just code
+	codeRegex = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+	// phones like to make quotes curly
+	curlyQuotes = strings.NewReplacer("“", `"`, "”", `"`)
+)
+
+// ParseThing the name of a karma target that isn't a user: a word, a "quoted phrase", a #word or a #channel.
+// Names are normalized to lower case, so Pizza and pizza are the same thing, and must be at least 2 characters long
+func ParseThing(s string) (string, bool) {
+	s = curlyQuotes.Replace(s)
+
+	var name string
+	switch m := channelRef.FindStringSubmatch(s); {
+	case m != nil && m[2] != "":
+		name = "#" + m[2]
+	case m != nil:
+		name = "#" + m[1]
+	case len(s) > 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`):
+		name = strings.Join(strings.Fields(s[1:len(s)-1]), " ")
+		if name == "" || strings.ContainsAny(name, `"<>`) {
+			return "", false
+		}
+	case thingWord.MatchString(s):
+		name = s
+	default:
+		return "", false
+	}
+
+	n := utf8.RuneCountInString(strings.TrimPrefix(name, "#"))
+	return strings.ToLower(name), n >= minThingLength && n <= maxThingLength
+}
+
+// splitWords the words of a command, like strings.Fields, except that a "quoted phrase" is one word.
+// An unclosed quote is left as it is
+func splitWords(text string) []string {
+	fields := strings.Fields(curlyQuotes.Replace(text))
+	words := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if !strings.HasPrefix(f, `"`) || (len(f) > 1 && strings.HasSuffix(f, `"`)) {
+			words = append(words, f)
+			continue
+		}
+
+		end := i + 1
+		for end < len(fields) && !strings.HasSuffix(fields[end], `"`) {
+			end++
+		}
+		if end == len(fields) {
+			words = append(words, f)
+			continue
+		}
+		words = append(words, strings.Join(fields[i:end+1], " "))
+		i = end
+	}
+
+	return words
+}
+
+// parseTarget a user, or failing that a thing. ok is false when it is neither
+func parseTarget(s string) (target string, thing bool, ok bool) {
+	if user, ok := slack.IsSlackUser(s); ok {
+		return user, false, true
+	}
+	if name, ok := ParseThing(s); ok {
+		return name, true, true
+	}
+	return "", false, false
+}
+
+// thingMentions finds every thing's karma in a message, and where it is.
+// Words glued to the text around them, like a#b++ or #a--b, and anything in code are not things
+func thingMentions(text string) []mentionAt {
+	var found []mentionAt
+	for _, m := range thingRegex.FindAllStringSubmatchIndex(withoutCode(text), -1) {
+		if prev, _ := utf8.DecodeLastRuneInString(text[:m[0]]); prev == '@' || unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			continue
+		}
+		if next, _ := utf8.DecodeRuneInString(text[m[1]:]); unicode.IsLetter(next) || unicode.IsDigit(next) {
+			continue
+		}
+
+		name, ok := ParseThing(strings.TrimSpace(text[m[2]:m[3]]))
+		if !ok {
+			continue
+		}
+		found = append(found, mentionAt{Mention{Target: name, Delta: opDelta(text[m[4]:m[5]]), Thing: true}, m[0], m[1]})
+	}
+
+	return found
+}
+
+// withoutCode blanks out the code in a message, keeping everything else where it was
+func withoutCode(text string) string {
+	return codeRegex.ReplaceAllStringFunc(text, func(code string) string {
+		return strings.Repeat(" ", len(code))
+	})
+}
+
+// giveThing applies the karma rules that make sense for things (single limit, bans and the daily limit) and then moves delta karma to the thing.
+// Things share the daily limit with users. A negative delta takes karma away
+func (p SlackProcessor) giveThing(team, channel, callee, thing string, delta int, reason string) (slack.Response, error) {
+	if delta == 0 {
+		return slack.ErrorResponse(msgNoOp), nil
+	}
+	if Abs(delta) > p.config.SingleLimit {
+		return slack.ErrorResponse(MsgDeltaLimit(p.config.SingleLimit)), nil
+	}
+
+	// things can't be banned, only their givers
+	banned, err := p.dao.IsBanned(team, callee)
+	if err != nil {
+		return slack.Response{}, err
+	}
+	if banned {
+		return slack.ErrorResponse(msgBannedGiver), nil
+	}
+
+	now := p.teamNow()
+	if resp, over, err := p.overDailyLimit(team, callee, delta, now); err != nil || over {
+		return resp, err
+	}
+
+	t := Transaction{
+		Team:    team,
+		From:    callee,
+		To:      thing,
+		Channel: channel,
+		Delta:   delta,
+		Message: reason,
+	}
+	k, err := p.dao.UpdateThingDaily(t, now)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	if delta > 0 {
+		msg.WriteString(MsgGiveThing(callee, thing, delta, reason))
+	} else {
+		msg.WriteString(MsgTakeThing(callee, thing, -delta, reason))
+	}
+	msg.WriteString(MsgThingStatus(thing, k))
+	att.WriteString(p.Salutation(delta))
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
+
+func (p SlackProcessor) thingStatus(team, callee, thing string) (slack.Response, error) {
+	k, err := p.dao.GetThing(team, thing)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	msg.WriteString(MsgThingStatusTarget(callee, thing))
+	msg.WriteString(MsgThingStatus(thing, k))
+	att.WriteString(p.Salutation(k))
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
+
+// topThings `/karma top things 5`
+func (p SlackProcessor) topThings(team string, words []string) (slack.Response, error) {
+	n := p.leaderboardSize(words[1:])
+
+	top, err := p.dao.TopThings(team, n)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	if len(top) == 0 {
+		return slack.DirectResponse(msgNoKarmaForThings, ""), nil
+	}
+
+	msg, att := &strings.Builder{}, &strings.Builder{}
+	msg.WriteString(MsgTopThings(top))
+	att.WriteString(p.compliment.Sentence())
+	return slack.ChannelAttachmentsResponse(msg.String(), att.String()), nil
+}
@@ -0,0 +1,209 @@
+package karma
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThing(t *testing.T) {
+	testcases := []struct {
+		text     string
+		expected string
+		ok       bool
+	}{
+		{"Pizza", "pizza", true},
+		{`"Pizza"`, "pizza", true},
+		{"#node.js", "#node.js", true},
+		{`"Pizza  Party"`, "pizza party", true},
+		{"“pizza party”", "pizza party", true},
+		{"#release", "#release", true},
+		{"<#C024BE7LR|release>", "#release", true},
+		{"<#C024BE7LR>", "#c024be7lr", true},
+		{`""`, "", false},
+		{"c", "", false},
+		{`"c"`, "", false},
+		{"pizza-", "", false},
+		{"C++", "", false},
+		{"#c", "", false},
+		{`"<@U123>"`, "", false},
+		{"@simon", "", false},
+		{"<@simon>", "", false},
+		{"#pizza!", "", false},
+		{"#" + strings.Repeat("a", maxThingLength), "#" + strings.Repeat("a", maxThingLength), true},
+		{"#" + strings.Repeat("a", maxThingLength+1), "", false},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.text, func(t *testing.T) {
+			actual, ok := ParseThing(test.text)
+			assert.Equal(t, test.ok, ok)
+			if test.ok {
+				assert.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	testcases := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"words", "++ pizza 2", []string{"++", "pizza", "2"}},
+		{"phrase", `++ "pizza party" 2 for lunch`, []string{"++", `"pizza party"`, "2", "for", "lunch"}},
+		{"one word phrase", `++ "pizza"`, []string{"++", `"pizza"`}},
+		{"curly", "++ “pizza party”", []string{"++", `"pizza party"`}},
+		{"unclosed", `++ "pizza party`, []string{"++", `"pizza`, "party"}},
+		{"lone quote", `++ " pizza`, []string{"++", `"`, "pizza"}},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, splitWords(test.text))
+		})
+	}
+}
+
+func TestParseThingMentions(t *testing.T) {
+	testcases := []struct {
+		name     string
+		text     string
+		expected []Mention
+	}{
+		{"word", "#pizza++", []Mention{{Target: "#pizza", Delta: 1, Thing: true}}},
+		{"case", "#PIZZA+++ for lunch", []Mention{{Target: "#pizza", Delta: 2, Reason: "lunch", Thing: true}}},
+		{"phrase", `"pizza party" --`, []Mention{{Target: "pizza party", Delta: -1, Thing: true}}},
+		{"channel", "<#C024BE7LR|release>++", []Mention{{Target: "#release", Delta: 1, Thing: true}}},
+		{"plain channel", "#release++", []Mention{{Target: "#release", Delta: 1, Thing: true}}},
+		{"with users", `<@UA> ++ and "pizza"++ for <@UB> ++`, []Mention{
+			{Target: "UA", Delta: 1},
+			{Target: "pizza", Delta: 1, Thing: true},
+			{Target: "UB", Delta: 1},
+		}},
+		{"a word needs its ++", "#pizza ++", []Mention{}},
+		{"not a name", "@simon++", []Mention{}},
+		{"dashes in a word", "#well--maybe", []Mention{}},
+		{"dashes after a word", "#pizza--- no", []Mention{{Target: "#pizza", Delta: -2, Thing: true}}},
+		{"bare words", "pizza++ in C++ with i++ and x--", []Mention{}},
+		{"glued #", "C#++ and a#b++", []Mention{}},
+		{"one character", `#c++ "c"++`, []Mention{}},
+		{"inline code", "try `#count++` or `\"count\"++`", []Mention{}},
+		{"code block", "```\nfor (#count = 0; #count < n; #count++) {}\n``` #review++", []Mention{{Target: "#review", Delta: 1, Thing: true}}},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseMentions(test.text))
+		})
+	}
+}
+
+func TestProcessThings(t *testing.T) {
+	dao := HappyDao()
+	var given Transaction
+	dao.UpdateThingDailyMock = func(t Transaction, date time.Time) (int, error) {
+		given = t
+		return 3 + t.Delta, nil
+	}
+	p := mockProcessor(dao)
+
+	testcases := []ProcessTestCase{
+		{
+			name:         "++",
+			command:      command("++ Pizza"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 1 karma to *pizza*. *pizza* has 4 karma.",
+			attach:       true,
+		},
+		{
+			name:         "-- a phrase with a reason",
+			command:      command(`-- "pizza party" 2 for the anchovies`),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is taking away 2 karma from *pizza party* for the anchovies. *pizza party* has 1 karma.",
+			attach:       true,
+		},
+		{
+			name:         "alias",
+			command:      command("+3 <#C024BE7LR|release>"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> is giving 3 karma to *#release*. *#release* has 6 karma.",
+			attach:       true,
+		},
+		{
+			name:         "single limit",
+			command:      command("++ pizza 6"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgDeltaLimit(5),
+		},
+		{
+			name:         "status",
+			command:      command("status pizza"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> has requested karma total for *pizza*. *pizza* has 3 karma.",
+			attach:       true,
+		},
+		{
+			name:         "top things",
+			command:      command("top things 2"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "The top 2 things by karma:\nRank\tName\tKarma\n1\t*thing0*\t2\n2\t*thing1*\t1\n",
+			attach:       true,
+		},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, p, test)
+	}
+	assert.Equal(t, Transaction{From: "UCALLER", To: "#release", Delta: 3}, given)
+
+	// neither a user nor a thing
+	for _, text := range []string{"++ @pizza", "-- p", "status pizza!"} {
+		res, err := p.Process(command(text))
+		assert.Nil(t, err)
+		assert.Equal(t, msgInvalidUser, res.Text, text)
+	}
+
+	responses, err := p.ProcessMessage(message("<#C024BE7LR|release>++ for shipping"))
+	assert.Nil(t, err)
+	assert.Equal(t, "<@UCALLER> is giving 1 karma to *#release* for shipping. *#release* has 4 karma.", responses[0].Text)
+}
+
+func TestThingRules(t *testing.T) {
+	processHelper(t, fullUsageMockProcessor(), ProcessTestCase{
+		name:         "things share the daily limit",
+		command:      command(`++ "pizza"`),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         MsgOverDailyLimit(25, 25, 0, time.Date(2026, time.May, 5, 0, 0, 0, 0, time.UTC), mockNow),
+	})
+
+	dao := HappyDao()
+	dao.TopThingsMock = func(team string, n int) ([]ThingKarma, error) {
+		return nil, nil
+	}
+	processHelper(t, mockProcessor(dao), ProcessTestCase{
+		name:         "no things",
+		command:      command("top things"),
+		responseType: slack.ResponseType.Ephemeral,
+		text:         msgNoKarmaForThings,
+	})
+
+	banned := command(`++ "pizza"`)
+	banned.UserID = "UBANNED"
+	processHelper(t, happyMockProcessor(), ProcessTestCase{
+		name:         "banned giver",
+		command:      banned,
+		responseType: slack.ResponseType.Ephemeral,
+		text:         msgBannedGiver,
+	})
+
+	p := sadMockProcessor()
+	for _, text := range []string{`++ "pizza"`, `status "pizza"`, "top things"} {
+		_, err := p.Process(command(text))
+		assert.NotNil(t, err, text)
+	}
+}
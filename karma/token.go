@@ -0,0 +1,105 @@
+package karma
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scope what an api token may do. Each scope includes the ones below it
+type Scope string
+
+// api token scopes
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+// AllTeams the team of an api token that may be used with every team
+const AllTeams = "*"
+
+// tokenPrefix makes a knave-bot token easy to recognize, in a config file or a leak scanner
+const tokenPrefix = "kb_"
+
+// ErrUnknownToken the api token does not exist, or was revoked
+var ErrUnknownToken = errors.New("unknown api token")
+
+// APIToken a bearer token for the REST api. The token itself is only shown once, when it is minted
+type APIToken struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Team      string     `json:"team"`
+	Scopes    []Scope    `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// TokenStore looks up api tokens by the hash of the bearer token
+type TokenStore interface {
+	TokenByHash(hash string) (APIToken, error)
+}
+
+// ParseScopes a comma separated list of scopes, like `read,write`
+func ParseScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+	for _, name := range strings.Split(s, ",") {
+		scope := Scope(strings.TrimSpace(name))
+		switch scope {
+		case ScopeRead, ScopeWrite, ScopeAdmin:
+			scopes = append(scopes, scope)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown scope %q, expected read, write or admin", scope)
+		}
+	}
+
+	if len(scopes) == 0 {
+		return nil, errors.New("a token needs at least one scope")
+	}
+	return scopes, nil
+}
+
+func joinScopes(scopes []Scope) string {
+	names := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		names = append(names, string(s))
+	}
+	return strings.Join(names, ",")
+}
+
+// Allows whether the token has the scope, admin implies write and write implies read
+func (t APIToken) Allows(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAdmin || (s == ScopeWrite && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsTeam whether the token may be used with the team
+func (t APIToken) AllowsTeam(team string) bool {
+	return t.Team == AllTeams || t.Team == team
+}
+
+// NewToken a random bearer token, and the hash to store in its place
+func NewToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := tokenPrefix + hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken the sha256 of a bearer token. Tokens are random, so they don't need a slow password hash
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,43 @@
+package karma
+
+import (
+	"errors"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// undo takes back the callee's most recent karma, to one user or several at once, as if it was never given.
+// Only karma given within the team's undo window can be, and it is announced in the channel
+func (p SlackProcessor) undo(team, callee string) (slack.Response, error) {
+	if p.config.UndoWindow == 0 {
+		return slack.ErrorResponse(msgUndoOff), nil
+	}
+
+	now := p.teamNow()
+	ts, err := p.dao.LastGiven(team, callee, now.Add(-time.Duration(p.config.UndoWindow)*time.Minute))
+	if errors.Is(err, ErrUndoThing) {
+		return slack.ErrorResponse(msgUndoThing), nil
+	}
+	if err != nil {
+		return slack.Response{}, err
+	}
+	if len(ts) == 0 {
+		return slack.ErrorResponse(MsgNothingToUndo(p.config.UndoWindow)), nil
+	}
+
+	// the daily usage was counted on the day it was given
+	ks, err := p.dao.Undo(ts, ts[0].CreatedAt.In(now.Location()))
+	if errors.Is(err, ErrAlreadyUndone) {
+		return slack.ErrorResponse(MsgNothingToUndo(p.config.UndoWindow)), nil
+	}
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	targets := make([]string, 0, len(ts))
+	for _, t := range ts {
+		targets = append(targets, t.To)
+	}
+	return slack.ChannelResponse(MsgUndo(callee, ts) + MsgUsersStatus(targets, ks)), nil
+}
@@ -0,0 +1,97 @@
+package karma
+
+import (
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessUndo(t *testing.T) {
+	var undone []Transaction
+	var undoneOn time.Time
+	dao := HappyDao()
+	dao.UndoMock = func(ts []Transaction, date time.Time) ([]int, error) {
+		undone, undoneOn = ts, date
+		return []int{4, 1}[:len(ts)], nil
+	}
+	many := HappyDao()
+	many.LastGivenMock = func(team, user string, since time.Time) ([]Transaction, error) {
+		return []Transaction{
+			{ID: 7, From: user, To: "UA", Delta: -2, CreatedAt: since},
+			{ID: 8, From: user, To: "UB", Delta: -2, CreatedAt: since},
+		}, nil
+	}
+	many.UndoMock = dao.UndoMock
+	nothing := HappyDao()
+	nothing.LastGivenMock = func(team, user string, since time.Time) ([]Transaction, error) {
+		assert.Equal(t, mockNow.Add(-5*time.Minute), since)
+		return nil, nil
+	}
+	raced := HappyDao()
+	raced.UndoMock = func(ts []Transaction, date time.Time) ([]int, error) {
+		return nil, ErrAlreadyUndone
+	}
+	thing := HappyDao()
+	thing.LastGivenMock = func(team, user string, since time.Time) ([]Transaction, error) {
+		return nil, ErrUndoThing
+	}
+	off := mockProcessor(HappyDao())
+	off.defaults.UndoWindow = 0
+
+	testcases := []struct {
+		ProcessTestCase
+		p SlackProcessor
+	}{
+		{ProcessTestCase{
+			name:         "undo",
+			command:      command("undo"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> has taken back the 1 karma they gave <@USER>. <@USER> has 4 karma.",
+		}, mockProcessor(dao)},
+		{ProcessTestCase{
+			name:         "several at once",
+			command:      command("undo"),
+			responseType: slack.ResponseType.InChannel,
+			text:         "<@UCALLER> has given back the karma they took from <@UA> and <@UB>. <@UA> has 4 and <@UB> has 1 karma.",
+		}, mockProcessor(many)},
+		{ProcessTestCase{
+			name:         "nothing to undo",
+			command:      command("undo"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgNothingToUndo(5),
+		}, mockProcessor(nothing)},
+		{ProcessTestCase{
+			name:         "already undone",
+			command:      command("undo"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         MsgNothingToUndo(5),
+		}, mockProcessor(raced)},
+		{ProcessTestCase{
+			name:         "last given to a thing",
+			command:      command("undo"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgUndoThing,
+		}, mockProcessor(thing)},
+		{ProcessTestCase{
+			name:         "turned off",
+			command:      command("undo"),
+			responseType: slack.ResponseType.Ephemeral,
+			text:         msgUndoOff,
+		}, off},
+	}
+
+	for _, test := range testcases {
+		processHelper(t, test.p, test.ProcessTestCase)
+	}
+
+	if assert.Len(t, undone, 2) {
+		assert.Equal(t, int64(7), undone[0].ID)
+		// the day it was given, in the team's timezone
+		assert.Equal(t, mockNow.Add(-5*time.Minute), undoneOn)
+	}
+
+	_, err := sadMockProcessor().Process(command("undo"))
+	assert.NotNil(t, err)
+}
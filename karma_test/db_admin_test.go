@@ -0,0 +1,119 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetKarma(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		_, err := dao.UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "loki", Delta: 3}, date)
+		assert.Nil(t, err)
+
+		assert.Nil(t, dao.SetKarma("avengers", "loki", -10, "fury"))
+		k, err := dao.GetKarma("avengers", "loki")
+		assert.Nil(t, err)
+		assert.Equal(t, -10, k)
+
+		// a user without any karma yet
+		assert.Nil(t, dao.SetKarma("avengers", "thor", 7, "fury"))
+		k, err = dao.GetKarma("avengers", "thor")
+		assert.Nil(t, err)
+		assert.Equal(t, 7, k)
+
+		assert.Nil(t, dao.SetKarma("avengers", "loki", 0, "fury"))
+		k, err = dao.GetKarma("avengers", "loki")
+		assert.Nil(t, err)
+		assert.Zero(t, k)
+
+		// the ledger still adds up
+		received, err := dao.Received("avengers", "loki", 10, 0)
+		assert.Nil(t, err)
+		assert.Len(t, received, 3)
+		assert.Equal(t, 10, received[0].Delta)
+		assert.Equal(t, "reset", received[0].Message)
+		assert.Equal(t, -13, received[1].Delta)
+		assert.Equal(t, "set", received[1].Message)
+
+		entries, err := dao.Audit("avengers", 10)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 3)
+		assert.Equal(t, "karma set", entries[0].Action)
+		assert.Equal(t, "fury", entries[0].Actor)
+		assert.Equal(t, "loki", entries[0].Target)
+		assert.Equal(t, "from -10 to 0", entries[0].Detail)
+		assert.Equal(t, "from 0 to 7", entries[1].Detail)
+		assert.Equal(t, "from 3 to -10", entries[2].Detail)
+	})
+}
+
+func TestAdmins(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		admin, err := dao.IsAdmin("avengers", "cap")
+		assert.Nil(t, err)
+		assert.False(t, admin)
+
+		assert.Nil(t, dao.AddAdmin("avengers", "cap", "fury"))
+		assert.Nil(t, dao.AddAdmin("avengers", "widow", "fury"))
+		// adding twice is harmless
+		assert.Nil(t, dao.AddAdmin("avengers", "cap", "fury"))
+		assert.Nil(t, dao.AddAdmin("guardians", "quill", "fury"))
+
+		admin, err = dao.IsAdmin("avengers", "cap")
+		assert.Nil(t, err)
+		assert.True(t, admin)
+		admin, err = dao.IsAdmin("guardians", "cap")
+		assert.Nil(t, err)
+		assert.False(t, admin)
+
+		admins, err := dao.Admins("avengers")
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"cap", "widow"}, admins)
+
+		assert.Nil(t, dao.RemoveAdmin("avengers", "cap", "widow"))
+		admins, err = dao.Admins("avengers")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"widow"}, admins)
+
+		entries, err := dao.Audit("avengers", 10)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 4)
+		assert.Equal(t, "admin remove", entries[0].Action)
+		assert.Equal(t, "widow", entries[0].Actor)
+		assert.Equal(t, "cap", entries[0].Target)
+	})
+}
+
+func TestBans(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		banned, err := dao.Banned("avengers")
+		assert.Nil(t, err)
+		assert.Empty(t, banned)
+
+		assert.Nil(t, dao.Ban("avengers", "loki", "fury"))
+		isBanned, err := dao.IsBanned("avengers", "loki")
+		assert.Nil(t, err)
+		assert.True(t, isBanned)
+		isBanned, err = dao.IsBanned("asgard", "loki")
+		assert.Nil(t, err)
+		assert.False(t, isBanned)
+
+		banned, err = dao.Banned("avengers")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"loki"}, banned)
+
+		assert.Nil(t, dao.Unban("avengers", "loki", "fury"))
+		isBanned, err = dao.IsBanned("avengers", "loki")
+		assert.Nil(t, err)
+		assert.False(t, isBanned)
+
+		entries, err := dao.Audit("avengers", 10)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "unban", entries[0].Action)
+		assert.Equal(t, "ban", entries[1].Action)
+	})
+}
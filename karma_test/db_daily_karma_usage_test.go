@@ -1,59 +1,81 @@
 package karma_test
 
 import (
+	"database/sql"
 	"testing"
 	"time"
 
+	"github.com/icemanblues/knave-bot/karma"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestUpdateKarmaDaily(t *testing.T) {
-	if testing.Short() {
-		t.Skip("skipping db integration test")
-	}
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		// confirm setup
 
-	db, dao, err := setupDB(testDB)
-	assert.Nil(t, err)
+		karmaRowCount := rowCountKarma(t, db)
+		assert.Zero(t, karmaRowCount)
 
-	// confirm setup
+		dailyRowCount := rowCountDailyUsage(t, db)
+		assert.Zero(t, dailyRowCount)
 
-	karmaRowCount := rowCountKarma(t, db)
-	assert.Zero(t, karmaRowCount)
+		date := time.Date(2019, time.November, 9, 0, 0, 0, 0, time.Local)
 
-	dailyRowCount := rowCountDailyUsage(t, db)
-	assert.Zero(t, dailyRowCount)
+		// confirm update karma daily
 
-	date := time.Date(2019, time.November, 9, 0, 0, 0, 0, time.Local)
+		t5 := karma.Transaction{Team: "avengers", From: "ironman", To: "spiderman", Delta: 5}
+		karmaSpiderman, err := dao.UpdateKarmaDaily(t5, date)
+		assert.Nil(t, err)
+		assert.Equal(t, 5, karmaSpiderman)
 
-	// confirm update karma daily
+		karmaRowCount = rowCountKarma(t, db)
+		assert.Equal(t, 1, karmaRowCount)
 
-	karmaSpiderman, err := dao.UpdateKarmaDaily("avengers", "ironman", "spiderman", 5, date)
-	assert.Nil(t, err)
-	assert.Equal(t, 5, karmaSpiderman)
+		dailyRowCount = rowCountDailyUsage(t, db)
+		assert.Equal(t, 1, dailyRowCount)
 
-	karmaRowCount = rowCountKarma(t, db)
-	assert.Equal(t, 1, karmaRowCount)
+		usageIronman, err := dao.GetDaily("avengers", "ironman", date)
+		assert.Nil(t, err)
+		assert.Equal(t, 5, usageIronman)
 
-	dailyRowCount = rowCountDailyUsage(t, db)
-	assert.Equal(t, 1, dailyRowCount)
+		// confirm another update karma daily
 
-	usageIronman, err := dao.GetDaily("avengers", "ironman", date)
-	assert.Nil(t, err)
-	assert.Equal(t, 5, usageIronman)
+		t10 := karma.Transaction{Team: "avengers", From: "ironman", To: "spiderman", Delta: -10}
+		karmaSpiderman, err = dao.UpdateKarmaDaily(t10, date)
+		assert.Nil(t, err)
+		assert.Equal(t, -5, karmaSpiderman)
 
-	// confirm another update karma daily
+		karmaRowCount = rowCountKarma(t, db)
+		assert.Equal(t, 1, karmaRowCount)
 
-	karmaSpiderman, err = dao.UpdateKarmaDaily("avengers", "ironman", "spiderman", -10, date)
-	assert.Nil(t, err)
-	assert.Equal(t, -5, karmaSpiderman)
+		dailyRowCount = rowCountDailyUsage(t, db)
+		assert.Equal(t, 1, dailyRowCount)
 
-	karmaRowCount = rowCountKarma(t, db)
-	assert.Equal(t, 1, karmaRowCount)
-
-	dailyRowCount = rowCountDailyUsage(t, db)
-	assert.Equal(t, 1, dailyRowCount)
+		usageIronman, err = dao.GetDaily("avengers", "ironman", date)
+		assert.Nil(t, err)
+		assert.Equal(t, 15, usageIronman)
+	})
+}
 
-	usageIronman, err = dao.GetDaily("avengers", "ironman", date)
-	assert.Nil(t, err)
-	assert.Equal(t, 15, usageIronman)
+func TestUpdateKarmaDailyMany(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		date := time.Date(2019, time.November, 9, 0, 0, 0, 0, time.Local)
+
+		_, err := dao.UpdateKarma("avengers", "hulk", 4)
+		assert.Nil(t, err)
+
+		ks, err := dao.UpdateKarmaDailyMany([]karma.Transaction{
+			{Team: "avengers", From: "ironman", To: "spiderman", Delta: 2, Message: "the battle"},
+			{Team: "avengers", From: "ironman", To: "hulk", Delta: 2, Message: "the battle"},
+			{Team: "avengers", From: "ironman", To: "thor", Delta: 2, Message: "the battle"},
+		}, date)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{2, 6, 2}, ks)
+
+		usage, err := dao.GetDaily("avengers", "ironman", date)
+		assert.Nil(t, err)
+		assert.Equal(t, 6, usage)
+		// one ledger row each, on top of the adjustment
+		assert.Equal(t, 4, rowCountLedger(t, db))
+	})
 }
@@ -0,0 +1,255 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func rowCountLedger(t *testing.T, db *sql.DB) int {
+	row := db.QueryRow("SELECT count(*) FROM karma_ledger")
+	var rowCount int
+	err := row.Scan(&rowCount)
+	assert.Nil(t, err)
+	return rowCount
+}
+
+func TestLedgerUpdateKarmaDaily(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		var err error
+		assert.Zero(t, rowCountLedger(t, db))
+
+		_, err = dao.UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "spiderman", Channel: "CTOWER", Delta: 3, Message: "saving the ferry"}, date)
+		assert.Nil(t, err)
+		_, err = dao.UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "cap", To: "spiderman", Channel: "CTOWER", Delta: -1}, date)
+		assert.Nil(t, err)
+		_, err = dao.UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "hulk", Channel: "CLAB", Delta: 2}, date)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 3, rowCountLedger(t, db))
+
+		received, err := dao.Received("avengers", "spiderman", 10, 0)
+		assert.Nil(t, err)
+		assert.Len(t, received, 2)
+		// most recent first
+		assert.Equal(t, "cap", received[0].From)
+		assert.Equal(t, -1, received[0].Delta)
+		assert.Equal(t, "ironman", received[1].From)
+		assert.Equal(t, "spiderman", received[1].To)
+		assert.Equal(t, "CTOWER", received[1].Channel)
+		assert.Equal(t, 3, received[1].Delta)
+		assert.Equal(t, "saving the ferry", received[1].Message)
+		assert.False(t, received[1].CreatedAt.IsZero())
+
+		given, err := dao.Given("avengers", "ironman", 10, 0)
+		assert.Nil(t, err)
+		assert.Len(t, given, 2)
+		assert.Equal(t, "hulk", given[0].To)
+		assert.Equal(t, "spiderman", given[1].To)
+
+		// paging
+		page, err := dao.Given("avengers", "ironman", 1, 1)
+		assert.Nil(t, err)
+		assert.Len(t, page, 1)
+		assert.Equal(t, given[1].ID, page[0].ID)
+
+		none, err := dao.Given("avengers", "thanos", 10, 0)
+		assert.Nil(t, err)
+		assert.Empty(t, none)
+	})
+}
+
+func TestLedgerUpdateAndDeleteKarma(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		var err error
+		_, err = dao.UpdateKarma("nycfc", "ring", 4)
+		assert.Nil(t, err)
+		_, err = dao.DeleteKarma("nycfc", "ring")
+		assert.Nil(t, err)
+
+		// nothing to reset, nothing recorded
+		_, err = dao.DeleteKarma("nycfc", "nobody")
+		assert.Nil(t, err)
+
+		assert.Equal(t, 2, rowCountLedger(t, db))
+
+		received, err := dao.Received("nycfc", "ring", 10, 0)
+		assert.Nil(t, err)
+		assert.Len(t, received, 2)
+		assert.Equal(t, -4, received[0].Delta)
+		assert.Equal(t, "reset", received[0].Message)
+		assert.Equal(t, 4, received[1].Delta)
+		assert.Equal(t, "", received[1].From)
+	})
+}
+
+func TestRebuildKarma(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		var err error
+		_, err = dao.UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "spiderman", Delta: 3}, date)
+		assert.Nil(t, err)
+		_, err = dao.UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "cap", To: "spiderman", Delta: -1}, date)
+		assert.Nil(t, err)
+		_, err = dao.UpdateKarma("avengers", "hulk", 5)
+		assert.Nil(t, err)
+		_, err = dao.UpdateKarma("xmen", "wolverine", 7)
+		assert.Nil(t, err)
+
+		// corrupt the totals, then rebuild them
+		_, err = db.Exec("UPDATE karma SET karma = 1000")
+		assert.Nil(t, err)
+
+		err = dao.RebuildKarma("avengers")
+		assert.Nil(t, err)
+
+		k, _ := dao.GetKarma("avengers", "spiderman")
+		assert.Equal(t, 2, k)
+		k, _ = dao.GetKarma("avengers", "hulk")
+		assert.Equal(t, 5, k)
+
+		// other teams are left alone
+		k, _ = dao.GetKarma("xmen", "wolverine")
+		assert.Equal(t, 1000, k)
+	})
+}
+
+func TestLedgerOpeningBalance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping db integration test")
+	}
+
+	db, _, err := setupDB(testDB)
+	assert.Nil(t, err)
+
+	// roll back to before the ledger existed, and give some karma
+	m, err := karma.NewMigrator(db, karma.DriverSQLite)
+	assert.Nil(t, err)
+	version, err := m.Version()
+	assert.Nil(t, err)
+	_, err = m.Down(version - 1)
+	assert.Nil(t, err)
+	_, err = db.Exec(`INSERT INTO karma (team, user, karma) VALUES ('nycfc', 'villa', 10), ('nycfc', 'lampard', -2), ('nycfc', 'pirlo', 0)`)
+	assert.Nil(t, err)
+	db.Close()
+
+	db, err = karma.InitDB(testDB)
+	assert.Nil(t, err)
+	dao := karma.NewDao(db)
+
+	assert.Equal(t, 2, rowCountLedger(t, db))
+
+	err = dao.RebuildKarma("nycfc")
+	assert.Nil(t, err)
+
+	k, _ := dao.GetKarma("nycfc", "villa")
+	assert.Equal(t, 10, k)
+	k, _ = dao.GetKarma("nycfc", "lampard")
+	assert.Equal(t, -2, k)
+
+	// the ledger is only seeded once
+	db.Close()
+	db, err = karma.InitDB(testDB)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rowCountLedger(t, db))
+}
+
+func TestReasons(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		for _, tx := range []karma.Transaction{
+			{Team: "avengers", From: "ironman", To: "spiderman", Delta: 3, Message: "saving the ferry"},
+			{Team: "avengers", From: "cap", To: "spiderman", Delta: 1},
+			{Team: "avengers", From: "cap", To: "spiderman", Delta: -2, Message: "sassing me"},
+			{Team: "avengers", From: "spiderman", To: "ironman", Delta: 1, Message: "the suit"},
+		} {
+			_, err := dao.UpdateKarmaDaily(tx, date)
+			assert.Nil(t, err)
+		}
+		// resets are not reasons
+		_, err := dao.DeleteKarma("avengers", "spiderman")
+		assert.Nil(t, err)
+
+		reasons, err := dao.Reasons("avengers", "spiderman", 5)
+		assert.Nil(t, err)
+		assert.Len(t, reasons, 2)
+		assert.Equal(t, "sassing me", reasons[0].Message)
+		assert.Equal(t, -2, reasons[0].Delta)
+		assert.Equal(t, "saving the ferry", reasons[1].Message)
+
+		reasons, err = dao.Reasons("avengers", "spiderman", 1)
+		assert.Nil(t, err)
+		assert.Len(t, reasons, 1)
+	})
+}
+
+func TestLedgerPairHistory(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		for _, tx := range []karma.Transaction{
+			{Team: "avengers", From: "ironman", To: "spiderman", Delta: 3},
+			{Team: "avengers", From: "cap", To: "spiderman", Delta: 2},
+			{Team: "avengers", From: "ironman", To: "spiderman", Delta: -1},
+			{Team: "avengers", From: "ironman", To: "hulk", Delta: 4},
+		} {
+			_, err := dao.UpdateKarmaDaily(tx, date)
+			assert.Nil(t, err)
+		}
+		// adjusted through the api, not given by anyone
+		_, err := dao.UpdateKarma("avengers", "spiderman", 10)
+		assert.Nil(t, err)
+
+		history, err := dao.PairHistory("avengers", "ironman", "spiderman", time.Now().Add(-time.Hour))
+		assert.Nil(t, err)
+		assert.Len(t, history, 2)
+		// oldest first
+		assert.Equal(t, 3, history[0].Delta)
+		assert.Equal(t, -1, history[1].Delta)
+
+		history, err = dao.PairHistory("avengers", "ironman", "spiderman", time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Empty(t, history)
+
+		received, err := dao.ReceivedSince("avengers", "spiderman", time.Now().Add(-time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, 5, received)
+
+		received, err = dao.ReceivedSince("avengers", "spiderman", time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Zero(t, received)
+	})
+}
+
+func TestLedgerGivenSince(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		now := time.Date(2026, time.May, 4, 15, 0, 0, 0, time.UTC)
+		for _, tx := range []struct {
+			karma.Transaction
+			ago time.Duration
+		}{
+			{karma.Transaction{Team: "avengers", From: "ironman", To: "spiderman", Delta: 3}, 20 * time.Hour},
+			{karma.Transaction{Team: "avengers", From: "ironman", To: "hulk", Delta: -2}, 10 * time.Hour},
+			{karma.Transaction{Team: "avengers", From: "cap", To: "hulk", Delta: 1}, 10 * time.Hour},
+			{karma.Transaction{Team: "avengers", From: "ironman", To: "spiderman", Delta: 1}, 0},
+		} {
+			// the ledger is stamped with the dao's clock
+			at := now.Add(-tx.ago)
+			_, err := dao.(karma.SQLDAO).WithClock(karma.FixedClock(at)).UpdateKarmaDaily(tx.Transaction, at)
+			assert.Nil(t, err)
+		}
+
+		given, err := dao.GivenSince("avengers", "ironman", now.Add(-15*time.Hour))
+		assert.Nil(t, err)
+		if assert.Len(t, given, 2) {
+			// oldest first
+			assert.Equal(t, "hulk", given[0].To)
+			assert.True(t, now.Add(-10*time.Hour).Equal(given[0].CreatedAt), given[0].CreatedAt)
+			assert.Equal(t, "spiderman", given[1].To)
+			assert.True(t, now.Equal(given[1].CreatedAt), given[1].CreatedAt)
+		}
+
+		given, err = dao.GivenSince("avengers", "ironman", now.Add(-24*time.Hour))
+		assert.Nil(t, err)
+		assert.Len(t, given, 3)
+	})
+}
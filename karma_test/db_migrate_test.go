@@ -0,0 +1,197 @@
+package karma_test
+
+import (
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	_, err := db.Exec("SELECT count(*) FROM " + table)
+	return err == nil
+}
+
+func TestMigrations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping db integration test")
+	}
+
+	db, _, err := setupDB(testDB)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	m, err := karma.NewMigrator(db, karma.DriverSQLite)
+	assert.Nil(t, err)
+
+	// InitDB applied everything
+	status, err := m.Status()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, status)
+	for _, s := range status {
+		assert.NotNil(t, s.AppliedAt, s.Name)
+	}
+	latest := status[len(status)-1].Version
+	version, err := m.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, latest, version)
+
+	n, err := m.Up()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	// roll everything back
+	n, err = m.Down(len(status) + 1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(status), n)
+	assert.False(t, tableExists(t, db, "karma"))
+	assert.False(t, tableExists(t, db, "karma_ledger"))
+	version, err = m.Version()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, version)
+
+	// and forward again
+	n, err = m.Up()
+	assert.Nil(t, err)
+	assert.Equal(t, len(status), n)
+	assert.True(t, tableExists(t, db, "karma"))
+	assert.True(t, tableExists(t, db, "karma_ledger"))
+}
+
+func TestMigrationsAdoptExistingDatabase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping db integration test")
+	}
+
+	db, dao, err := setupDB(testDB)
+	assert.Nil(t, err)
+	_, err = dao.UpdateKarma("nycfc", "villa", 7)
+	assert.Nil(t, err)
+
+	// a database from before schema_migrations has the original tables, but no versions
+	m, err := karma.NewMigrator(db, karma.DriverSQLite)
+	assert.Nil(t, err)
+	version, err := m.Version()
+	assert.Nil(t, err)
+	_, err = m.Down(version - 2)
+	assert.Nil(t, err)
+	_, err = db.Exec("DROP TABLE schema_migrations")
+	assert.Nil(t, err)
+	db.Close()
+
+	db, err = karma.InitDB(testDB)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	k, err := karma.NewDao(db).GetKarma("nycfc", "villa")
+	assert.Nil(t, err)
+	assert.Equal(t, 7, k)
+}
+
+func TestMigrationsTeamWordsAnyCase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping db integration test")
+	}
+
+	db, _, err := setupDB(testDB)
+	assert.Nil(t, err)
+	defer db.Close()
+	m, err := karma.NewMigrator(db, karma.DriverSQLite)
+	assert.Nil(t, err)
+
+	// a database from before words were the same in any case
+	_, err = m.Down(1)
+	assert.Nil(t, err)
+	insert := func(word, status string) error {
+		_, err := db.Exec(`INSERT INTO team_words (team, kit, col, word, status, submitted_by, approved_by, created_at) VALUES ('avengers', 'insult', 'B', ?, ?, 'hulk', '', ?)`, word, status, time.Now())
+		return err
+	}
+	assert.Nil(t, insert("Puny", karma.WordPending))
+	assert.Nil(t, insert("puny", karma.WordApproved))
+	assert.Nil(t, insert("PUNY", karma.WordPending))
+	assert.Nil(t, insert("Beetle-Headed", karma.WordPending))
+	assert.Nil(t, insert("beetle-headed", karma.WordPending))
+
+	n, err := m.Up()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	// one of each is kept, the approved one or else the first
+	words, err := karma.NewDao(db).TeamWords("avengers")
+	assert.Nil(t, err)
+	var kept []string
+	for _, w := range words {
+		kept = append(kept, w.Word)
+	}
+	assert.ElementsMatch(t, []string{"puny", "Beetle-Headed"}, kept)
+	assert.NotNil(t, insert("BEETLE-headed", karma.WordPending))
+
+	_, err = m.Down(1)
+	assert.Nil(t, err)
+	assert.Nil(t, insert("BEETLE-headed", karma.WordPending))
+}
+
+func TestMigrationsConcurrentReplicas(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping db integration test")
+	}
+	dsn := os.Getenv(envPostgresDSN)
+	if dsn == "" {
+		t.Skipf("skipping postgres, %v is not set", envPostgresDSN)
+	}
+
+	db, _, err := karma.Open(karma.DriverPostgres, dsn)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer db.Close()
+	m, err := karma.NewMigrator(db, karma.DriverPostgres)
+	assert.Nil(t, err)
+	status, err := m.Status()
+	assert.Nil(t, err)
+	_, err = m.Down(len(status))
+	assert.Nil(t, err)
+
+	// replicas starting at the same time, each with its own connection. One of them migrates, the rest find nothing to do
+	const replicas = 4
+	applied := make(chan int, replicas)
+	errs := make(chan error, replicas)
+	var wg sync.WaitGroup
+	for i := 0; i < replicas; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			replica, err := sql.Open(karma.DriverPostgres, dsn)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer replica.Close()
+
+			m, err := karma.NewMigrator(replica, karma.DriverPostgres)
+			if err != nil {
+				errs <- err
+				return
+			}
+			n, err := m.Up()
+			applied <- n
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(applied)
+	close(errs)
+
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+	var counts []int
+	for n := range applied {
+		counts = append(counts, n)
+	}
+	assert.ElementsMatch(t, []int{len(status), 0, 0, 0}, counts)
+}
@@ -0,0 +1,59 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func insertLedger(t *testing.T, db *sql.DB, tx karma.Transaction) {
+	_, err := db.Exec(`
+		INSERT INTO karma_ledger (team, from_user, to_user, channel, delta, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		tx.Team, tx.From, tx.To, tx.Channel, tx.Delta, tx.Message, tx.CreatedAt.UTC())
+	assert.Nil(t, err)
+}
+
+func TestReport(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		sept := time.Date(2026, time.September, 15, 12, 0, 0, 0, time.UTC)
+		oct := time.Date(2026, time.October, 2, 12, 0, 0, 0, time.UTC)
+
+		for _, tx := range []karma.Transaction{
+			// september, outside of the report
+			{Team: "avengers", From: "ironman", To: "spiderman", Channel: "CTOWER", Delta: 5, CreatedAt: sept},
+			// october
+			{Team: "avengers", From: "ironman", To: "spiderman", Channel: "CTOWER", Delta: 3, CreatedAt: oct},
+			{Team: "avengers", From: "cap", To: "spiderman", Channel: "CTOWER", Delta: 2, CreatedAt: oct},
+			{Team: "avengers", From: "cap", To: "ironman", Channel: "CTOWER", Delta: -4, CreatedAt: oct},
+			{Team: "avengers", From: "thor", To: "hulk", Channel: "CASGARD", Delta: 1, CreatedAt: oct},
+			{Team: "avengers", From: "ironman", To: "hulk", Channel: "CLAB", Delta: 4, CreatedAt: oct},
+			// rest api adjustments are not part of the report
+			{Team: "avengers", From: "", To: "hulk", Channel: "", Delta: 100, CreatedAt: oct},
+			// another team
+			{Team: "xmen", From: "storm", To: "wolverine", Channel: "CMANSION", Delta: 5, CreatedAt: oct},
+		} {
+			insertLedger(t, db, tx)
+		}
+
+		from := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+		r, err := dao.Report("avengers", from, to, 2)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 5, r.Transactions)
+		assert.Equal(t, 6, r.Net)
+		assert.Equal(t, 14, r.Moved)
+		assert.Equal(t, []karma.UserKarma{{User: "hulk", Karma: 5}, {User: "spiderman", Karma: 5}}, r.Gainers)
+		assert.Equal(t, []karma.UserKarma{{User: "ironman", Karma: 7}, {User: "cap", Karma: 2}}, r.Givers)
+		assert.Equal(t, []karma.ChannelKarma{{Channel: "CTOWER", Transactions: 3, Karma: 9}, {Channel: "CASGARD", Transactions: 1, Karma: 1}}, r.Channels)
+
+		empty, err := dao.Report("avengers", to, to.AddDate(0, 1, 0), 2)
+		assert.Nil(t, err)
+		assert.Zero(t, empty.Transactions)
+		assert.Empty(t, empty.Gainers)
+	})
+}
@@ -0,0 +1,91 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeasons(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		start := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+		at := func(t time.Time) karma.DAO {
+			return dao.(karma.SQLDAO).WithClock(karma.FixedClock(t))
+		}
+
+		_, err := dao.CurrentSeason("avengers")
+		assert.ErrorIs(t, err, karma.ErrNoSeason)
+		_, err = dao.EndSeason("avengers", end)
+		assert.ErrorIs(t, err, karma.ErrNoSeason)
+
+		// karma before the season doesn't count
+		before := start.Add(-time.Hour)
+		_, err = at(before).UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "hulk", Delta: 5}, before)
+		assert.Nil(t, err)
+
+		s, err := dao.StartSeason("avengers", "2026Q2", start)
+		assert.Nil(t, err)
+		assert.Equal(t, "2026Q2", s.Name)
+		assert.True(t, start.Equal(s.StartedAt))
+		assert.Nil(t, s.EndedAt)
+
+		_, err = dao.StartSeason("avengers", "another", start)
+		assert.ErrorIs(t, err, karma.ErrSeasonRunning)
+
+		during := start.Add(24 * time.Hour)
+		_, err = at(during).UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "hulk", Delta: 2}, during)
+		assert.Nil(t, err)
+		_, err = at(during).UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "hulk", To: "spiderman", Delta: 3}, during)
+		assert.Nil(t, err)
+		_, err = at(during).UpdateKarmaDaily(karma.Transaction{Team: "justice", From: "batman", To: "superman", Delta: 4}, during)
+		assert.Nil(t, err)
+
+		top, err := dao.SeasonTop("avengers", start, 5)
+		assert.Nil(t, err)
+		assert.Equal(t, []karma.UserKarma{{User: "spiderman", Karma: 3}, {User: "hulk", Karma: 2}}, top)
+
+		running, err := dao.RunningSeasons()
+		assert.Nil(t, err)
+		if assert.Len(t, running, 1) {
+			assert.Equal(t, "avengers", running[0].Team)
+		}
+
+		// the season is archived as it ends, and the next one starts
+		next, err := dao.RolloverSeason("avengers", "2026Q3", end)
+		assert.Nil(t, err)
+		assert.Equal(t, "2026Q3", next.Name)
+
+		ended, err := dao.GetSeason("avengers", "2026Q2")
+		assert.Nil(t, err)
+		if assert.NotNil(t, ended.EndedAt) {
+			assert.True(t, end.Equal(*ended.EndedAt))
+		}
+
+		archived, err := dao.ArchivedTop("avengers", "2026Q2", 1)
+		assert.Nil(t, err)
+		assert.Equal(t, []karma.UserKarma{{User: "spiderman", Karma: 3}}, archived)
+
+		current, err := dao.CurrentSeason("avengers")
+		assert.Nil(t, err)
+		assert.Equal(t, "2026Q3", current.Name)
+
+		_, err = dao.EndSeason("avengers", end.Add(time.Hour))
+		assert.Nil(t, err)
+		_, err = dao.StartSeason("avengers", "2026Q2", end.Add(time.Hour))
+		assert.ErrorIs(t, err, karma.ErrSeasonExists)
+
+		seasons, err := dao.Seasons("avengers")
+		assert.Nil(t, err)
+		if assert.Len(t, seasons, 2) {
+			assert.Equal(t, "2026Q3", seasons[0].Name)
+			assert.Equal(t, "2026Q2", seasons[1].Name)
+		}
+
+		_, err = dao.GetSeason("avengers", "1999Q1")
+		assert.ErrorIs(t, err, karma.ErrNoSeason)
+	})
+}
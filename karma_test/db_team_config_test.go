@@ -0,0 +1,64 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamConfig(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		settings, err := dao.TeamConfig("nycfc")
+		assert.Nil(t, err)
+		assert.Empty(t, settings)
+
+		err = dao.SetTeamConfig("nycfc", "daily_limit", "50", "UADMIN")
+		assert.Nil(t, err)
+		err = dao.SetTeamConfig("nycfc", "daily_limit", "40", "UOTHER")
+		assert.Nil(t, err)
+		err = dao.SetTeamConfig("nycfc", "top_user_max", "20", "UADMIN")
+		assert.Nil(t, err)
+		// other teams are unaffected
+		err = dao.SetTeamConfig("redbulls", "daily_limit", "1", "UADMIN")
+		assert.Nil(t, err)
+
+		settings, err = dao.TeamConfig("nycfc")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"daily_limit": "40", "top_user_max": "20"}, settings)
+
+		err = dao.ResetTeamConfig("nycfc", "daily_limit", "UADMIN")
+		assert.Nil(t, err)
+		settings, err = dao.TeamConfig("nycfc")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"top_user_max": "20"}, settings)
+	})
+}
+
+func TestAudit(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		assert.Nil(t, dao.SetTeamConfig("nycfc", "daily_limit", "50", "UADMIN"))
+		assert.Nil(t, dao.SetTeamConfig("nycfc", "daily_limit", "40", "UOTHER"))
+		assert.Nil(t, dao.ResetTeamConfig("nycfc", "daily_limit", "UADMIN"))
+		assert.Nil(t, dao.SetTeamConfig("redbulls", "daily_limit", "1", "UADMIN"))
+
+		entries, err := dao.Audit("nycfc", 10)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 3)
+
+		// newest first
+		assert.Equal(t, "config reset", entries[0].Action)
+		assert.Equal(t, "from 40 to default", entries[0].Detail)
+		assert.Equal(t, "UOTHER", entries[1].Actor)
+		assert.Equal(t, "from 50 to 40", entries[1].Detail)
+		assert.Equal(t, "config set", entries[2].Action)
+		assert.Equal(t, "daily_limit", entries[2].Target)
+		assert.Equal(t, "from default to 50", entries[2].Detail)
+		assert.False(t, entries[2].CreatedAt.IsZero())
+
+		entries, err = dao.Audit("nycfc", 1)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 1)
+	})
+}
@@ -0,0 +1,54 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThings(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		k, err := dao.GetThing("avengers", "shawarma")
+		assert.Nil(t, err)
+		assert.Zero(t, k)
+
+		for _, tx := range []karma.Transaction{
+			{Team: "avengers", From: "ironman", To: "shawarma", Delta: 3, Message: "after the battle"},
+			{Team: "avengers", From: "cap", To: "shawarma", Delta: 2},
+			{Team: "avengers", From: "thor", To: "#asgard", Delta: 5},
+			{Team: "avengers", From: "loki", To: "#asgard", Delta: -1},
+			{Team: "avengers", From: "hulk", To: "smashing", Delta: -4},
+			{Team: "shield", From: "fury", To: "shawarma", Delta: 1},
+		} {
+			_, err := dao.UpdateThingDaily(tx, date)
+			assert.Nil(t, err)
+		}
+
+		k, err = dao.GetThing("avengers", "shawarma")
+		assert.Nil(t, err)
+		assert.Equal(t, 5, k)
+
+		top, err := dao.TopThings("avengers", 5)
+		assert.Nil(t, err)
+		assert.Equal(t, []karma.ThingKarma{{Thing: "shawarma", Karma: 5}, {Thing: "#asgard", Karma: 4}}, top)
+
+		// things share the daily limit, but are never users
+		usage, err := dao.GetDaily("avengers", "ironman", date)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, usage)
+
+		users, err := dao.Top("avengers", 5)
+		assert.Nil(t, err)
+		assert.Empty(t, users)
+
+		given, err := dao.GivenSince("avengers", "ironman", time.Now().Add(-time.Hour))
+		assert.Nil(t, err)
+		if assert.Len(t, given, 1) {
+			assert.Equal(t, "shawarma", given[0].To)
+			assert.Equal(t, "after the battle", given[0].Message)
+		}
+	})
+}
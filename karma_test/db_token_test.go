@@ -0,0 +1,51 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokens(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		_, err := dao.TokenByHash(karma.HashToken("kb_nope"))
+		assert.ErrorIs(t, err, karma.ErrUnknownToken)
+
+		ci, err := dao.CreateToken(karma.APIToken{Name: "ci", Team: "avengers", Scopes: []karma.Scope{karma.ScopeRead, karma.ScopeWrite}}, karma.HashToken("kb_ci"))
+		assert.Nil(t, err)
+		assert.NotZero(t, ci.ID)
+		ops, err := dao.CreateToken(karma.APIToken{Name: "ops", Team: karma.AllTeams, Scopes: []karma.Scope{karma.ScopeAdmin}}, karma.HashToken("kb_ops"))
+		assert.Nil(t, err)
+		assert.NotEqual(t, ci.ID, ops.ID)
+
+		token, err := dao.TokenByHash(karma.HashToken("kb_ci"))
+		assert.Nil(t, err)
+		assert.Equal(t, ci.ID, token.ID)
+		assert.Equal(t, "ci", token.Name)
+		assert.Equal(t, "avengers", token.Team)
+		assert.Equal(t, []karma.Scope{karma.ScopeRead, karma.ScopeWrite}, token.Scopes)
+		assert.False(t, token.CreatedAt.IsZero())
+		assert.Nil(t, token.RevokedAt)
+
+		tokens, err := dao.Tokens("avengers")
+		assert.Nil(t, err)
+		assert.Len(t, tokens, 1)
+		tokens, err = dao.Tokens("")
+		assert.Nil(t, err)
+		assert.Len(t, tokens, 2)
+
+		assert.Nil(t, dao.RevokeToken(ci.ID))
+		_, err = dao.TokenByHash(karma.HashToken("kb_ci"))
+		assert.ErrorIs(t, err, karma.ErrUnknownToken)
+		assert.ErrorIs(t, dao.RevokeToken(ci.ID), karma.ErrUnknownToken)
+		assert.ErrorIs(t, dao.RevokeToken(1000), karma.ErrUnknownToken)
+
+		// revoked tokens are still listed
+		tokens, err = dao.Tokens("avengers")
+		assert.Nil(t, err)
+		assert.Len(t, tokens, 1)
+		assert.NotNil(t, tokens[0].RevokedAt)
+	})
+}
@@ -0,0 +1,109 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndo(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		now := time.Date(2026, time.May, 4, 15, 0, 0, 0, time.UTC)
+		at := func(ago time.Duration) karma.DAO {
+			return dao.(karma.SQLDAO).WithClock(karma.FixedClock(now.Add(-ago)))
+		}
+
+		_, err := at(time.Hour).UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "hulk", Delta: 3}, now)
+		assert.Nil(t, err)
+		// several at once are one action
+		_, err = at(2*time.Minute).UpdateKarmaDailyMany([]karma.Transaction{
+			{Team: "avengers", From: "ironman", To: "spiderman", Delta: -2},
+			{Team: "avengers", From: "ironman", To: "hulk", Delta: -2},
+		}, now)
+		assert.Nil(t, err)
+		_, err = at(time.Minute).UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "cap", To: "hulk", Delta: 1}, now)
+		assert.Nil(t, err)
+
+		last, err := dao.LastGiven("avengers", "ironman", now.Add(-5*time.Minute))
+		assert.Nil(t, err)
+		if assert.Len(t, last, 2) {
+			assert.Equal(t, "spiderman", last[0].To)
+			assert.Equal(t, "hulk", last[1].To)
+		}
+
+		ks, err := dao.Undo(last, now)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{0, 4}, ks)
+
+		usage, err := dao.GetDaily("avengers", "ironman", now)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, usage)
+
+		// the ledger keeps the karma that was undone, and the karma that reversed it
+		assert.Equal(t, 6, rowCountLedger(t, db))
+		var reversed int
+		err = db.QueryRow(`SELECT COALESCE(SUM(delta), 0) FROM karma_ledger WHERE reverses = $1 OR id = $1`, last[0].ID).Scan(&reversed)
+		assert.Nil(t, err)
+		assert.Zero(t, reversed)
+		given, err := dao.Given("avengers", "ironman", 10, 0)
+		assert.Nil(t, err)
+		assert.Len(t, given, 5)
+
+		// reports and seasons leave it out
+		report, err := dao.Report("avengers", now.Add(-time.Hour), now.Add(time.Hour), 5)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, report.Transactions)
+		assert.Equal(t, 4, report.Net)
+		assert.Equal(t, []karma.UserKarma{{User: "ironman", Karma: 3}, {User: "cap", Karma: 1}}, report.Givers)
+		top, err := dao.SeasonTop("avengers", now.Add(-time.Hour), 5)
+		assert.Nil(t, err)
+		assert.Equal(t, []karma.UserKarma{{User: "hulk", Karma: 4}}, top)
+
+		// the same karma can't be undone twice
+		_, err = dao.Undo(last, now)
+		assert.ErrorIs(t, err, karma.ErrAlreadyUndone)
+
+		// the karma before it is outside the window
+		last, err = dao.LastGiven("avengers", "ironman", now.Add(-5*time.Minute))
+		assert.Nil(t, err)
+		assert.Empty(t, last)
+
+		// and the karma before that, when it is in the window
+		last, err = dao.LastGiven("avengers", "ironman", now.Add(-2*time.Hour))
+		assert.Nil(t, err)
+		if assert.Len(t, last, 1) {
+			assert.Equal(t, 3, last[0].Delta)
+		}
+	})
+}
+
+func TestUndoAfterThing(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		now := time.Date(2026, time.May, 4, 15, 0, 0, 0, time.UTC)
+		at := func(ago time.Duration) karma.DAO {
+			return dao.(karma.SQLDAO).WithClock(karma.FixedClock(now.Add(-ago)))
+		}
+
+		_, err := at(2*time.Minute).UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "hulk", Delta: 3}, now)
+		assert.Nil(t, err)
+		_, err = at(time.Minute).UpdateThingDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "shawarma", Delta: 1}, now)
+		assert.Nil(t, err)
+
+		// the thing is the most recent, so the karma to hulk before it isn't undone instead
+		last, err := dao.LastGiven("avengers", "ironman", now.Add(-5*time.Minute))
+		assert.ErrorIs(t, err, karma.ErrUndoThing)
+		assert.Empty(t, last)
+
+		// karma to a user after the thing can be
+		_, err = at(30*time.Second).UpdateKarmaDaily(karma.Transaction{Team: "avengers", From: "ironman", To: "thor", Delta: 1}, now)
+		assert.Nil(t, err)
+		last, err = dao.LastGiven("avengers", "ironman", now.Add(-5*time.Minute))
+		assert.Nil(t, err)
+		if assert.Len(t, last, 1) {
+			assert.Equal(t, "thor", last[0].To)
+		}
+	})
+}
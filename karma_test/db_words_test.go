@@ -0,0 +1,64 @@
+package karma_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamWords(t *testing.T) {
+	eachBackend(t, func(t *testing.T, db *sql.DB, dao karma.DAO) {
+		words, err := dao.TeamWords("avengers")
+		assert.Nil(t, err)
+		assert.Empty(t, words)
+
+		beetle, err := dao.AddTeamWord(karma.TeamWord{Team: "avengers", Kit: "insult", Column: "B", Word: "beetle-headed", Status: karma.WordPending, SubmittedBy: "hulk"})
+		assert.Nil(t, err)
+		assert.NotZero(t, beetle.ID)
+		_, err = dao.AddTeamWord(karma.TeamWord{Team: "avengers", Kit: "insult", Column: "A", Word: "puny", Status: karma.WordApproved, SubmittedBy: "thor", ApprovedBy: "thor"})
+		assert.Nil(t, err)
+		// another team may have the same word
+		_, err = dao.AddTeamWord(karma.TeamWord{Team: "justice", Kit: "insult", Column: "B", Word: "beetle-headed", Status: karma.WordPending, SubmittedBy: "batman"})
+		assert.Nil(t, err)
+
+		_, err = dao.AddTeamWord(karma.TeamWord{Team: "avengers", Kit: "insult", Column: "B", Word: "beetle-headed", Status: karma.WordPending, SubmittedBy: "loki"})
+		assert.ErrorIs(t, err, karma.ErrWordExists)
+		// in any case
+		_, err = dao.AddTeamWord(karma.TeamWord{Team: "avengers", Kit: "insult", Column: "B", Word: "Beetle-Headed", Status: karma.WordPending, SubmittedBy: "loki"})
+		assert.ErrorIs(t, err, karma.ErrWordExists)
+
+		words, err = dao.TeamWords("avengers")
+		assert.Nil(t, err)
+		if assert.Len(t, words, 2) {
+			assert.Equal(t, "puny", words[0].Word)
+			assert.Equal(t, "thor", words[0].ApprovedBy)
+			assert.Equal(t, beetle.ID, words[1].ID)
+			assert.Equal(t, karma.WordPending, words[1].Status)
+			assert.Equal(t, "", words[1].ApprovedBy)
+		}
+
+		approved, err := dao.ApproveTeamWord("avengers", beetle.ID, "thor")
+		assert.Nil(t, err)
+		assert.Equal(t, karma.WordApproved, approved.Status)
+		assert.Equal(t, "thor", approved.ApprovedBy)
+
+		// a word is only found in its own team
+		_, err = dao.ApproveTeamWord("justice", beetle.ID, "batman")
+		assert.ErrorIs(t, err, karma.ErrNoWord)
+
+		removed, err := dao.RemoveTeamWord("avengers", beetle.ID, "thor")
+		assert.Nil(t, err)
+		assert.Equal(t, "beetle-headed", removed.Word)
+		_, err = dao.RemoveTeamWord("avengers", beetle.ID, "thor")
+		assert.ErrorIs(t, err, karma.ErrNoWord)
+
+		audit, err := dao.Audit("avengers", 5)
+		assert.Nil(t, err)
+		if assert.Len(t, audit, 2) {
+			actions := []string{audit[0].Action, audit[1].Action}
+			assert.ElementsMatch(t, []string{"word approve", "word remove"}, actions)
+		}
+	})
+}
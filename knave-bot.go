@@ -1,34 +1,66 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // team timezones load even where the host has no zoneinfo
 
+	"github.com/icemanblues/knave-bot/config"
 	"github.com/icemanblues/knave-bot/karma"
 	"github.com/icemanblues/knave-bot/knave"
 	"github.com/icemanblues/knave-bot/shakespeare"
+	"github.com/icemanblues/knave-bot/slack"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
 
-func logger() {
-	log.SetFormatter(&log.TextFormatter{
-		FullTimestamp: true,
-	})
+func logger(c config.Log) {
+	if c.Format == config.FormatJSON {
+		log.SetFormatter(&log.JSONFormatter{})
+	} else {
+		log.SetFormatter(&log.TextFormatter{
+			FullTimestamp: true,
+		})
+	}
 	log.SetOutput(os.Stdout)
-	log.SetLevel(log.InfoLevel)
+	level, _ := log.ParseLevel(c.Level)
+	log.SetLevel(level)
 	log.SetReportCaller(true) // This could have performance impact
 }
 
 // InitKarma initializes the components and wires them together, for Karma and Knave bot
-func initKarma(insult, compliment shakespeare.Generator, config karma.ProcConfig, dao karma.DAO) (knave.Handler, karma.Handler) {
-	karmaProc := karma.NewProcessor(config, dao, insult, compliment)
+func initKarma(insult, compliment shakespeare.Generator, kits knave.Kits, grammar shakespeare.Grammar, config karma.ProcConfig, dao karma.DAO, client slack.Client, dir slack.Directory) (knave.Handler, karma.Handler, karma.SlackProcessor) {
+	karmaProc := karma.NewProcessor(config, dao, insult, compliment).WithDirectory(dir)
+
+	// teams add their own words to the kits, and their admins approve them
+	knave := knave.NewHandler(insult, compliment).WithWords(dao, kits, karmaProc).WithGrammar(grammar)
+	karma := karma.NewHandler(karmaProc, dao, client)
+
+	return knave, karma, karmaProc
+}
 
-	knave := knave.NewHandler(insult, compliment)
-	karma := karma.NewHandler(karmaProc, dao)
+// loadGrammar the grammar `/knave` insults are made from, checked against the insult kit's words. None without a file
+func loadGrammar(file string, kits knave.Kits) (shakespeare.Grammar, error) {
+	if file == "" {
+		return shakespeare.Grammar{}, nil
+	}
+	g, err := shakespeare.LoadGrammar(file)
+	if err != nil {
+		return shakespeare.Grammar{}, err
+	}
 
-	return knave, karma
+	c, _ := kits.Corpus(shakespeare.InsultName)
+	if _, err := shakespeare.NewGrammarGenerator(c.Grammar().With(g)); err != nil {
+		return shakespeare.Grammar{}, err
+	}
+	return g, nil
 }
 
 func initGin() *gin.Engine {
@@ -40,25 +72,104 @@ func initGin() *gin.Engine {
 }
 
 func main() {
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// the migrate subcommand only needs the database
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := cfg.Database.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+			os.Exit(2)
+		}
+		if err := runMigrate(args[1:], cfg.Database.Driver, cfg.Database.DSN, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// so does the token subcommand
+	if len(args) > 0 && args[0] == "token" {
+		if err := cfg.Database.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+			os.Exit(2)
+		}
+		_, dao, err := karma.Open(cfg.Database.Driver, cfg.Database.DSN)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := runToken(args[1:], dao, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+
 	// initialize logger
-	logger()
-	log.Infof("Insult    : %v", shakespeare.Insult())
-	log.Infof("Compliment: %v", shakespeare.Compliment())
+	logger(cfg.Log)
+
+	// the built in words, with the team's own word lists merged in
+	words, err := shakespeare.NewLibrary(cfg.Words.Files...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid word lists:\n%v\n", err)
+		os.Exit(2)
+	}
+	grammar, err := loadGrammar(cfg.Words.Grammar, words)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid grammar:\n%v\n", err)
+		os.Exit(2)
+	}
+	insult, _ := words.Generator(shakespeare.InsultName)
+	compliment, _ := words.Generator(shakespeare.ComplimentName)
+	log.Infof("Insult    : %v", insult.Sentence())
+	log.Infof("Compliment: %v", compliment.Sentence())
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		if err := words.Watch(context.Background(), hup); err != nil {
+			log.Errorf("Unable to watch the word lists, they won't be reloaded: %v", err)
+		}
+	}()
 
-	// initialize database
-	db, err := karma.InitDB("/var/lib/sqlite/karma.db")
+	// initialize database. sqlite by default, postgres for running several replicas
+	_, dao, err := karma.Open(cfg.Database.Driver, cfg.Database.DSN)
 	if err != nil {
 		log.Panic("Unable to initialize the database", err)
 		panic(err)
 	}
-	dao := karma.NewDao(db)
 
-	procConfig := karma.DefaultConfig
-	knaveHandler, karmaHandler := initKarma(shakespeare.InsultGenerator, shakespeare.ComplimentGenerator, procConfig, dao)
+	// the bot token is used to reply to messages from the events api
+	client := slack.NewWebClient(cfg.Slack.BotToken)
+
+	knaveHandler, karmaHandler, karmaProc := initKarma(insult, compliment, words, grammar, cfg.Karma, dao, client, client)
+
+	// seasons roll over at the end of their month or quarter, for the teams that want them to
+	go karma.RunSeasonRollover(context.Background(), karmaProc, karma.SeasonCheckInterval)
+
+	// slack signs every request with the app's signing secret
+	verifier := slack.NewVerifier(cfg.Slack.SigningSecret, slack.DefaultReplayWindow)
+
+	// the REST api needs a bearer token, minted with `knave-bot token create`
+	auth := karma.NewTokenAuth(dao)
 
 	r := initGin()
-	BindRoutes(r, knaveHandler, karmaHandler)
+	BindRoutes(r, knaveHandler, karmaHandler, verifier, auth)
 
-	// listen and serve on 0.0.0.0:8080
-	r.Run()
+	log.Infof("Listening on %v", cfg.Server.Addr)
+	if err := r.Run(cfg.Server.Addr); err != nil {
+		log.Fatal(err)
+	}
 }
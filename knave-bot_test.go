@@ -4,16 +4,22 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/icemanblues/knave-bot/karma"
 	"github.com/icemanblues/knave-bot/shakespeare"
+	"github.com/icemanblues/knave-bot/slack"
 	"github.com/stretchr/testify/assert"
 )
 
 const testDB = "var/test/functional.db"
 
+const testSecret = "functional-signing-secret"
+
+const testToken = "functional-api-token"
+
 func setupDB(datasource string) (karma.DAO, error) {
 	if err := os.RemoveAll(datasource); err != nil {
 		return nil, err
@@ -31,11 +37,18 @@ func setup(t *testing.T) *gin.Engine {
 	dao, err := setupDB(testDB)
 	assert.Nil(t, err)
 
+	_, err = dao.CreateToken(karma.APIToken{Name: "functional", Team: "team1", Scopes: []karma.Scope{karma.ScopeWrite}}, karma.HashToken(testToken))
+	assert.Nil(t, err)
+
+	auth := karma.NewTokenAuth(dao)
+
 	insult := shakespeare.New("insult", "", nil)
 	compliment := shakespeare.New("compliment", "", nil)
-	knave, karma := initKarma(insult, compliment, karma.DefaultConfig, dao)
+	kits, err := shakespeare.NewLibrary()
+	assert.Nil(t, err)
+	knave, karma, _ := initKarma(insult, compliment, kits, shakespeare.Grammar{}, karma.DefaultConfig, dao, slack.NewFakeClient(), slack.NewFakeClient())
 	r := initGin()
-	BindRoutes(r, knave, karma)
+	BindRoutes(r, knave, karma, slack.NewVerifier(testSecret, 0), auth)
 	return r
 }
 
@@ -75,7 +88,7 @@ func TestKnaveSlashCommand(t *testing.T) {
 	r := setup(t)
 
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/knavebot/v1/cmd/knave", nil)
+	req, _ := slack.NewSignedRequest("POST", "/knavebot/v1/cmd/knave", testSecret, nil)
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
@@ -83,6 +96,30 @@ func TestKnaveSlashCommand(t *testing.T) {
 	// assert.Equal(t, "insult", w.Body.String())
 }
 
+func TestKnaveWords(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping functional test")
+	}
+
+	r := setup(t)
+	w := httptest.NewRecorder()
+	body := strings.NewReader(`{"kit": "insult", "column": "b", "word": "beetle-headed"}`)
+	req, _ := http.NewRequest("POST", "/knavebot/v1/team/team1/words", body)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 201, w.Code)
+	assert.Contains(t, w.Body.String(), `"column":"B","word":"beetle-headed","status":"pending","submitted_by":"token:1 (functional)"`)
+
+	// approving needs the admin scope
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/knavebot/v1/team/team1/words/1", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 403, w.Code)
+}
+
 func TestKarmaPut(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping functional test")
@@ -91,6 +128,7 @@ func TestKarmaPut(t *testing.T) {
 	r := setup(t)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/karmabot/v1/team/team1/playerB?delta=3", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
@@ -105,6 +143,7 @@ func TestKarmaDelete(t *testing.T) {
 	r := setup(t)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("DELETE", "/karmabot/v1/team/team1/playerD", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
@@ -119,6 +158,7 @@ func TestKarmaGet(t *testing.T) {
 	r := setup(t)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/karmabot/v1/team/team1/playerA", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
@@ -132,9 +172,42 @@ func TestKarmaSlashCommand(t *testing.T) {
 
 	r := setup(t)
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/knavebot/v1/cmd/karma", nil)
+	req, _ := slack.NewSignedRequest("POST", "/knavebot/v1/cmd/karma", testSecret, nil)
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
 	// TODO: check that it is returning the help message
 }
+
+func TestLoadGrammar(t *testing.T) {
+	kits, err := shakespeare.NewLibrary()
+	assert.Nil(t, err)
+
+	g, err := loadGrammar("knave-bot.grammar.example.yaml", kits)
+	assert.Nil(t, err)
+	c, _ := kits.Corpus(shakespeare.InsultName)
+	gen, err := shakespeare.NewGrammarGenerator(c.Grammar().With(g))
+	assert.Nil(t, err)
+	seen := make(map[string]bool)
+	for seed := int64(0); seed < 200; seed++ {
+		s := gen.SentenceFor(seed)
+		assert.NotContains(t, s, "a/an")
+		assert.NotContains(t, s, "  ")
+		seen[s[:4]] = true
+	}
+	assert.Equal(t, map[string]bool{"Thou": true, "Away": true, "Out ": true}, seen)
+
+	// no file, no grammar
+	g, err = loadGrammar("", kits)
+	assert.Nil(t, err)
+	assert.Empty(t, g.Rules)
+
+	// a rule the kit doesn't have
+	file := t.TempDir() + "/bad.yaml"
+	assert.Nil(t, os.WriteFile(file, []byte("rules:\n  sentence: [Thou <D>!]\n"), 0o644))
+	_, err = loadGrammar(file, kits)
+	assert.NotNil(t, err)
+
+	_, err = loadGrammar(t.TempDir()+"/missing.yaml", kits)
+	assert.NotNil(t, err)
+}
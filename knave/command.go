@@ -0,0 +1,127 @@
+package knave
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// sub-commands of `/knave`
+const (
+	insult     = "insult"
+	compliment = "compliment"
+	practice   = "practice"
+	help       = "help"
+	words      = "words"
+)
+
+// Command Examples
+const (
+	cmdInsult     = "/knave @user"
+	cmdCompliment = "/knave compliment @user"
+	cmdPractice   = "/knave practice [compliment] [@user]"
+	cmdWords      = "/knave words add insult B \"beetle-headed\""
+	cmdHelp       = "/knave help"
+)
+
+// ResponseHelp the slack response for the HELP command
+var ResponseHelp = slack.Response{
+	ResponseType: slack.ResponseType.Ephemeral,
+	Attachments: []slack.Attachments{
+		{
+			Fallback: "*Help* Helpful information on how to insult with Shakespearean candor.",
+			Title:    "Helpful information on how to insult with Shakespearean candor.",
+			Text:     "Below are the sub-commands:",
+			Fields: []slack.Field{
+				{
+					Title: cmdInsult,
+					Value: "Insult a @user in front of the channel. Leave out the @user to insult nobody in particular.",
+					Short: true,
+				},
+				{
+					Title: cmdCompliment,
+					Value: "Compliment a @user in front of the channel, or nobody in particular.",
+					Short: true,
+				},
+				{
+					Title: cmdPractice,
+					Value: "Try out an insult, or a compliment, where only you can see it.",
+					Short: true,
+				},
+				{
+					Title: cmdWords,
+					Value: "Add your team's own words to a column of the insult or compliment kit. An admin approves them. Also `remove`, `approve` and `list`.",
+					Short: true,
+				},
+				{
+					Title: cmdHelp,
+					Value: "This helpful dialogue. Thou art welcome!",
+					Short: true,
+				},
+			},
+		},
+	},
+}
+
+const msgPractice = "Only you can see this. Practice makes perfect:"
+
+// MsgUnknown the reply to a sub-command that knave doesn't know
+func MsgUnknown(word string) string {
+	return fmt.Sprintf("I know not what `%v` means. Try `%v`.", word, cmdHelp)
+}
+
+// MsgTargeted a sentence aimed at a user by the callee
+func MsgTargeted(callee, target, sentence string) string {
+	return fmt.Sprintf("<@%v> to <@%v>: %v", callee, target, sentence)
+}
+
+// Process a `/knave` slash command: who it is aimed at, and whether it is an insult or a compliment
+func (g GinHandler) Process(data slack.CommandData) (slack.Response, error) {
+	args := strings.Fields(data.Text)
+
+	isPractice := len(args) > 0 && strings.EqualFold(args[0], practice)
+	if isPractice {
+		args = args[1:]
+	}
+
+	kit := insult
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case help:
+			return ResponseHelp, nil
+		case words:
+			if !isPractice {
+				return g.teamWords(data, args[1:])
+			}
+		case insult:
+			args = args[1:]
+		case compliment:
+			kit = compliment
+			args = args[1:]
+		}
+	}
+
+	var target string
+	if len(args) > 0 {
+		user, ok := slack.IsSlackUser(args[0])
+		if !ok || len(args) > 1 {
+			return slack.ErrorResponse(MsgUnknown(strings.Join(args, " "))), nil
+		}
+		target = user
+	}
+
+	gen, err := g.generator(data.TeamID, kit)
+	if err != nil {
+		return slack.Response{}, err
+	}
+	sentence := gen.Sentence()
+	if target != "" {
+		sentence = MsgTargeted(data.UserID, target, sentence)
+	}
+
+	if isPractice {
+		return slack.DirectResponse(msgPractice, sentence), nil
+	}
+	return slack.ChannelResponse(sentence), nil
+}
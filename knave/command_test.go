@@ -0,0 +1,51 @@
+package knave
+
+import (
+	"testing"
+
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func command(text string) slack.CommandData {
+	return slack.CommandData{
+		Command: "/knave",
+		Text:    text,
+		TeamID:  "nycfc",
+		UserID:  "UCALLER",
+	}
+}
+
+func TestProcess(t *testing.T) {
+	h := setupHandler()
+
+	testcases := []struct {
+		name     string
+		text     string
+		expected slack.Response
+	}{
+		{"no text", "", slack.ChannelResponse("insult")},
+		{"insult a user", "<@USER|user>", slack.ChannelResponse("<@UCALLER> to <@USER>: insult")},
+		{"insult a user explicitly", "insult <@USER>", slack.ChannelResponse("<@UCALLER> to <@USER>: insult")},
+		{"compliment", "compliment", slack.ChannelResponse("compliment")},
+		{"compliment a user", "Compliment <@USER>", slack.ChannelResponse("<@UCALLER> to <@USER>: compliment")},
+		{"practice", "practice", slack.DirectResponse(msgPractice, "insult")},
+		{"practice on a user", "practice <@USER>", slack.DirectResponse(msgPractice, "<@UCALLER> to <@USER>: insult")},
+		{"practice a compliment", "practice compliment <@USER>", slack.DirectResponse(msgPractice, "<@UCALLER> to <@USER>: compliment")},
+		{"help", "help", ResponseHelp},
+		{"practice help", "practice help", ResponseHelp},
+		{"unknown", "dance", slack.ErrorResponse(MsgUnknown("dance"))},
+		{"not a user", "compliment everyone", slack.ErrorResponse(MsgUnknown("everyone"))},
+		{"too many users", "<@USER> <@OTHER>", slack.ErrorResponse(MsgUnknown("<@USER> <@OTHER>"))},
+		{"no words without a database", "words list", slack.ErrorResponse(msgWordsOff)},
+		{"no practicing words", "practice words", slack.ErrorResponse(MsgUnknown("words"))},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := h.Process(command(test.text))
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -1,9 +1,16 @@
 package knave
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/icemanblues/knave-bot/karma"
 	"github.com/icemanblues/knave-bot/shakespeare"
+	"github.com/icemanblues/knave-bot/slack"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 // Handler handler functions interface
@@ -11,12 +18,20 @@ type Handler interface {
 	Insult(c *gin.Context)
 	Compliment(c *gin.Context)
 	SlashKnave(c *gin.Context)
+	TeamWords(c *gin.Context)
+	AddTeamWord(c *gin.Context)
+	ApproveTeamWord(c *gin.Context)
+	RemoveTeamWord(c *gin.Context)
 }
 
 // GinHandler an implementation using Gin
 type GinHandler struct {
 	insult     shakespeare.Generator
 	compliment shakespeare.Generator
+	words      WordStore
+	kits       Kits
+	admins     Admins
+	grammar    shakespeare.Grammar
 }
 
 // Insult handler function to generate an insult
@@ -29,11 +44,170 @@ func (g GinHandler) Compliment(c *gin.Context) {
 	c.String(200, "%s", g.compliment.Sentence())
 }
 
+var responseUnknownError = slack.ErrorResponse("Alas! Something is rotten in the state of this knave. Try again anon.")
+
 // SlashKnave handler function for slash-command `/knave`
 func (g GinHandler) SlashKnave(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"text":          g.insult.Sentence(),
-		"response_type": "in_channel",
+	data := slack.CommandData{
+		Command:      c.PostForm("command"),
+		Text:         c.PostForm("text"),
+		ResponseURL:  c.PostForm("response_url"),
+		EnterpriseID: c.PostForm("enterprise_id"),
+		TeamID:       c.PostForm("team_id"),
+		ChannelID:    c.PostForm("channel_id"),
+		UserID:       c.PostForm("user_id"),
+	}
+
+	response, err := g.Process(data)
+	if err != nil {
+		log.Errorf("Could not process a slack slash command. %v %v", data, err)
+		response = responseUnknownError
+	}
+
+	c.JSON(200, response)
+}
+
+// TeamWordRequest the body to add a word to a team's kit. The api token that adds it is its submitter
+type TeamWordRequest struct {
+	Kit    string `json:"kit"`
+	Column string `json:"column"`
+	Word   string `json:"word"`
+}
+
+// TeamWords handler method for all of a team's words, pending and approved
+func (g GinHandler) TeamWords(c *gin.Context) {
+	team := c.Param("team")
+	if g.words == nil {
+		abortError(c, 404, msgWordsOff)
+		return
+	}
+
+	words, err := g.words.TeamWords(team)
+	if err != nil {
+		log.Errorf("Unable to lookup team words. %v %v", team, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+	if words == nil {
+		words = []karma.TeamWord{}
+	}
+
+	c.JSON(200, words)
+}
+
+// AddTeamWord handler method to submit a word for a team's kit. It is pending until an admin approves it
+func (g GinHandler) AddTeamWord(c *gin.Context) {
+	team := c.Param("team")
+	if g.words == nil {
+		abortError(c, 404, msgWordsOff)
+		return
+	}
+
+	actor, ok := requestActor(c)
+	if !ok {
+		return
+	}
+
+	var req TeamWordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortError(c, 400, `Please pass the word. {"kit": "insult", "column": "B", "word": "beetle-headed"}`)
+		return
+	}
+	w, err := g.NewTeamWord(team, req.Kit, req.Column, req.Word, actor)
+	if err != nil {
+		abortError(c, 400, err.Error())
+		return
+	}
+
+	added, err := g.words.AddTeamWord(w)
+	if errors.Is(err, karma.ErrWordExists) {
+		abortError(c, 409, err.Error())
+		return
+	}
+	if err != nil {
+		log.Errorf("Unable to add a team word. %v %v %v", team, w, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(201, added)
+}
+
+// ApproveTeamWord handler method to approve a team's pending word. The api token is recorded in the audit trail
+func (g GinHandler) ApproveTeamWord(c *gin.Context) {
+	team := c.Param("team")
+	id, ok := g.wordID(c)
+	if !ok {
+		return
+	}
+	actor, ok := requestActor(c)
+	if !ok {
+		return
+	}
+
+	w, err := g.words.ApproveTeamWord(team, id, actor)
+	g.wordChanged(c, team, id, w, err)
+}
+
+// RemoveTeamWord handler method to remove a team's word. The api token is recorded in the audit trail
+func (g GinHandler) RemoveTeamWord(c *gin.Context) {
+	team := c.Param("team")
+	id, ok := g.wordID(c)
+	if !ok {
+		return
+	}
+	actor, ok := requestActor(c)
+	if !ok {
+		return
+	}
+
+	w, err := g.words.RemoveTeamWord(team, id, actor)
+	g.wordChanged(c, team, id, w, err)
+}
+
+// requestActor the api token that made the request, which is who the audit trail says did it
+func requestActor(c *gin.Context) (string, bool) {
+	actor, ok := karma.RequestActor(c)
+	if !ok {
+		abortError(c, 401, "Please pass an api token. Authorization: Bearer <token>")
+	}
+	return actor, ok
+}
+
+func (g GinHandler) wordID(c *gin.Context) (int64, bool) {
+	if g.words == nil {
+		abortError(c, 404, msgWordsOff)
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		abortError(c, 400, "Please pass the word's id. "+c.Param("id"))
+		return 0, false
+	}
+	return id, true
+}
+
+func (g GinHandler) wordChanged(c *gin.Context, team string, id int64, w karma.TeamWord, err error) {
+	if errors.Is(err, karma.ErrNoWord) {
+		abortError(c, 404, err.Error())
+		return
+	}
+	if err != nil {
+		log.Errorf("Unable to change a team word. %v %v %v", team, id, err)
+		abortError(c, 500, err.Error())
+		return
+	}
+
+	c.JSON(200, w)
+}
+
+// abortError stops the request with the same json error body as the karma api
+func abortError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, karma.APIError{
+		Status:  status,
+		Error:   http.StatusText(status),
+		Message: message,
 	})
 }
 
@@ -44,3 +218,19 @@ func NewHandler(insult, compliment shakespeare.Generator) GinHandler {
 		compliment: compliment,
 	}
 }
+
+// WithWords a copy of this handler where teams add their own words to the kits, kept in the store.
+// Admins approve them
+func (g GinHandler) WithWords(store WordStore, kits Kits, admins Admins) GinHandler {
+	g.words = store
+	g.kits = kits
+	g.admins = admins
+	return g
+}
+
+// WithGrammar a copy of this handler where `/knave` insults come from the grammar, with the rules it adds to the
+// insult kit's. Its sentences use the kit's columns, and the team's words, as <A>, <B> and so on
+func (g GinHandler) WithGrammar(grammar shakespeare.Grammar) GinHandler {
+	g.grammar = grammar
+	return g
+}
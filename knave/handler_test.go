@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/icemanblues/knave-bot/karma"
 	"github.com/icemanblues/knave-bot/shakespeare"
 	"github.com/icemanblues/knave-bot/slack"
 	"github.com/stretchr/testify/assert"
@@ -17,10 +19,12 @@ func setupHandler() GinHandler {
 		shakespeare.New("compliment", "", nil))
 }
 
+const testSecret = "knave-signing-secret"
+
 func setupGin(h GinHandler) *gin.Engine {
 	r := gin.Default()
 	g := r.Group("/knavebot")
-	BindRoutes(g, h)
+	BindRoutes(g, h, slack.NewVerifier(testSecret, 0).Middleware(), karma.NewTokenAuth(karma.HappyDao()))
 
 	return r
 }
@@ -54,7 +58,7 @@ func TestSlashKnave(t *testing.T) {
 	r := setupGin(h)
 
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/knavebot/v1/cmd/knave", nil)
+	req, _ := slack.NewSignedRequest("POST", "/knavebot/v1/cmd/knave", testSecret, nil)
 	r.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
@@ -66,3 +70,36 @@ func TestSlashKnave(t *testing.T) {
 	assert.True(t, len(body.Text) > 4)
 	assert.Equal(t, "insult", body.Text)
 }
+
+func TestSlashKnaveTargeted(t *testing.T) {
+	h := setupHandler()
+	r := setupGin(h)
+
+	form := url.Values{
+		"text":    []string{"compliment <@USER>"},
+		"user_id": []string{"UCALLER"},
+		"team_id": []string{"nycfc"},
+	}
+	w := httptest.NewRecorder()
+	req, _ := slack.NewSignedRequest("POST", "/knavebot/v1/cmd/knave", testSecret, []byte(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var body slack.Response
+	err := json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Nil(t, err)
+	assert.Equal(t, slack.ChannelResponse("<@UCALLER> to <@USER>: compliment"), body)
+}
+
+func TestSlashKnaveUnsigned(t *testing.T) {
+	h := setupHandler()
+	r := setupGin(h)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/knavebot/v1/cmd/knave", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 401, w.Code)
+}
@@ -1,15 +1,27 @@
 package knave
 
 import (
+	"github.com/icemanblues/knave-bot/karma"
+
 	"github.com/gin-gonic/gin"
 )
 
 // BindRoutes bind handlers to router
-func BindRoutes(r *gin.RouterGroup, knave Handler) {
+// verify is applied to the slack integrations, so only signed requests reach the handlers
+// auth is applied to the REST api, each route requires an api token with the scope it needs
+func BindRoutes(r *gin.RouterGroup, knave Handler, verify gin.HandlerFunc, auth karma.TokenAuth) {
+	read, write, admin := auth.Require(karma.ScopeRead), auth.Require(karma.ScopeWrite), auth.Require(karma.ScopeAdmin)
+
 	v1 := r.Group("/v1")
 	v1.GET("/insult", knave.Insult)
 	v1.GET("/compliment", knave.Compliment)
 
+	// a team's own words, submitted with write and approved with admin
+	v1.GET("/team/:team/words", read, knave.TeamWords)
+	v1.POST("/team/:team/words", write, knave.AddTeamWord)
+	v1.PUT("/team/:team/words/:id", admin, knave.ApproveTeamWord)
+	v1.DELETE("/team/:team/words/:id", admin, knave.RemoveTeamWord)
+
 	// slack slash command integration
-	v1.POST("/cmd/knave", knave.SlashKnave)
+	v1.POST("/cmd/knave", verify, knave.SlashKnave)
 }
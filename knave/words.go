@@ -0,0 +1,299 @@
+package knave
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/icemanblues/knave-bot/shakespeare"
+	"github.com/icemanblues/knave-bot/slack"
+)
+
+// sub-commands of `/knave words`
+const (
+	wordsAdd     = "add"
+	wordsRemove  = "remove"
+	wordsApprove = "approve"
+	wordsList    = "list"
+)
+
+// maxWordLength the longest word, or phrase, a team can add
+const maxWordLength = 40
+
+// WordStore the words teams add to the kits, kept in the database
+type WordStore interface {
+	TeamWords(team string) ([]karma.TeamWord, error)
+	AddTeamWord(w karma.TeamWord) (karma.TeamWord, error)
+	ApproveTeamWord(team string, id int64, actor string) (karma.TeamWord, error)
+	RemoveTeamWord(team string, id int64, actor string) (karma.TeamWord, error)
+}
+
+// Kits the word lists that teams add their words to, by name
+type Kits interface {
+	Corpus(name string) (shakespeare.Corpus, bool)
+}
+
+// Admins who may approve the words a team adds
+type Admins interface {
+	IsAdmin(team, user string) (bool, error)
+}
+
+// ErrInvalidWord the kit, column or word can't be added to
+var ErrInvalidWord = errors.New("invalid word")
+
+const (
+	msgWordsOff    = "This knave has no database to keep a team's words in."
+	msgWordsUsage  = "Try `/knave words add insult B \"beetle-headed\"`, `/knave words remove insult B \"beetle-headed\"`, `/knave words approve insult B \"beetle-headed\"` or `/knave words list`."
+	msgNoWords     = "This team has added no words yet. Add one with `/knave words add insult B \"beetle-headed\"`."
+	msgWordsAdmin  = "Only an admin may do that."
+	msgWordExists  = "That word is already in that column."
+	msgWordMissing = "This team has no such word."
+)
+
+// MsgWordAdded the reply to adding a word, straight in when an admin adds it or else waiting for approval
+func MsgWordAdded(w karma.TeamWord) string {
+	if w.Status == karma.WordApproved {
+		return fmt.Sprintf("Added `%v` to %v column %v.", w.Word, w.Kit, w.Column)
+	}
+	return fmt.Sprintf("Submitted `%v` for %v column %v. It is used once an admin approves it.", w.Word, w.Kit, w.Column)
+}
+
+// MsgWordApproved the reply to approving a word
+func MsgWordApproved(w karma.TeamWord) string {
+	return fmt.Sprintf("Approved `%v` for %v column %v, submitted by %v.", w.Word, w.Kit, w.Column, karma.MsgActor(w.SubmittedBy))
+}
+
+// MsgWordRemoved the reply to removing a word
+func MsgWordRemoved(w karma.TeamWord) string {
+	return fmt.Sprintf("Removed `%v` from %v column %v.", w.Word, w.Kit, w.Column)
+}
+
+// MsgTeamWords the team's words by kit and column, then the ones waiting for approval
+func MsgTeamWords(words []karma.TeamWord) string {
+	var approved, pending strings.Builder
+	for _, w := range words {
+		line := fmt.Sprintf("%v %v: %v\n", w.Kit, w.Column, w.Word)
+		if w.Status == karma.WordApproved {
+			approved.WriteString(line)
+		} else {
+			pending.WriteString(strings.TrimSuffix(line, "\n") + fmt.Sprintf(" (from %v)\n", karma.MsgActor(w.SubmittedBy)))
+		}
+	}
+
+	var b strings.Builder
+	if approved.Len() != 0 {
+		b.WriteString("This team's words:\n")
+		b.WriteString(approved.String())
+	}
+	if pending.Len() != 0 {
+		b.WriteString("Waiting for an admin to approve:\n")
+		b.WriteString(pending.String())
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// NewTeamWord a word for a kit's column, checked against the kit. The kit and column may be in any case
+func (g GinHandler) NewTeamWord(team, kit, column, word, submitter string) (karma.TeamWord, error) {
+	kit = strings.ToLower(kit)
+	c, ok := g.kit(kit)
+	if !ok {
+		return karma.TeamWord{}, fmt.Errorf("%w: the kits are %v and %v", ErrInvalidWord, insult, compliment)
+	}
+
+	col, ok := columnIndex(c, column)
+	if !ok {
+		return karma.TeamWord{}, fmt.Errorf("%w: %v has columns %v to %v", ErrInvalidWord, kit, shakespeare.ColumnName(0), shakespeare.ColumnName(len(c.Columns)-1))
+	}
+
+	word = strings.Join(strings.Fields(word), " ")
+	switch {
+	case word == "":
+		return karma.TeamWord{}, fmt.Errorf("%w: the word is blank", ErrInvalidWord)
+	case len(word) > maxWordLength:
+		return karma.TeamWord{}, fmt.Errorf("%w: the word is longer than %v characters", ErrInvalidWord, maxWordLength)
+	case strings.ContainsAny(word, "<>"):
+		// slack mentions and links would ping people from every insult
+		return karma.TeamWord{}, fmt.Errorf("%w: the word can't have < or > in it", ErrInvalidWord)
+	}
+
+	return karma.TeamWord{
+		Team:        team,
+		Kit:         kit,
+		Column:      shakespeare.ColumnName(col),
+		Word:        word,
+		Status:      karma.WordPending,
+		SubmittedBy: submitter,
+	}, nil
+}
+
+func (g GinHandler) kit(name string) (shakespeare.Corpus, bool) {
+	if g.kits == nil || (name != insult && name != compliment) {
+		return shakespeare.Corpus{}, false
+	}
+	return g.kits.Corpus(name)
+}
+
+func columnIndex(c shakespeare.Corpus, column string) (int, bool) {
+	for i := range c.Columns {
+		if strings.EqualFold(shakespeare.ColumnName(i), column) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// generator the kit's generator for a team, with the words the team has added when it has approved any.
+// Insults come from the grammar, when there is one
+func (g GinHandler) generator(team, kit string) (shakespeare.Generator, error) {
+	base := g.insult
+	if kit == compliment {
+		base = g.compliment
+	}
+	grammar := kit == insult && len(g.grammar.Rules) > 0
+	if g.words == nil && !grammar {
+		return base, nil
+	}
+
+	c, ok := g.kit(kit)
+	if !ok {
+		return base, nil
+	}
+	found := false
+	if g.words != nil {
+		words, err := g.words.TeamWords(team)
+		if err != nil {
+			return nil, err
+		}
+
+		added := shakespeare.Corpus{Name: kit, Columns: make([][]string, len(c.Columns))}
+		for _, w := range words {
+			if w.Kit != kit || w.Status != karma.WordApproved {
+				continue
+			}
+			// a column the kit no longer has is left out
+			if i, ok := columnIndex(c, w.Column); ok {
+				added.Columns[i] = append(added.Columns[i], w.Word)
+				found = true
+			}
+		}
+		if found {
+			merged, err := c.Merge(added)
+			if err != nil {
+				return nil, err
+			}
+			c = merged
+		}
+	}
+
+	if grammar {
+		return shakespeare.NewGrammarGenerator(c.Grammar().With(g.grammar))
+	}
+	if !found {
+		return base, nil
+	}
+	return c.Generator(), nil
+}
+
+// teamWords `/knave words <add|remove|approve|list> [kit column word]`
+func (g GinHandler) teamWords(data slack.CommandData, args []string) (slack.Response, error) {
+	if g.words == nil {
+		return slack.ErrorResponse(msgWordsOff), nil
+	}
+
+	sub := wordsList
+	if len(args) > 0 {
+		sub = strings.ToLower(args[0])
+	}
+
+	switch sub {
+	case wordsList:
+		words, err := g.words.TeamWords(data.TeamID)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		if len(words) == 0 {
+			return slack.ErrorResponse(msgNoWords), nil
+		}
+		return slack.DirectResponse(MsgTeamWords(words), ""), nil
+	case wordsAdd, wordsRemove, wordsApprove:
+	default:
+		return slack.ErrorResponse(msgWordsUsage), nil
+	}
+
+	if len(args) < 4 {
+		return slack.ErrorResponse(msgWordsUsage), nil
+	}
+	w, err := g.NewTeamWord(data.TeamID, args[1], args[2], unquote(strings.Join(args[3:], " ")), data.UserID)
+	if err != nil {
+		return slack.ErrorResponse(err.Error()), nil
+	}
+
+	admin, err := g.admins.IsAdmin(data.TeamID, data.UserID)
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	if sub == wordsAdd {
+		if admin {
+			w.Status, w.ApprovedBy = karma.WordApproved, data.UserID
+		}
+		added, err := g.words.AddTeamWord(w)
+		if errors.Is(err, karma.ErrWordExists) {
+			return slack.ErrorResponse(msgWordExists), nil
+		}
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgWordAdded(added), ""), nil
+	}
+
+	existing, err := g.findWord(w)
+	if errors.Is(err, karma.ErrNoWord) {
+		return slack.ErrorResponse(msgWordMissing), nil
+	}
+	if err != nil {
+		return slack.Response{}, err
+	}
+
+	if sub == wordsApprove {
+		if !admin {
+			return slack.ErrorResponse(msgWordsAdmin), nil
+		}
+		approved, err := g.words.ApproveTeamWord(data.TeamID, existing.ID, data.UserID)
+		if err != nil {
+			return slack.Response{}, err
+		}
+		return slack.DirectResponse(MsgWordApproved(approved), ""), nil
+	}
+
+	// whoever submitted a word may take it back, until it is approved
+	mine := existing.Status == karma.WordPending && existing.SubmittedBy == data.UserID
+	if !admin && !mine {
+		return slack.ErrorResponse(msgWordsAdmin), nil
+	}
+	removed, err := g.words.RemoveTeamWord(data.TeamID, existing.ID, data.UserID)
+	if err != nil {
+		return slack.Response{}, err
+	}
+	return slack.DirectResponse(MsgWordRemoved(removed), ""), nil
+}
+
+// findWord the team's word in the same kit and column. karma.ErrNoWord when it has none
+func (g GinHandler) findWord(w karma.TeamWord) (karma.TeamWord, error) {
+	words, err := g.words.TeamWords(w.Team)
+	if err != nil {
+		return karma.TeamWord{}, err
+	}
+	for _, existing := range words {
+		if existing.Kit == w.Kit && existing.Column == w.Column && strings.EqualFold(existing.Word, w.Word) {
+			return existing, nil
+		}
+	}
+	return karma.TeamWord{}, karma.ErrNoWord
+}
+
+// unquote takes off the quotes around a phrase, including the curly ones slack likes to put in
+func unquote(s string) string {
+	return strings.Trim(s, "\"'“”‘’")
+}
@@ -0,0 +1,199 @@
+package knave
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/icemanblues/knave-bot/shakespeare"
+	"github.com/icemanblues/knave-bot/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+// the api tokens of the happy mock dao, for team nycfc
+const (
+	readToken  = "read-token"
+	writeToken = "write-token"
+	adminToken = "admin-token"
+)
+
+type testKits map[string]shakespeare.Corpus
+
+func (k testKits) Corpus(name string) (shakespeare.Corpus, bool) {
+	c, ok := k[name]
+	return c, ok
+}
+
+var kits = testKits{
+	insult:     {Name: insult, Prefix: "Thou", Columns: [][]string{{"artless"}, {"base-court"}, {"apple-john"}}},
+	compliment: {Name: compliment, Prefix: "Thou", Columns: [][]string{{"rare"}, {"honey-tongued"}, {"smilet"}}},
+}
+
+func wordsHandler(dao karma.MockDAO) GinHandler {
+	return setupHandler().WithWords(dao, kits, dao)
+}
+
+func adminCommand(text string) slack.CommandData {
+	c := command(text)
+	c.UserID = "UADMIN"
+	return c
+}
+
+func TestProcessWords(t *testing.T) {
+	h := wordsHandler(karma.HappyDao())
+	added := karma.TeamWord{Team: "nycfc", Kit: insult, Column: "B", Word: "dog-hearted", Status: karma.WordPending, SubmittedBy: "UCALLER"}
+	approved := added
+	approved.Status, approved.SubmittedBy, approved.ApprovedBy = karma.WordApproved, "UADMIN", "UADMIN"
+	pending := karma.TeamWord{ID: 2, Team: "nycfc", Kit: insult, Column: "C", Word: "knave-nut", SubmittedBy: "UCALLER"}
+
+	testcases := []struct {
+		name     string
+		command  slack.CommandData
+		expected slack.Response
+	}{
+		{"list", command("words"), slack.DirectResponse("This team's words:\ninsult B: beetle-headed\nWaiting for an admin to approve:\ninsult C: knave-nut (from <@UCALLER>)", "")},
+		{"add", command(`words add insult b "dog-hearted"`), slack.DirectResponse(MsgWordAdded(added), "")},
+		{"add curly quotes", command("words add Insult B “dog-hearted”"), slack.DirectResponse(MsgWordAdded(added), "")},
+		{"admin adds", adminCommand("words add insult B dog-hearted"), slack.DirectResponse(MsgWordAdded(approved), "")},
+		{"add again", command(`words add insult B "beetle-headed"`), slack.ErrorResponse(msgWordExists)},
+		{"unknown kit", command(`words add pirate B "scurvy"`), slack.ErrorResponse("invalid word: the kits are insult and compliment")},
+		{"unknown column", command(`words add insult D "scurvy"`), slack.ErrorResponse("invalid word: insult has columns A to C")},
+		{"mention", command(`words add insult C "<@USER>"`), slack.ErrorResponse("invalid word: the word can't have < or > in it")},
+		{"too long", command(`words add insult C "` + string(bytes.Repeat([]byte("a"), 41)) + `"`), slack.ErrorResponse("invalid word: the word is longer than 40 characters")},
+		{"missing word", command("words add insult C"), slack.ErrorResponse(msgWordsUsage)},
+		{"approve", adminCommand("words approve insult C knave-nut"), slack.DirectResponse("Approved `knave-nut` for insult column C, submitted by <@UCALLER>.", "")},
+		{"approve not an admin", command("words approve insult C knave-nut"), slack.ErrorResponse(msgWordsAdmin)},
+		{"approve nothing", adminCommand("words approve insult C nut-hook"), slack.ErrorResponse(msgWordMissing)},
+		{"remove", adminCommand(`words remove insult B "beetle-headed"`), slack.DirectResponse("Removed `beetle-headed` from insult column B.", "")},
+		{"remove own pending", command("words remove insult C Knave-Nut"), slack.DirectResponse(MsgWordRemoved(pending), "")},
+		{"remove approved", command(`words remove insult B "beetle-headed"`), slack.ErrorResponse(msgWordsAdmin)},
+		{"unknown", command("words shout"), slack.ErrorResponse(msgWordsUsage)},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := h.Process(test.command)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	empty := karma.HappyDao()
+	empty.TeamWordsMock = func(team string) ([]karma.TeamWord, error) { return nil, nil }
+	actual, err := wordsHandler(empty).Process(command("words list"))
+	assert.Nil(t, err)
+	assert.Equal(t, slack.ErrorResponse(msgNoWords), actual)
+
+	for _, text := range []string{"words list", "words add insult B dog-hearted", "", "compliment"} {
+		_, err := wordsHandler(karma.SadDao()).Process(command(text))
+		assert.NotNil(t, err, text)
+	}
+}
+
+func TestTeamGenerator(t *testing.T) {
+	h := wordsHandler(karma.HappyDao())
+
+	// the approved word joins the kit's column, the pending one doesn't
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		actual, err := h.Process(command("<@USER>"))
+		assert.Nil(t, err)
+		seen[actual.Text] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"<@UCALLER> to <@USER>: Thou artless base-court apple-john":    true,
+		"<@UCALLER> to <@USER>: Thou artless beetle-headed apple-john": true,
+	}, seen)
+
+	// the compliment kit has no words of the team's
+	actual, err := h.Process(command("compliment"))
+	assert.Nil(t, err)
+	assert.Equal(t, "compliment", actual.Text)
+}
+
+func TestTeamGrammar(t *testing.T) {
+	h := wordsHandler(karma.HappyDao()).WithGrammar(shakespeare.Grammar{Rules: map[string][]shakespeare.Alternative{
+		shakespeare.StartRule: {{Text: "Away, thou <B> <C>!"}},
+	}})
+
+	// the grammar's sentences, with the team's words in the kit's columns
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		actual, err := h.Process(command(""))
+		assert.Nil(t, err)
+		seen[actual.Text] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"Away, thou base-court apple-john!":    true,
+		"Away, thou beetle-headed apple-john!": true,
+	}, seen)
+
+	// compliments don't use it
+	actual, err := h.Process(command("compliment"))
+	assert.Nil(t, err)
+	assert.Equal(t, "compliment", actual.Text)
+
+	// a rule the kit doesn't have
+	h = h.WithGrammar(shakespeare.Grammar{Rules: map[string][]shakespeare.Alternative{
+		shakespeare.StartRule: {{Text: "Thou <D>!"}},
+	}})
+	_, err = h.Process(command(""))
+	assert.NotNil(t, err)
+}
+
+func wordsRequest(r http.Handler, method, url, token string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, url, bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestTeamWordsAPI(t *testing.T) {
+	r := setupGin(wordsHandler(karma.HappyDao()))
+
+	testcases := []struct {
+		name   string
+		method string
+		url    string
+		token  string
+		body   interface{}
+		code   int
+		expect string
+	}{
+		{"list", "GET", "/knavebot/v1/team/nycfc/words", readToken, nil, 200, `"word":"knave-nut","status":"pending"`},
+		{"add", "POST", "/knavebot/v1/team/nycfc/words", writeToken, TeamWordRequest{Kit: "insult", Column: "a", Word: "dankish"}, 201, `"id":3,"team":"nycfc","kit":"insult","column":"A","word":"dankish","status":"pending","submitted_by":"token:2 (writer)"`},
+		{"add needs write", "POST", "/knavebot/v1/team/nycfc/words", readToken, TeamWordRequest{Kit: "insult", Column: "A", Word: "dankish"}, 403, "write scope"},
+		{"add as someone else", "POST", "/knavebot/v1/team/nycfc/words", writeToken, map[string]string{"kit": "insult", "column": "A", "word": "dankish", "actor": "USOMEONE"}, 201, `"submitted_by":"token:2 (writer)"`},
+		{"add invalid", "POST", "/knavebot/v1/team/nycfc/words", writeToken, TeamWordRequest{Kit: "insult", Column: "Z", Word: "dankish"}, 400, "insult has columns A to C"},
+		{"add twice", "POST", "/knavebot/v1/team/nycfc/words", writeToken, TeamWordRequest{Kit: "insult", Column: "B", Word: "beetle-headed"}, 409, karma.ErrWordExists.Error()},
+		{"add twice in another case", "POST", "/knavebot/v1/team/nycfc/words", writeToken, TeamWordRequest{Kit: "insult", Column: "B", Word: "Beetle-Headed"}, 409, karma.ErrWordExists.Error()},
+		{"approve", "PUT", "/knavebot/v1/team/nycfc/words/2", adminToken, nil, 200, `"status":"approved","submitted_by":"UCALLER","approved_by":"token:3 (admin)"`},
+		{"approve as someone else", "PUT", "/knavebot/v1/team/nycfc/words/2", adminToken, map[string]string{"actor": "USOMEONE"}, 200, `"approved_by":"token:3 (admin)"`},
+		{"approve needs admin", "PUT", "/knavebot/v1/team/nycfc/words/2", writeToken, nil, 403, "admin scope"},
+		{"approve nothing", "PUT", "/knavebot/v1/team/nycfc/words/9", adminToken, nil, 404, karma.ErrNoWord.Error()},
+		{"approve a bad id", "PUT", "/knavebot/v1/team/nycfc/words/two", adminToken, nil, 400, "the word's id"},
+		{"remove", "DELETE", "/knavebot/v1/team/nycfc/words/1?actor=USOMEONE", adminToken, nil, 200, `"word":"beetle-headed"`},
+		{"another team", "GET", "/knavebot/v1/team/other/words", readToken, nil, 403, "not for team other"},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			w := wordsRequest(r, test.method, test.url, test.token, test.body)
+			assert.Equal(t, test.code, w.Code)
+			assert.Contains(t, w.Body.String(), test.expect)
+		})
+	}
+
+	// without a database there are no words to manage
+	w := wordsRequest(setupGin(setupHandler()), "GET", "/knavebot/v1/team/nycfc/words", readToken, nil)
+	assert.Equal(t, 404, w.Code)
+
+	sad := setupGin(wordsHandler(karma.SadDao()))
+	w = wordsRequest(sad, "POST", "/knavebot/v1/team/nycfc/words", adminToken, TeamWordRequest{Kit: "insult", Column: "A", Word: "dankish"})
+	assert.Equal(t, 500, w.Code)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/icemanblues/knave-bot/karma"
+)
+
+const migrateUsage = "usage: knave-bot migrate status|up|down [steps]"
+
+// runMigrate the `knave-bot migrate` subcommand
+// status lists the migrations, up applies the pending ones and down rolls back the latest (1 by default)
+func runMigrate(args []string, driver, dsn string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := karma.Connect(driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := karma.NewMigrator(db, driver)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		status, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d %-30v %v\n", s.Version, s.Name, applied)
+		}
+		return nil
+
+	case "up":
+		n, err := m.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "applied %v migration(s)\n", n)
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number: %v", args[1])
+			}
+		}
+		n, err := m.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "rolled back %v migration(s)\n", n)
+		return nil
+	}
+
+	return errors.New(migrateUsage)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunMigrate(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "karma.db")
+	out := &strings.Builder{}
+
+	err := runMigrate([]string{"status"}, karma.DriverSQLite, dsn, out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "pending")
+	assert.NotContains(t, out.String(), "applied")
+
+	out.Reset()
+	err = runMigrate([]string{"up"}, karma.DriverSQLite, dsn, out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "applied")
+
+	out.Reset()
+	err = runMigrate([]string{"down"}, karma.DriverSQLite, dsn, out)
+	assert.Nil(t, err)
+	assert.Equal(t, "rolled back 1 migration(s)\n", out.String())
+
+	out.Reset()
+	err = runMigrate([]string{"status"}, karma.DriverSQLite, dsn, out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "applied")
+	assert.Contains(t, out.String(), "pending")
+
+	for _, args := range [][]string{{}, {"sideways"}, {"down", "zero"}} {
+		err = runMigrate(args, karma.DriverSQLite, dsn, out)
+		assert.NotNil(t, err, args)
+	}
+}
@@ -3,15 +3,18 @@ package main
 import (
 	"github.com/icemanblues/knave-bot/karma"
 	"github.com/icemanblues/knave-bot/knave"
+	"github.com/icemanblues/knave-bot/slack"
 
 	"github.com/gin-gonic/gin"
 )
 
 // BindRoutes bind handlers to router
-func BindRoutes(r *gin.Engine, knaveHandler knave.Handler, karmaHandler karma.Handler) {
+func BindRoutes(r *gin.Engine, knaveHandler knave.Handler, karmaHandler karma.Handler, verifier slack.Verifier, auth karma.TokenAuth) {
+	verify := verifier.Middleware()
+
 	knaveRouter := r.Group("/knavebot")
-	knave.BindRoutes(knaveRouter, knaveHandler)
+	knave.BindRoutes(knaveRouter, knaveHandler, verify, auth)
 
 	karmaRouter := r.Group("/karmabot")
-	karma.BindRoutes(karmaRouter, knaveRouter, karmaHandler)
+	karma.BindRoutes(karmaRouter, knaveRouter, karmaHandler, verify, auth)
 }
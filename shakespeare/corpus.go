@@ -0,0 +1,199 @@
+package shakespeare
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the corpora that are built in
+const (
+	InsultName     = "insult"
+	ComplimentName = "compliment"
+)
+
+//go:embed words/*.yaml
+var defaults embed.FS
+
+// Corpus the words for a FormulaGenerator, as they are kept in a .json, .yaml or .yml file
+type Corpus struct {
+	Name     string            `json:"name" yaml:"name"`
+	Prefix   string            `json:"prefix" yaml:"prefix"`
+	Columns  [][]string        `json:"columns" yaml:"columns"`
+	Postfix  string            `json:"postfix" yaml:"postfix"`
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// Validate a corpus needs a name and at least one column, and every column needs words
+func (c Corpus) Validate() error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if len(c.Columns) == 0 {
+		errs = append(errs, errors.New("columns are required"))
+	}
+	for i, col := range c.Columns {
+		if len(col) == 0 {
+			errs = append(errs, fmt.Errorf("column %v is empty", i+1))
+		}
+		for _, word := range col {
+			if strings.TrimSpace(word) == "" {
+				errs = append(errs, fmt.Errorf("column %v has a blank word", i+1))
+				break
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("corpus %q: %w", c.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// Merge adds the other corpus' words to this one's, column by column, leaving out the words it already has.
+// The other corpus' prefix, postfix and metadata win when they are set
+func (c Corpus) Merge(other Corpus) (Corpus, error) {
+	if len(other.Columns) != len(c.Columns) {
+		return Corpus{}, fmt.Errorf("corpus %q has %v columns, it can't be merged into one with %v", c.Name, len(other.Columns), len(c.Columns))
+	}
+
+	merged := Corpus{
+		Name:     c.Name,
+		Prefix:   c.Prefix,
+		Postfix:  c.Postfix,
+		Columns:  make([][]string, len(c.Columns)),
+		Metadata: make(map[string]string, len(c.Metadata)+len(other.Metadata)),
+	}
+	if other.Prefix != "" {
+		merged.Prefix = other.Prefix
+	}
+	if other.Postfix != "" {
+		merged.Postfix = other.Postfix
+	}
+	for k, v := range c.Metadata {
+		merged.Metadata[k] = v
+	}
+	for k, v := range other.Metadata {
+		merged.Metadata[k] = v
+	}
+
+	for i, col := range c.Columns {
+		seen := make(map[string]bool, len(col))
+		words := make([]string, 0, len(col)+len(other.Columns[i]))
+		for _, list := range [][]string{col, other.Columns[i]} {
+			for _, word := range list {
+				if !seen[word] {
+					seen[word] = true
+					words = append(words, word)
+				}
+			}
+		}
+		merged.Columns[i] = words
+	}
+	return merged, nil
+}
+
+// Generator a FormulaGenerator for this corpus' words
+func (c Corpus) Generator() FormulaGenerator {
+	return New(c.Prefix, c.Postfix, c.Columns)
+}
+
+// ParseCorpus reads a corpus from json, or yaml when the extension is .yaml or .yml.
+// A corpus without a name is named after its file
+func ParseCorpus(data []byte, file string) (Corpus, error) {
+	var c Corpus
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &c)
+	default:
+		return Corpus{}, fmt.Errorf("word list %v: unknown format %q, use .json .yaml or .yml", file, ext)
+	}
+	if err != nil {
+		return Corpus{}, fmt.Errorf("word list %v: %w", file, err)
+	}
+
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	return c, nil
+}
+
+// LoadCorpus reads a corpus from a file
+func LoadCorpus(file string) (Corpus, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Corpus{}, err
+	}
+	return ParseCorpus(data, file)
+}
+
+// Defaults the corpora that are built in, by name
+func Defaults() (map[string]Corpus, error) {
+	entries, err := defaults.ReadDir("words")
+	if err != nil {
+		return nil, err
+	}
+
+	corpora := make(map[string]Corpus, len(entries))
+	for _, e := range entries {
+		file := path.Join("words", e.Name())
+		data, err := defaults.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		c, err := ParseCorpus(data, file)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+		corpora[c.Name] = c
+	}
+	return corpora, nil
+}
+
+// LoadCorpora the built in corpora, with the words in the files merged into the one with the same name.
+// A file with a new name adds a corpus of its own
+func LoadCorpora(files ...string) (map[string]Corpus, error) {
+	corpora, err := Defaults()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		c, err := LoadCorpus(file)
+		if err != nil {
+			return nil, err
+		}
+		if base, ok := corpora[c.Name]; ok {
+			c, err = base.Merge(c)
+			if err != nil {
+				return nil, fmt.Errorf("word list %v: %w", file, err)
+			}
+		}
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("word list %v: %w", file, err)
+		}
+		corpora[c.Name] = c
+	}
+	return corpora, nil
+}
+
+func mustDefault(name string) Corpus {
+	corpora, err := Defaults()
+	if err != nil {
+		panic(err)
+	}
+	return corpora[name]
+}
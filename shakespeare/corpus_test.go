@@ -0,0 +1,164 @@
+package shakespeare
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeWords(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	err := os.WriteFile(file, []byte(content), 0644)
+	assert.Nil(t, err)
+	return file
+}
+
+func TestDefaults(t *testing.T) {
+	corpora, err := Defaults()
+	assert.Nil(t, err)
+
+	insult := corpora[InsultName]
+	assert.Equal(t, Thou, insult.Prefix)
+	if assert.Len(t, insult.Columns, 3) {
+		assert.Len(t, insult.Columns[0], 50)
+		assert.Equal(t, "artless", insult.Columns[0][0])
+		assert.Equal(t, "wagtail", insult.Columns[2][49])
+	}
+
+	compliment := corpora[ComplimentName]
+	if assert.Len(t, compliment.Columns, 3) {
+		assert.Equal(t, "welsh cheese", compliment.Columns[2][6])
+	}
+
+	assert.True(t, strings.HasPrefix(Insult(), "Thou "))
+	assert.True(t, strings.HasPrefix(Compliment(), "Thou "))
+}
+
+func TestParseCorpus(t *testing.T) {
+	expected := Corpus{Name: "pirate", Prefix: "Ye", Columns: [][]string{{"scurvy"}, {"bilge rat", "landlubber"}}, Postfix: "!", Metadata: map[string]string{"author": "me"}}
+
+	testcases := []struct {
+		name string
+		file string
+		data string
+	}{
+		{"json", "pirate.json", `{"prefix": "Ye", "columns": [["scurvy"], ["bilge rat", "landlubber"]], "postfix": "!", "metadata": {"author": "me"}}`},
+		{"yaml", "words.yaml", "name: pirate\nprefix: Ye\ncolumns:\n  - [scurvy]\n  - [bilge rat, landlubber]\npostfix: \"!\"\nmetadata:\n  author: me\n"},
+		{"yml", "pirate.YML", "prefix: Ye\ncolumns: [[scurvy], [bilge rat, landlubber]]\npostfix: \"!\"\nmetadata: {author: me}\n"},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ParseCorpus([]byte(test.data), test.file)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, c)
+		})
+	}
+
+	_, err := ParseCorpus([]byte("prefix = 'Ye'"), "pirate.toml")
+	assert.ErrorContains(t, err, "unknown format")
+	_, err = ParseCorpus([]byte("{"), "pirate.json")
+	assert.ErrorContains(t, err, "pirate.json")
+}
+
+func TestCorpusValidate(t *testing.T) {
+	assert.Nil(t, Corpus{Name: "ok", Columns: [][]string{{"a"}}}.Validate())
+
+	err := Corpus{}.Validate()
+	assert.ErrorContains(t, err, "name is required")
+	assert.ErrorContains(t, err, "columns are required")
+
+	err = Corpus{Name: "gaps", Columns: [][]string{{"a"}, {}, {"b", " "}}}.Validate()
+	assert.ErrorContains(t, err, "column 2 is empty")
+	assert.ErrorContains(t, err, "column 3 has a blank word")
+}
+
+func TestCorpusMerge(t *testing.T) {
+	base := Corpus{Name: "insult", Prefix: "Thou", Columns: [][]string{{"a1", "a2"}, {"b1"}}, Metadata: map[string]string{"source": "kit"}}
+
+	merged, err := base.Merge(Corpus{Name: "insult", Columns: [][]string{{"a2", "a3"}, {}}, Postfix: "!", Metadata: map[string]string{"team": "avengers"}})
+	assert.Nil(t, err)
+	assert.Equal(t, Corpus{
+		Name:     "insult",
+		Prefix:   "Thou",
+		Columns:  [][]string{{"a1", "a2", "a3"}, {"b1"}},
+		Postfix:  "!",
+		Metadata: map[string]string{"source": "kit", "team": "avengers"},
+	}, merged)
+	// the base is left alone
+	assert.Equal(t, []string{"a1", "a2"}, base.Columns[0])
+
+	_, err = base.Merge(Corpus{Name: "insult", Columns: [][]string{{"a3"}}})
+	assert.ErrorContains(t, err, "1 columns")
+}
+
+func TestLoadCorpora(t *testing.T) {
+	dir := t.TempDir()
+	extra := writeWords(t, dir, "insult.yaml", "columns:\n  - [beetle-headed]\n  - []\n  - [knave]\n")
+	pirate := writeWords(t, dir, "pirate.json", `{"prefix": "Ye", "columns": [["scurvy"], ["dog"]]}`)
+
+	corpora, err := LoadCorpora(extra, pirate)
+	assert.Nil(t, err)
+	assert.Contains(t, corpora[InsultName].Columns[0], "beetle-headed")
+	assert.Len(t, corpora[InsultName].Columns[1], 50)
+	assert.Equal(t, "Ye scurvy dog", corpora["pirate"].Generator().Sentence())
+
+	empty := writeWords(t, dir, "empty.yaml", "columns:\n  - [a]\n  - []\n")
+	_, err = LoadCorpora(empty)
+	assert.ErrorContains(t, err, "column 2 is empty")
+
+	_, err = LoadCorpora(filepath.Join(dir, "missing.yaml"))
+	assert.NotNil(t, err)
+}
+
+func TestLibrary(t *testing.T) {
+	dir := t.TempDir()
+	file := writeWords(t, dir, "pirate.yaml", "columns: [[scurvy], [dog]]\n")
+
+	lib, err := NewLibrary(file)
+	assert.Nil(t, err)
+	pirate, ok := lib.Generator("pirate")
+	assert.True(t, ok)
+	assert.Equal(t, "scurvy dog", pirate.Sentence())
+	_, ok = lib.Generator("nobody")
+	assert.False(t, ok)
+
+	// a bad edit keeps the words in use
+	writeWords(t, dir, "pirate.yaml", "columns: [[scurvy], []]\n")
+	assert.NotNil(t, lib.Reload())
+	assert.Equal(t, "scurvy dog", pirate.SentenceFor(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hup := make(chan os.Signal, 1)
+	done := make(chan error)
+	go func() { done <- lib.Watch(ctx, hup) }()
+
+	t.Run("file change", func(t *testing.T) {
+		// the watcher may not have started yet, so the file is written until it is noticed
+		assert.Eventually(t, func() bool {
+			writeWords(t, dir, "pirate.yaml", "columns: [[mangy], [dog]]\n")
+			return pirate.Sentence() == "mangy dog"
+		}, 5*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("sighup", func(t *testing.T) {
+		// written while nothing is watching, as the reload is for the signal
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+		writeWords(t, dir, "pirate.yaml", "columns: [[bilge], [rat]]\n")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { done <- lib.Watch(ctx, hup) }()
+		hup <- syscall.SIGHUP
+		assert.Eventually(t, func() bool {
+			return pirate.Sentence() == "bilge rat"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
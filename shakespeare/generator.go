@@ -3,12 +3,32 @@ package shakespeare
 import (
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Generator generates sentences following a formula.
 type Generator interface {
 	Sentence() string
+	SentenceFor(seed int64) string
+}
+
+// Random a source of random numbers that is safe for concurrent use
+type Random struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewRandom constructs a Random from the source
+func NewRandom(src rand.Source) *Random {
+	return &Random{rnd: rand.New(src)}
+}
+
+// Intn a random number in [0,n)
+func (r *Random) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Intn(n)
 }
 
 // FormulaGenerator generates sentences following a formula.
@@ -19,6 +39,7 @@ type FormulaGenerator struct {
 	prefix  string
 	columns [][]string
 	postfix string
+	rand    *Random
 }
 
 // Sentence the result of this generator's formula
@@ -26,19 +47,30 @@ func (g FormulaGenerator) Sentence() string {
 	return g.Generate(" ")
 }
 
+// SentenceFor the result of this generator's formula, the same every time for the same seed
+func (g FormulaGenerator) SentenceFor(seed int64) string {
+	return g.generate(" ", rand.New(rand.NewSource(seed)).Intn)
+}
+
 // Generate follows the formula using the delim
 func (g FormulaGenerator) Generate(delim string) string {
+	if g.rand == nil {
+		return g.generate(delim, rand.Intn)
+	}
+	return g.generate(delim, g.rand.Intn)
+}
+
+func (g FormulaGenerator) generate(delim string, intn func(n int) int) string {
 	builder := strings.Builder{}
 	if g.prefix != "" {
 		builder.WriteString(g.prefix)
 	}
 
-	rand.Seed(time.Now().Unix())
 	for i, col := range g.columns {
 		if i != 0 || g.prefix != "" {
 			builder.WriteString(delim)
 		}
-		r := rand.Intn(len(col))
+		r := intn(len(col))
 		builder.WriteString(col[r])
 	}
 
@@ -52,11 +84,23 @@ func (g FormulaGenerator) Generate(delim string) string {
 	return builder.String()
 }
 
+// WithSource a copy of this generator that picks its words with src
+func (g FormulaGenerator) WithSource(src rand.Source) FormulaGenerator {
+	g.rand = NewRandom(src)
+	return g
+}
+
+// WithSeed a copy of this generator that picks its words from a source seeded with seed
+func (g FormulaGenerator) WithSeed(seed int64) FormulaGenerator {
+	return g.WithSource(rand.NewSource(seed))
+}
+
 // New constructs a FormulaGenerator
 func New(pre, post string, cols [][]string) FormulaGenerator {
 	return FormulaGenerator{
 		prefix:  pre,
 		postfix: post,
 		columns: cols,
+		rand:    NewRandom(rand.NewSource(time.Now().UnixNano())),
 	}
 }
@@ -88,3 +88,35 @@ func TestGenerator(t *testing.T) {
 		})
 	}
 }
+
+func TestGeneratorSeed(t *testing.T) {
+	colA := []string{"a1", "a2", "a3", "a4", "a5", "a6", "a7", "a8"}
+	colB := []string{"b1", "b2", "b3", "b4", "b5", "b6", "b7", "b8"}
+	gen := New("Thou", "", [][]string{colA, colB, colA, colB})
+
+	t.Run("sentence for", func(t *testing.T) {
+		assert.Equal(t, gen.SentenceFor(20260504), gen.SentenceFor(20260504))
+		assert.Equal(t, "Thou a2 b4 a5 b7", gen.SentenceFor(42))
+	})
+
+	t.Run("with seed", func(t *testing.T) {
+		a, b := gen.WithSeed(7), gen.WithSeed(7)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, a.Sentence(), b.Sentence())
+		}
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		assert.Equal(t, "", FormulaGenerator{}.Sentence())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		done := make(chan string)
+		for i := 0; i < 10; i++ {
+			go func() { done <- gen.Sentence() }()
+		}
+		for i := 0; i < 10; i++ {
+			assert.Contains(t, <-done, "Thou ")
+		}
+	})
+}
@@ -0,0 +1,421 @@
+package shakespeare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StartRule the rule a grammar starts from, when it doesn't name one
+const StartRule = "sentence"
+
+// maxDepth how deep rules may nest before the rest of a sentence is left out, so a recursive grammar still ends
+const maxDepth = 16
+
+// Alternative one way to expand a rule. Weight is how likely it is to be chosen, relative to the rule's other
+// alternatives, and 0 counts as 1.
+// Text is literal apart from `<rule>` which expands a rule, `[optional part]` which is left out half the time,
+// `a/an` which agrees with the word after it, and `\` which makes the next character literal
+type Alternative struct {
+	Weight int    `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Text   string `json:"text" yaml:"text"`
+}
+
+// UnmarshalYAML an alternative is either its text, or a map with its text and weight
+func (a *Alternative) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*a = Alternative{Text: value.Value}
+		return nil
+	}
+	type alternative Alternative
+	return value.Decode((*alternative)(a))
+}
+
+// UnmarshalJSON an alternative is either its text, or an object with its text and weight
+func (a *Alternative) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*a = Alternative{Text: text}
+		return nil
+	}
+	type alternative Alternative
+	return json.Unmarshal(data, (*alternative)(a))
+}
+
+// Grammar a context-free grammar, each rule expands to one of its alternatives
+type Grammar struct {
+	Name  string                   `json:"name" yaml:"name"`
+	Start string                   `json:"start,omitempty" yaml:"start,omitempty"`
+	Rules map[string][]Alternative `json:"rules" yaml:"rules"`
+}
+
+// With a copy of this grammar with the other grammar's rules added, replacing any with the same name
+func (g Grammar) With(other Grammar) Grammar {
+	rules := make(map[string][]Alternative, len(g.Rules)+len(other.Rules))
+	for name, alts := range g.Rules {
+		rules[name] = alts
+	}
+	for name, alts := range other.Rules {
+		rules[name] = alts
+	}
+	g.Rules = rules
+	if other.Start != "" {
+		g.Start = other.Start
+	}
+	return g
+}
+
+// Grammar this corpus' formula as a grammar. Column A is the rule `<A>`, column B `<B>` and so on
+func (c Corpus) Grammar() Grammar {
+	rules := make(map[string][]Alternative, len(c.Columns)+1)
+	parts := make([]string, 0, len(c.Columns)+2)
+	if c.Prefix != "" {
+		parts = append(parts, escapeText(c.Prefix))
+	}
+	for i, col := range c.Columns {
+		name := ColumnName(i)
+		parts = append(parts, "<"+name+">")
+		alts := make([]Alternative, len(col))
+		for j, word := range col {
+			alts[j] = Alternative{Text: escapeText(word)}
+		}
+		rules[name] = alts
+	}
+	if c.Postfix != "" {
+		parts = append(parts, escapeText(c.Postfix))
+	}
+	rules[StartRule] = []Alternative{{Text: strings.Join(parts, " ")}}
+
+	return Grammar{Name: c.Name, Start: StartRule, Rules: rules}
+}
+
+// ColumnName how column i of a corpus is named: A, B, C and so on
+func ColumnName(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return fmt.Sprintf("col%v", i+1)
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "<", `\<`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// ParseGrammar reads a grammar from json, or yaml when the extension is .yaml or .yml.
+// A grammar without a name is named after its file
+func ParseGrammar(data []byte, file string) (Grammar, error) {
+	var g Grammar
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &g)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &g)
+	default:
+		return Grammar{}, fmt.Errorf("grammar %v: unknown format %q, use .json .yaml or .yml", file, ext)
+	}
+	if err != nil {
+		return Grammar{}, fmt.Errorf("grammar %v: %w", file, err)
+	}
+
+	if g.Name == "" {
+		g.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	return g, nil
+}
+
+// LoadGrammar reads a grammar from a file
+func LoadGrammar(file string) (Grammar, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Grammar{}, err
+	}
+	return ParseGrammar(data, file)
+}
+
+// part of an alternative's text: literal text, a rule to expand, or optional parts
+type part struct {
+	text     string
+	rule     string
+	optional []part
+}
+
+// choice a parsed alternative
+type choice struct {
+	weight int
+	parts  []part
+}
+
+// GrammarGenerator generates sentences from a grammar
+type GrammarGenerator struct {
+	start string
+	rules map[string][]choice
+	rand  *Random
+}
+
+// NewGrammarGenerator constructs a GrammarGenerator. Every rule the grammar uses must be defined
+func NewGrammarGenerator(g Grammar) (GrammarGenerator, error) {
+	start := g.Start
+	if start == "" {
+		start = StartRule
+	}
+
+	// sorted, so the errors come out in the same order
+	names := make([]string, 0, len(g.Rules))
+	for name := range g.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	rules := make(map[string][]choice, len(g.Rules))
+	for _, name := range names {
+		alts := g.Rules[name]
+		if len(alts) == 0 {
+			errs = append(errs, fmt.Errorf("rule %v has no alternatives", name))
+		}
+		choices := make([]choice, 0, len(alts))
+		for i, alt := range alts {
+			if alt.Weight < 0 {
+				errs = append(errs, fmt.Errorf("rule %v alternative %v has a negative weight", name, i+1))
+				continue
+			}
+			parts, err := parseText(alt.Text)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("rule %v alternative %v: %w", name, i+1, err))
+				continue
+			}
+			choices = append(choices, choice{weight: max(alt.Weight, 1), parts: parts})
+		}
+		rules[name] = choices
+	}
+
+	if _, ok := g.Rules[start]; !ok {
+		errs = append(errs, fmt.Errorf("the start rule %v is not defined", start))
+	}
+	for _, name := range names {
+		for _, c := range rules[name] {
+			for _, ref := range refs(c.parts) {
+				if _, ok := g.Rules[ref]; !ok {
+					errs = append(errs, fmt.Errorf("rule %v uses <%v>, which is not defined", name, ref))
+				}
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return GrammarGenerator{}, fmt.Errorf("grammar %q: %w", g.Name, errors.Join(errs...))
+	}
+	return GrammarGenerator{
+		start: start,
+		rules: rules,
+		rand:  NewRandom(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// parseText splits an alternative's text into its parts
+func parseText(s string) ([]part, error) {
+	parts, rest, err := parseParts([]rune(s), false)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("] without a [")
+	}
+	return parts, nil
+}
+
+// parseParts parses until the end of the text, or the ] that closes an optional part
+func parseParts(s []rune, optional bool) ([]part, []rune, error) {
+	var parts []part
+	var text strings.Builder
+	flush := func() {
+		if text.Len() != 0 {
+			parts = append(parts, part{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for len(s) != 0 {
+		switch s[0] {
+		case '\\':
+			if len(s) < 2 {
+				return nil, nil, errors.New(`\ at the end`)
+			}
+			text.WriteRune(s[1])
+			s = s[2:]
+		case '<':
+			end := -1
+			for i, r := range s {
+				if r == '>' {
+					end = i
+					break
+				}
+			}
+			if end == -1 {
+				return nil, nil, errors.New("< without a >")
+			}
+			name := string(s[1:end])
+			if name == "" || strings.ContainsAny(name, " <[]") {
+				return nil, nil, fmt.Errorf("<%v> is not a rule name", name)
+			}
+			flush()
+			parts = append(parts, part{rule: name})
+			s = s[end+1:]
+		case '[':
+			flush()
+			inner, rest, err := parseParts(s[1:], true)
+			if err != nil {
+				return nil, nil, err
+			}
+			parts = append(parts, part{optional: inner})
+			s = rest
+		case ']':
+			if !optional {
+				return nil, s, nil
+			}
+			flush()
+			return parts, s[1:], nil
+		default:
+			text.WriteRune(s[0])
+			s = s[1:]
+		}
+	}
+
+	if optional {
+		return nil, nil, errors.New("[ without a ]")
+	}
+	flush()
+	return parts, nil, nil
+}
+
+// refs the rules that the parts use
+func refs(parts []part) []string {
+	var names []string
+	for _, p := range parts {
+		if p.rule != "" {
+			names = append(names, p.rule)
+		}
+		names = append(names, refs(p.optional)...)
+	}
+	return names
+}
+
+// Sentence expands the start rule
+func (g GrammarGenerator) Sentence() string {
+	if g.rand == nil {
+		return g.generate(rand.Intn)
+	}
+	return g.generate(g.rand.Intn)
+}
+
+// SentenceFor expands the start rule, the same every time for the same seed
+func (g GrammarGenerator) SentenceFor(seed int64) string {
+	return g.generate(rand.New(rand.NewSource(seed)).Intn)
+}
+
+// WithSource a copy of this generator that makes its choices with src
+func (g GrammarGenerator) WithSource(src rand.Source) GrammarGenerator {
+	g.rand = NewRandom(src)
+	return g
+}
+
+// WithSeed a copy of this generator that makes its choices from a source seeded with seed
+func (g GrammarGenerator) WithSeed(seed int64) GrammarGenerator {
+	return g.WithSource(rand.NewSource(seed))
+}
+
+func (g GrammarGenerator) generate(intn func(n int) int) string {
+	var b strings.Builder
+	g.expand(&b, g.start, intn, 0)
+	return tidy(b.String())
+}
+
+func (g GrammarGenerator) expand(b *strings.Builder, rule string, intn func(n int) int, depth int) {
+	if depth > maxDepth {
+		return
+	}
+	choices := g.rules[rule]
+	if len(choices) == 0 {
+		return
+	}
+	// nothing to choose, so nothing is drawn. A corpus' grammar makes the same sentence as its formula for a seed
+	if len(choices) == 1 {
+		g.write(b, choices[0].parts, intn, depth)
+		return
+	}
+
+	total := 0
+	for _, c := range choices {
+		total += c.weight
+	}
+	r := intn(total)
+	for _, c := range choices {
+		if r < c.weight {
+			g.write(b, c.parts, intn, depth)
+			return
+		}
+		r -= c.weight
+	}
+}
+
+func (g GrammarGenerator) write(b *strings.Builder, parts []part, intn func(n int) int, depth int) {
+	for _, p := range parts {
+		switch {
+		case p.rule != "":
+			g.expand(b, p.rule, intn, depth+1)
+		case p.optional != nil:
+			if intn(2) == 0 {
+				g.write(b, p.optional, intn, depth)
+			}
+		default:
+			b.WriteString(p.text)
+		}
+	}
+}
+
+var (
+	spaces       = regexp.MustCompile(`\s+`)
+	spacedPunct  = regexp.MustCompile(` ([,.!?;:])`)
+	articleRegex = regexp.MustCompile(`\b([Aa])/an(\s+)([[:alpha:]]+)`)
+)
+
+// tidy the spaces left by optional parts that were left out, and agree a/an with the word after it
+func tidy(s string) string {
+	s = spaces.ReplaceAllString(s, " ")
+	s = spacedPunct.ReplaceAllString(s, "$1")
+	s = articleRegex.ReplaceAllStringFunc(s, func(m string) string {
+		sub := articleRegex.FindStringSubmatch(m)
+		article := sub[1]
+		if vowelSound(sub[3]) {
+			article += "n"
+		}
+		return article + sub[2] + sub[3]
+	})
+	return strings.TrimSpace(s)
+}
+
+// vowelSound whether a word starts with a vowel sound, close enough for the kits' words
+func vowelSound(word string) bool {
+	w := strings.ToLower(word)
+	for _, prefix := range []string{"hour", "honest", "honour", "heir"} {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	for _, prefix := range []string{"uni", "use", "usu", "one", "eu"} {
+		if strings.HasPrefix(w, prefix) {
+			return false
+		}
+	}
+	return strings.ContainsRune("aeiou", rune(w[0]))
+}
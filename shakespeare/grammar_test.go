@@ -0,0 +1,137 @@
+package shakespeare
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrammarSentence(t *testing.T) {
+	testcases := []struct {
+		name     string
+		rules    map[string][]Alternative
+		expected string
+	}{
+		{"literal", map[string][]Alternative{StartRule: {{Text: "Thou knave!"}}}, "Thou knave!"},
+		{"nested", map[string][]Alternative{
+			StartRule: {{Text: "Away, thou <insult>!"}},
+			"insult":  {{Text: "<adj> <noun>"}},
+			"adj":     {{Text: "rank"}},
+			"noun":    {{Text: "varlot"}},
+		}, "Away, thou rank varlot!"},
+		{"a", map[string][]Alternative{StartRule: {{Text: "Thou art a/an <noun>."}}, "noun": {{Text: "pignut"}}}, "Thou art a pignut."},
+		{"an", map[string][]Alternative{StartRule: {{Text: "A/an <noun> art thou."}}, "noun": {{Text: "apple-john"}}}, "An apple-john art thou."},
+		{"an hour", map[string][]Alternative{StartRule: {{Text: "a/an hour, a/an unicorn"}}}, "an hour, a unicorn"},
+		{"escaped", map[string][]Alternative{StartRule: {{Text: `\<not a rule\> \[nor optional\]`}}}, "<not a rule> [nor optional]"},
+		{"never ends", map[string][]Alternative{StartRule: {{Text: "a <sentence>"}}}, "a a a a a a a a a a a a a a a a a"},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			gen, err := NewGrammarGenerator(Grammar{Name: test.name, Rules: test.rules})
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, gen.Sentence())
+		})
+	}
+}
+
+func TestGrammarChoices(t *testing.T) {
+	gen, err := NewGrammarGenerator(Grammar{Rules: map[string][]Alternative{
+		StartRule: {{Text: "Thou [<adj>] <noun>[, a/an <adj> one]!"}},
+		"adj":     {{Text: "artless", Weight: 3}, {Text: "bawdy"}},
+		"noun":    {{Text: "knave"}, {Text: "never", Weight: 0}, {Text: "ratsbane"}},
+	}})
+	assert.Nil(t, err)
+
+	seen := make(map[string]bool)
+	r := gen.WithSeed(1)
+	for i := 0; i < 500; i++ {
+		seen[r.Sentence()] = true
+	}
+	assert.True(t, seen["Thou knave!"], "the optional parts are left out")
+	assert.True(t, seen["Thou artless ratsbane, an artless one!"])
+	assert.True(t, seen["Thou bawdy knave, a bawdy one!"])
+	assert.Len(t, seen, 3*3*3, "every choice is made")
+
+	assert.Equal(t, gen.SentenceFor(7), gen.SentenceFor(7))
+}
+
+func TestGrammarWeights(t *testing.T) {
+	gen, err := NewGrammarGenerator(Grammar{Rules: map[string][]Alternative{
+		StartRule: {{Text: "often", Weight: 9}, {Text: "rarely"}},
+	}})
+	assert.Nil(t, err)
+
+	gen = gen.WithSource(rand.NewSource(42))
+	count := 0
+	for i := 0; i < 1000; i++ {
+		if gen.Sentence() == "often" {
+			count++
+		}
+	}
+	assert.InDelta(t, 900, count, 50)
+}
+
+func TestGrammarInvalid(t *testing.T) {
+	_, err := NewGrammarGenerator(Grammar{Name: "bad", Start: "insult", Rules: map[string][]Alternative{
+		"a": {},
+		"b": {{Text: "<missing>"}},
+		"c": {{Text: "[unclosed"}},
+		"d": {{Text: "unopened]"}},
+		"e": {{Text: "<unclosed"}},
+		"f": {{Text: "x", Weight: -1}},
+		"g": {{Text: "< >"}},
+	}})
+	assert.ErrorContains(t, err, `grammar "bad"`)
+	assert.ErrorContains(t, err, "rule a has no alternatives")
+	assert.ErrorContains(t, err, "rule b uses <missing>, which is not defined")
+	assert.ErrorContains(t, err, "rule c alternative 1: [ without a ]")
+	assert.ErrorContains(t, err, "rule d alternative 1: ] without a [")
+	assert.ErrorContains(t, err, "rule e alternative 1: < without a >")
+	assert.ErrorContains(t, err, "rule f alternative 1 has a negative weight")
+	assert.ErrorContains(t, err, "< > is not a rule name")
+	assert.ErrorContains(t, err, "the start rule insult is not defined")
+}
+
+func TestCorpusGrammar(t *testing.T) {
+	// a kit's grammar makes the same sentences as its formula
+	for _, name := range []string{InsultName, ComplimentName} {
+		c := mustDefault(name)
+		gen, err := NewGrammarGenerator(c.Grammar())
+		assert.Nil(t, err)
+		for seed := int64(0); seed < 20; seed++ {
+			assert.Equal(t, c.Generator().SentenceFor(seed), gen.SentenceFor(seed))
+		}
+	}
+
+	gen, err := NewGrammarGenerator(Corpus{Name: "odd", Prefix: "<pre>", Columns: [][]string{{"[a]"}, {`b\`}}, Postfix: "!"}.Grammar())
+	assert.Nil(t, err)
+	assert.Equal(t, `<pre> [a] b\!`, gen.Sentence())
+}
+
+func TestParseGrammar(t *testing.T) {
+	expected := Grammar{Name: "away", Start: "insult", Rules: map[string][]Alternative{
+		"insult": {{Text: "Away, thou <noun>!", Weight: 2}, {Text: "Thou <noun>!"}},
+		"noun":   {{Text: "knave"}},
+	}}
+
+	g, err := ParseGrammar([]byte(`
+start: insult
+rules:
+  insult:
+    - text: Away, thou <noun>!
+      weight: 2
+    - Thou <noun>!
+  noun: [knave]
+`), "away.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, g)
+
+	g, err = ParseGrammar([]byte(`{"name": "away", "start": "insult", "rules": {"insult": [{"text": "Away, thou <noun>!", "weight": 2}, "Thou <noun>!"], "noun": ["knave"]}}`), "other.json")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, g)
+
+	_, err = ParseGrammar(nil, "away.txt")
+	assert.ErrorContains(t, err, "unknown format")
+}
@@ -3,167 +3,8 @@ package shakespeare
 // Thou Thou
 const Thou = "Thou"
 
-var insultA = []string{
-	"artless",
-	"bawdy",
-	"beslubbering",
-	"bootless",
-	"churlish",
-	"cockered",
-	"clouted",
-	"craven",
-	"currish",
-	"dankish",
-	"dissembling",
-	"droning",
-	"errant",
-	"fawning",
-	"fobbing",
-	"froward",
-	"frothy",
-	"gleeking",
-	"goatish",
-	"gorbellied",
-	"impertinent",
-	"infectious",
-	"jarring",
-	"loggerheaded",
-	"lumpish",
-	"mammering",
-	"mangled",
-	"mewling",
-	"paunchy",
-	"pribbling",
-	"puking",
-	"puny",
-	"qualling",
-	"rank",
-	"reeky",
-	"roguish",
-	"ruttish",
-	"saucy",
-	"spleeny",
-	"spongy",
-	"surly",
-	"tottering",
-	"unmuzzled",
-	"vain",
-	"venomed",
-	"villainous",
-	"warped",
-	"wayward",
-	"weedy",
-	"yeasty",
-}
-
-var insultB = []string{
-	"base-court",
-	"bat-fowling",
-	"beef-witted",
-	"beetle-headed",
-	"boil-brained",
-	"clapper-clawed",
-	"clay-brained",
-	"common-kissing",
-	"crook-pated",
-	"dismal-dreaming",
-	"dizzy-eyed",
-	"doghearted",
-	"dread-bolted",
-	"earth-vexing",
-	"elf-skinned",
-	"fat-kidneyed",
-	"fen-sucked",
-	"flap-mouthed",
-	"fly-bitten",
-	"folly-fallen",
-	"fool-born",
-	"full-gorged",
-	"guts-griping",
-	"half-faced",
-	"hasty-witted",
-	"hedge-born",
-	"hell-hated",
-	"idle-headed",
-	"ill-breeding",
-	"ill-nurtured",
-	"knotty-pated",
-	"milk-livered",
-	"motley-minded",
-	"onion-eyed",
-	"plume-plucked",
-	"pottle-deep",
-	"pox-marked",
-	"reeling-ripe",
-	"rough-hewn",
-	"rude-growing",
-	"rump-fed",
-	"shard-borne",
-	"sheep-biting",
-	"spur-galled",
-	"swag-bellied",
-	"tardy-gaited",
-	"tickle-brained",
-	"toad-spotted",
-	"unchin-snouted",
-	"weather-bitten",
-}
-
-var insultC = []string{
-	"apple-john",
-	"baggage",
-	"barnacle",
-	"bladder",
-	"boar-pig",
-	"bugbear",
-	"bum-bailey",
-	"canker-blossom",
-	"clack-dish",
-	"clotpole",
-	"coxcomb",
-	"codpiece",
-	"death-token",
-	"dewberry",
-	"flap-dragon",
-	"flax-wench",
-	"flirt-gill",
-	"foot-licker",
-	"fustilarian",
-	"giglet",
-	"gudgeon",
-	"haggard",
-	"harpy",
-	"hedge-pig",
-	"horn-beast",
-	"hugger-mugger",
-	"joithead",
-	"lewdster",
-	"lout",
-	"maggot-pie",
-	"malt-worm",
-	"mammet",
-	"measle",
-	"minnow",
-	"miscreant",
-	"moldwarp",
-	"mumble-news",
-	"nut-hook",
-	"pigeon-egg",
-	"pignut",
-	"puttock",
-	"pumpion",
-	"ratsbane",
-	"scut",
-	"skainsmate",
-	"strumpet",
-	"varlot",
-	"vassal",
-	"whey-face",
-	"wagtail",
-}
-
 // InsultGenerator Generator for Shakespearean Insults
-var InsultGenerator = New(Thou, "", [][]string{insultA, insultB, insultC})
+var InsultGenerator = mustDefault(InsultName).Generator()
 
 // Insult randomly generates a Shakespearean Insult
 func Insult() string {
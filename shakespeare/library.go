@@ -0,0 +1,161 @@
+package shakespeare
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// reloadDelay editors write a file in several steps, so a reload waits for them to settle
+const reloadDelay = 100 * time.Millisecond
+
+// Reloadable a Generator whose words can be swapped while it is in use
+type Reloadable struct {
+	gen atomic.Pointer[FormulaGenerator]
+}
+
+// NewReloadable constructs a Reloadable that starts with g's words
+func NewReloadable(g FormulaGenerator) *Reloadable {
+	r := &Reloadable{}
+	r.Store(g)
+	return r
+}
+
+// Store swaps in g's words
+func (r *Reloadable) Store(g FormulaGenerator) {
+	r.gen.Store(&g)
+}
+
+// Sentence the result of the current formula
+func (r *Reloadable) Sentence() string {
+	return r.gen.Load().Sentence()
+}
+
+// SentenceFor the result of the current formula, the same every time for the same seed
+func (r *Reloadable) SentenceFor(seed int64) string {
+	return r.gen.Load().SentenceFor(seed)
+}
+
+// Library the generators for the built in corpora and the word list files, which can be reloaded
+type Library struct {
+	files      []string
+	mu         sync.Mutex
+	corpora    map[string]Corpus
+	generators map[string]*Reloadable
+}
+
+// NewLibrary loads the built in corpora and the word list files
+func NewLibrary(files ...string) (*Library, error) {
+	l := &Library{files: files, generators: make(map[string]*Reloadable)}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Generator the generator for the named corpus
+func (l *Library) Generator(name string) (*Reloadable, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.generators[name]
+	return r, ok
+}
+
+// Corpus the words in use for the named corpus
+func (l *Library) Corpus(name string) (Corpus, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, ok := l.corpora[name]
+	return c, ok
+}
+
+// Reload reads the word list files again. When one of them is invalid, the words in use are kept
+func (l *Library) Reload() error {
+	corpora, err := LoadCorpora(l.files...)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.corpora = corpora
+	for name, c := range corpora {
+		if r, ok := l.generators[name]; ok {
+			r.Store(c.Generator())
+		} else {
+			l.generators[name] = NewReloadable(c.Generator())
+		}
+	}
+	return nil
+}
+
+// Watch reloads the word lists when one of their files changes or a signal arrives on hup, until ctx is done
+func (l *Library) Watch(ctx context.Context, hup <-chan os.Signal) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// the directories are watched, as editors and config maps replace a file rather than write to it
+	files := make(map[string]bool, len(l.files))
+	for _, file := range l.files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		files[abs] = true
+	}
+	dirs := make(map[string]bool)
+	for file := range files {
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		dirs[dir] = true
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	reload := func(why string) {
+		if err := l.Reload(); err != nil {
+			log.Errorf("Unable to reload the word lists after %v, keeping the old words: %v", why, err)
+			return
+		}
+		log.Infof("Reloaded the word lists after %v", why)
+	}
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case sig := <-hup:
+			reload(sig.String())
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			abs, _ := filepath.Abs(event.Name)
+			if files[abs] && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				timer.Reset(reloadDelay)
+			}
+		case <-timer.C:
+			reload("a change to the files")
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warnf("Watching the word lists: %v", err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultAPIURL base url of the slack web api
+const DefaultAPIURL = "https://slack.com/api"
+
+// Client posts messages back to slack
+type Client interface {
+	PostMessage(channel string, res Response) error
+	PostEphemeral(channel, user string, res Response) error
+}
+
+// Directory looks up who is in a user group or a channel
+type Directory interface {
+	UserGroupMembers(group string) ([]string, error)
+	ChannelMembers(channel string) ([]string, error)
+	IsActive(user string) (bool, error)
+}
+
+// WebClient a Client (and Directory) backed by the slack web api, authenticated with a bot token
+type WebClient struct {
+	token   string
+	baseURL string
+	http    *http.Client
+}
+
+// NewWebClient factory method
+func NewWebClient(token string) WebClient {
+	return WebClient{
+		token:   token,
+		baseURL: DefaultAPIURL,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithBaseURL points the client at a different api host, such as a local fake
+func (w WebClient) WithBaseURL(url string) WebClient {
+	w.baseURL = url
+	return w
+}
+
+type postMessage struct {
+	Channel     string        `json:"channel"`
+	User        string        `json:"user,omitempty"`
+	Text        string        `json:"text,omitempty"`
+	Attachments []Attachments `json:"attachments,omitempty"`
+}
+
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// PostMessage chat.postMessage, visible to the whole channel
+func (w WebClient) PostMessage(channel string, res Response) error {
+	return w.post("chat.postMessage", postMessage{
+		Channel:     channel,
+		Text:        res.Text,
+		Attachments: res.Attachments,
+	}, nil)
+}
+
+// PostEphemeral chat.postEphemeral, visible only to the user
+func (w WebClient) PostEphemeral(channel, user string, res Response) error {
+	return w.post("chat.postEphemeral", postMessage{
+		Channel:     channel,
+		User:        user,
+		Text:        res.Text,
+		Attachments: res.Attachments,
+	}, nil)
+}
+
+type usersList struct {
+	Users []string `json:"users"`
+}
+
+type membersList struct {
+	Members  []string `json:"members"`
+	Metadata struct {
+		NextCursor string `json:"next_cursor"`
+	} `json:"response_metadata"`
+}
+
+type presence struct {
+	Presence string `json:"presence"`
+}
+
+// UserGroupMembers usergroups.users.list, the users in a user group
+func (w WebClient) UserGroupMembers(group string) ([]string, error) {
+	var out usersList
+	if err := w.get("usergroups.users.list", url.Values{"usergroup": {group}}, &out); err != nil {
+		return nil, err
+	}
+	return out.Users, nil
+}
+
+// ChannelMembers conversations.members, every page of the users in a channel
+func (w WebClient) ChannelMembers(channel string) ([]string, error) {
+	var members []string
+	params := url.Values{"channel": {channel}, "limit": {"200"}}
+	for {
+		var out membersList
+		if err := w.get("conversations.members", params, &out); err != nil {
+			return nil, err
+		}
+		members = append(members, out.Members...)
+
+		if out.Metadata.NextCursor == "" {
+			return members, nil
+		}
+		params.Set("cursor", out.Metadata.NextCursor)
+	}
+}
+
+// IsActive users.getPresence, whether the user is online right now
+func (w WebClient) IsActive(user string) (bool, error) {
+	var out presence
+	if err := w.get("users.getPresence", url.Values{"user": {user}}, &out); err != nil {
+		return false, err
+	}
+	return out.Presence == "active", nil
+}
+
+// post calls a web api method with a json body, decoding the reply into out (if not nil)
+func (w WebClient) post(method string, body interface{}, out interface{}) error {
+	j, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.baseURL+"/"+method, bytes.NewReader(j))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return w.do(method, req, out)
+}
+
+// get calls a web api method that reads, with its arguments in the query string
+func (w WebClient) get(method string, params url.Values, out interface{}) error {
+	req, err := http.NewRequest("GET", w.baseURL+"/"+method+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return w.do(method, req, out)
+}
+
+// do sends an authenticated request, checking slack's ok flag before decoding the reply into out (if not nil)
+func (w WebClient) do(method string, req *http.Request, out interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+w.token)
+
+	res, err := w.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack %v returned http status %v", method, res.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&raw); err != nil {
+		return err
+	}
+
+	var status apiResponse
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return err
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %v failed: %v", method, status.Error)
+	}
+
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeSlackAPI(t *testing.T, reply string, requests map[string]postMessage) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+
+		var body postMessage
+		err := json.NewDecoder(r.Body).Decode(&body)
+		assert.Nil(t, err)
+		requests[r.URL.Path] = body
+
+		w.Write([]byte(reply))
+	}))
+}
+
+func TestWebClientPostMessage(t *testing.T) {
+	requests := make(map[string]postMessage)
+	srv := fakeSlackAPI(t, `{"ok":true}`, requests)
+	defer srv.Close()
+
+	client := NewWebClient("xoxb-token").WithBaseURL(srv.URL)
+	err := client.PostMessage("CGENERAL", ChannelAttachmentsResponse("hello", "there"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, postMessage{
+		Channel:     "CGENERAL",
+		Text:        "hello",
+		Attachments: NewAttachments("there"),
+	}, requests["/chat.postMessage"])
+}
+
+func TestWebClientPostEphemeral(t *testing.T) {
+	requests := make(map[string]postMessage)
+	srv := fakeSlackAPI(t, `{"ok":true}`, requests)
+	defer srv.Close()
+
+	client := NewWebClient("xoxb-token").WithBaseURL(srv.URL)
+	err := client.PostEphemeral("CGENERAL", "UCALLER", ErrorResponse("psst"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, postMessage{
+		Channel: "CGENERAL",
+		User:    "UCALLER",
+		Text:    "psst",
+	}, requests["/chat.postEphemeral"])
+}
+
+func TestWebClientError(t *testing.T) {
+	requests := make(map[string]postMessage)
+	srv := fakeSlackAPI(t, `{"ok":false,"error":"channel_not_found"}`, requests)
+	defer srv.Close()
+
+	client := NewWebClient("xoxb-token").WithBaseURL(srv.URL)
+	err := client.PostMessage("CNOPE", ChannelResponse("hello"))
+	assert.EqualError(t, err, "slack chat.postMessage failed: channel_not_found")
+}
+
+func TestWebClientDirectory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/usergroups.users.list":
+			assert.Equal(t, "S123", q.Get("usergroup"))
+			w.Write([]byte(`{"ok":true,"users":["UA","UB"]}`))
+		case "/conversations.members":
+			assert.Equal(t, "CGENERAL", q.Get("channel"))
+			// two pages
+			if q.Get("cursor") == "" {
+				w.Write([]byte(`{"ok":true,"members":["UA"],"response_metadata":{"next_cursor":"page2"}}`))
+				return
+			}
+			assert.Equal(t, "page2", q.Get("cursor"))
+			w.Write([]byte(`{"ok":true,"members":["UB","UC"],"response_metadata":{"next_cursor":""}}`))
+		case "/users.getPresence":
+			w.Write([]byte(`{"ok":true,"presence":"` + map[string]string{"UA": "active"}[q.Get("user")] + `"}`))
+		default:
+			w.Write([]byte(`{"ok":false,"error":"unknown_method"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewWebClient("xoxb-token").WithBaseURL(srv.URL)
+
+	users, err := client.UserGroupMembers("S123")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UA", "UB"}, users)
+
+	members, err := client.ChannelMembers("CGENERAL")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UA", "UB", "UC"}, members)
+
+	active, err := client.IsActive("UA")
+	assert.Nil(t, err)
+	assert.True(t, active)
+
+	active, err = client.IsActive("UB")
+	assert.Nil(t, err)
+	assert.False(t, active)
+}
+
+func TestFakeClientDirectory(t *testing.T) {
+	fake := NewFakeClient()
+	fake.Groups["S123"] = []string{"UA"}
+
+	users, err := fake.UserGroupMembers("S123")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"UA"}, users)
+
+	_, err = fake.UserGroupMembers("S999")
+	assert.NotNil(t, err)
+	_, err = fake.ChannelMembers("CNOPE")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,43 @@
+package slack
+
+// Events API envelope types
+// https://api.slack.com/events-api#receiving_events
+const (
+	EventURLVerification = "url_verification"
+	EventCallback        = "event_callback"
+)
+
+// EventMessage the inner event type for messages posted to a channel
+const EventMessage = "message"
+
+// HeaderRetryNum set by slack when it is re-delivering an event we did not acknowledge in time
+const HeaderRetryNum = "X-Slack-Retry-Num"
+
+// EventEnvelope the outer payload of every Events API request
+type EventEnvelope struct {
+	Token     string       `json:"token,omitempty"`
+	Challenge string       `json:"challenge,omitempty"`
+	Type      string       `json:"type,omitempty"`
+	TeamID    string       `json:"team_id,omitempty"`
+	EventID   string       `json:"event_id,omitempty"`
+	Event     MessageEvent `json:"event,omitempty"`
+}
+
+// MessageEvent a message posted to a channel (message.channels)
+type MessageEvent struct {
+	Type        string `json:"type,omitempty"`
+	Subtype     string `json:"subtype,omitempty"`
+	Team        string `json:"team,omitempty"`
+	Channel     string `json:"channel,omitempty"`
+	ChannelType string `json:"channel_type,omitempty"`
+	User        string `json:"user,omitempty"`
+	BotID       string `json:"bot_id,omitempty"`
+	Text        string `json:"text,omitempty"`
+	TS          string `json:"ts,omitempty"`
+}
+
+// IsUserMessage true for plain messages typed by a person.
+// Bot messages, edits, joins and the like all carry a subtype or bot id
+func (m MessageEvent) IsUserMessage() bool {
+	return m.Type == EventMessage && m.Subtype == "" && m.BotID == "" && m.User != ""
+}
@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"errors"
+	"sync"
+)
+
+// Posted a message that was sent through the FakeClient
+type Posted struct {
+	Channel  string
+	User     string
+	Response Response
+}
+
+// FakeClient an in-memory Client that records everything posted to it, for tests.
+// It is a Directory too, of the user groups and channels it is given. Only the Active users are online
+type FakeClient struct {
+	mu        sync.Mutex
+	Messages  []Posted
+	Ephemeral []Posted
+	Err       error
+
+	Groups   map[string][]string
+	Channels map[string][]string
+	Active   map[string]bool
+}
+
+// NewFakeClient factory method
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		Groups:   make(map[string][]string),
+		Channels: make(map[string][]string),
+		Active:   make(map[string]bool),
+	}
+}
+
+// PostMessage records a channel message
+func (f *FakeClient) PostMessage(channel string, res Response) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Messages = append(f.Messages, Posted{Channel: channel, Response: res})
+	return nil
+}
+
+// PostEphemeral records an ephemeral message
+func (f *FakeClient) PostEphemeral(channel, user string, res Response) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Ephemeral = append(f.Ephemeral, Posted{Channel: channel, User: user, Response: res})
+	return nil
+}
+
+// UserGroupMembers the members of a user group it was given
+func (f *FakeClient) UserGroupMembers(group string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	members, ok := f.Groups[group]
+	if !ok {
+		return nil, errors.New("slack usergroups.users.list failed: no_such_subteam")
+	}
+	return members, nil
+}
+
+// ChannelMembers the members of a channel it was given
+func (f *FakeClient) ChannelMembers(channel string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	members, ok := f.Channels[channel]
+	if !ok {
+		return nil, errors.New("slack conversations.members failed: channel_not_found")
+	}
+	return members, nil
+}
+
+// IsActive whether the user is one of the Active ones
+func (f *FakeClient) IsActive(user string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return false, f.Err
+	}
+	return f.Active[user], nil
+}
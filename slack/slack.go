@@ -1,6 +1,9 @@
 package slack
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+)
 
 // CommandData data payload for a slash command
 type CommandData struct {
@@ -112,3 +115,37 @@ func IsSlackUser(userID string) (string, bool) {
 
 	return "", false
 }
+
+// group kinds
+const (
+	GroupSubteam = "subteam"
+	GroupHere    = "here"
+	GroupChannel = "channel"
+)
+
+// Group a mention of several users at once: a user group, @here or @channel.
+// ID is the user group's id, Label is how to name it without notifying everyone again, like @devs
+type Group struct {
+	Kind  string
+	ID    string
+	Label string
+}
+
+var escapedGroup = regexp.MustCompile(`^<!(here|channel|subteam\^(S[A-Z0-9]+))(?:\|([^>]*))?>$`)
+
+// IsSlackGroup returns the group of an escaped group mention. <!subteam^S0614TZR7|@devs> => user group S0614TZR7.
+// @everyone is not a group, it is the whole workspace
+func IsSlackGroup(s string) (Group, bool) {
+	m := escapedGroup.FindStringSubmatch(s)
+	if m == nil {
+		return Group{}, false
+	}
+
+	switch {
+	case m[1] == GroupHere, m[1] == GroupChannel:
+		return Group{Kind: m[1], Label: "@" + m[1]}, true
+	case m[3] != "":
+		return Group{Kind: GroupSubteam, ID: m[2], Label: "@" + strings.TrimPrefix(m[3], "@")}, true
+	}
+	return Group{Kind: GroupSubteam, ID: m[2], Label: "@" + m[2]}, true
+}
@@ -27,3 +27,33 @@ func TestIsSlackUser(t *testing.T) {
 		})
 	}
 }
+
+func TestIsSlackGroup(t *testing.T) {
+	testCases := []struct {
+		name     string
+		group    string
+		ok       bool
+		expected Group
+	}{
+		{"user group", "<!subteam^S0614TZR7|@devs>", true, Group{Kind: GroupSubteam, ID: "S0614TZR7", Label: "@devs"}},
+		{"user group without a label", "<!subteam^S0614TZR7>", true, Group{Kind: GroupSubteam, ID: "S0614TZR7", Label: "@S0614TZR7"}},
+		{"here", "<!here>", true, Group{Kind: GroupHere, Label: "@here"}},
+		{"here with a label", "<!here|here>", true, Group{Kind: GroupHere, Label: "@here"}},
+		{"channel", "<!channel>", true, Group{Kind: GroupChannel, Label: "@channel"}},
+		{"everyone", "<!everyone>", false, Group{}},
+		{"user", "<@UAWQFTRT7>", false, Group{}},
+		{"plain", "@here", false, Group{}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok := IsSlackGroup(test.group)
+			if ok != test.ok {
+				t.Errorf("Expecting %v but actual %v\n", test.ok, ok)
+			}
+			if actual != test.expected {
+				t.Errorf("Expecting %v but actual %v\n", test.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func TestSign(t *testing.T) {
+	// example from https://api.slack.com/authentication/verifying-requests-from-slack
+	body := "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	actual := Sign(testSecret, 1531420618, []byte(body))
+	assert.Equal(t, "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503", actual)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1531420618, 0)
+	body := []byte("text=hello")
+	sig := Sign(testSecret, now.Unix(), body)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	testcases := []struct {
+		name     string
+		sig      string
+		ts       string
+		clock    time.Time
+		expected error
+	}{
+		{"valid", sig, ts, now, nil},
+		{"valid within window", sig, ts, now.Add(4 * time.Minute), nil},
+		{"missing signature", "", ts, now, ErrMissingSignature},
+		{"missing timestamp", sig, "", now, ErrMissingSignature},
+		{"malformed timestamp", sig, "yesterday", now, ErrInvalidTimestamp},
+		{"replay", sig, ts, now.Add(10 * time.Minute), ErrStaleTimestamp},
+		{"future", sig, ts, now.Add(-10 * time.Minute), ErrStaleTimestamp},
+		{"wrong signature", Sign("wrong", now.Unix(), body), ts, now, ErrInvalidSignature},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			v := NewVerifier(testSecret, 0)
+			v.now = func() time.Time { return test.clock }
+
+			header := http.Header{}
+			header.Set(HeaderSignature, test.sig)
+			header.Set(HeaderTimestamp, test.ts)
+
+			actual := v.Verify(header, body)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	r := gin.New()
+	r.POST("/cmd", NewVerifier(testSecret, time.Minute).Middleware(), func(c *gin.Context) {
+		c.String(200, c.PostForm("text"))
+	})
+
+	body := "text=hello"
+
+	testcases := []struct {
+		name     string
+		secret   string
+		code     int
+		expected string
+	}{
+		{"signed", testSecret, 200, "hello"},
+		{"wrong secret", "wrong", 401, `{"error":"slack signature does not match"}`},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := NewSignedRequest("POST", "/cmd", test.secret, []byte(body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, test.expected, w.Body.String())
+		})
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/cmd", strings.NewReader(body))
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 401, w.Code)
+	})
+}
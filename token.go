@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/icemanblues/knave-bot/karma"
+)
+
+const tokenUsage = `usage: knave-bot token create -team T -scopes read,write [-name N]
+       knave-bot token list [-team T]
+       knave-bot token revoke <id>`
+
+// runToken the `knave-bot token` subcommand, to mint, list and revoke api tokens for the REST api
+// a minted token is printed once, only its hash is saved
+func runToken(args []string, dao karma.DAO, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(tokenUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("token create", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		team := fs.String("team", "", "the team the token is for, * for every team")
+		scopes := fs.String("scopes", string(karma.ScopeRead), "comma separated scopes: read, write, admin")
+		name := fs.String("name", "", "what the token is for")
+		if err := fs.Parse(args[1:]); err != nil {
+			return fmt.Errorf("%v\n%v", err, tokenUsage)
+		}
+		if *team == "" {
+			return errors.New("a token needs a -team, or * for every team")
+		}
+		s, err := karma.ParseScopes(*scopes)
+		if err != nil {
+			return err
+		}
+
+		plain, hash, err := karma.NewToken()
+		if err != nil {
+			return err
+		}
+		t, err := dao.CreateToken(karma.APIToken{Name: *name, Team: *team, Scopes: s}, hash)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created token %v for team %v with scopes %v. It won't be shown again:\n%v\n", t.ID, t.Team, *scopes, plain)
+		return nil
+
+	case "list":
+		fs := flag.NewFlagSet("token list", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		team := fs.String("team", "", "only the tokens of this team")
+		if err := fs.Parse(args[1:]); err != nil {
+			return fmt.Errorf("%v\n%v", err, tokenUsage)
+		}
+
+		tokens, err := dao.Tokens(*team)
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			status := "active"
+			if t.RevokedAt != nil {
+				status = "revoked " + t.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%-4v %-20v %-12v %-18v %v\n", t.ID, t.Name, t.Team, fmt.Sprint(t.Scopes), status)
+		}
+		return nil
+
+	case "revoke":
+		if len(args) < 2 {
+			return errors.New(tokenUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("id must be a number: %v", args[1])
+		}
+		if err := dao.RevokeToken(id); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "revoked token %v\n", id)
+		return nil
+	}
+
+	return errors.New(tokenUsage)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/icemanblues/knave-bot/karma"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunToken(t *testing.T) {
+	_, dao, err := karma.Open(karma.DriverSQLite, filepath.Join(t.TempDir(), "karma.db"))
+	assert.Nil(t, err)
+	out := &strings.Builder{}
+
+	err = runToken([]string{"create", "-team", "T1", "-scopes", "read,write", "-name", "ci"}, dao, out)
+	assert.Nil(t, err)
+	token := regexp.MustCompile(`kb_[0-9a-f]+`).FindString(out.String())
+	assert.NotEmpty(t, token)
+
+	minted, err := dao.TokenByHash(karma.HashToken(token))
+	assert.Nil(t, err)
+	assert.Equal(t, "T1", minted.Team)
+	assert.Equal(t, []karma.Scope{karma.ScopeRead, karma.ScopeWrite}, minted.Scopes)
+
+	out.Reset()
+	err = runToken([]string{"list"}, dao, out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "ci")
+	assert.Contains(t, out.String(), "active")
+	assert.NotContains(t, out.String(), token)
+
+	out.Reset()
+	err = runToken([]string{"revoke", "1"}, dao, out)
+	assert.Nil(t, err)
+	assert.Equal(t, "revoked token 1\n", out.String())
+	_, err = dao.TokenByHash(karma.HashToken(token))
+	assert.ErrorIs(t, err, karma.ErrUnknownToken)
+
+	out.Reset()
+	err = runToken([]string{"list", "-team", "T1"}, dao, out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "revoked")
+
+	for _, args := range [][]string{
+		{},
+		{"sideways"},
+		{"create"},
+		{"create", "-team", "T1", "-scopes", "delete"},
+		{"create", "-nope"},
+		{"revoke"},
+		{"revoke", "one"},
+		{"revoke", "1"},
+	} {
+		err = runToken(args, dao, out)
+		assert.NotNil(t, err, args)
+	}
+}